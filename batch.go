@@ -0,0 +1,133 @@
+package passgen
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"unicode/utf8"
+)
+
+// maxDuplicateAttempts is the least number of duplicates in a row GenerateN
+// with WithUnique discards before giving up. The last passwords of a batch
+// exhausting a keyspace of K take about K draws each, so the limit grows to
+// duplicateFactor*K for larger keyspaces. Reaching it means that fewer
+// passwords can be drawn than the keyspace holds, such as when a breach filter
+// rejects some of them.
+const (
+	maxDuplicateAttempts = 1000
+	duplicateFactor      = 20
+)
+
+// maxBatchBytes bounds the encoded size of the passwords of a batch, which
+// GenerateN allocates up front.
+const maxBatchBytes = 1 << 30
+
+type BatchOption func(*batchConfig)
+
+type batchConfig struct {
+	unique bool
+}
+
+// WithUnique guarantees that no password appears twice within the batch.
+func WithUnique() BatchOption {
+	return func(c *batchConfig) {
+		c.unique = true
+	}
+}
+
+// GenerateN generates n passwords. Each is drawn as Generate draws it, so a
+// deterministic reader gives the same passwords as n calls to Generate, and
+// all passwords share one backing allocation. With
+// WithUnique, duplicates are discarded and regenerated, and GenerateN fails
+// once too many come in a row.
+func (g *Generator) GenerateN(n int, opts ...BatchOption) ([]string, error) {
+	cfg := &batchConfig{}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	size := g.cfg.length * g.width
+	if n < 0 {
+		return nil, fmt.Errorf("batch size cannot be negative, got %d", n)
+	}
+	if n > maxBatchBytes/size {
+		return nil, fmt.Errorf("batch size cannot exceed %d for passwords of up to %d bytes, got %d", maxBatchBytes/size, size, n)
+	}
+	// Only a keyspace within a few bits of n needs the exact count.
+	if cfg.unique && g.log2Keyspace() < math.Log2(float64(n))+1 && g.keyspace().Cmp(big.NewInt(int64(n))) < 0 {
+		return nil, fmt.Errorf("cannot generate %d unique passwords from a keyspace of %v", n, g.keyspace())
+	}
+
+	r := newSource(nil, sourceSize(g.cfg.length))
+	pass := make([]rune, g.cfg.length)
+	defer func() {
+		clear(pass)
+		r.reset(nil)
+	}()
+	pool := make([]byte, 0, n*size)
+	offsets := make([]int, 1, n+1)
+
+	var seen map[string]struct{}
+	limit := maxDuplicateAttempts
+	if cfg.unique {
+		seen = make(map[string]struct{}, n)
+		if k := math.Exp2(g.log2Keyspace()) * duplicateFactor; k > float64(limit) {
+			limit = math.MaxInt
+			if k < math.MaxInt {
+				limit = int(k)
+			}
+		}
+	}
+
+	duplicates := 0
+	for len(offsets) <= n {
+		// Like getScratch, start every password from a fresh buffer.
+		r.reset(g.cfg.rand)
+		if err := g.generate(r, pass); err != nil {
+			return nil, fmt.Errorf("failed to generate password %d: %w", len(offsets)-1, err)
+		}
+
+		start := len(pool)
+		for _, c := range pass {
+			pool = utf8.AppendRune(pool, c)
+		}
+
+		if seen != nil {
+			if _, dup := seen[string(pool[start:])]; dup {
+				pool = pool[:start]
+				if duplicates++; duplicates >= limit {
+					return nil, fmt.Errorf("failed to generate password %d: %d duplicates in a row, too few distinct passwords can be drawn", len(offsets)-1, duplicates)
+				}
+				continue
+			}
+			duplicates = 0
+			seen[string(pool[start:])] = struct{}{}
+		}
+
+		offsets = append(offsets, len(pool))
+	}
+
+	all := string(pool)
+	passwords := make([]string, n)
+	for i := range passwords {
+		passwords[i] = all[offsets[i]:offsets[i+1]]
+	}
+
+	return passwords, nil
+}
+
+// utf8Width returns the largest UTF-8 encoded size of the characters in
+// charset. An invalid rune counts as utf8.RuneError, which is what
+// utf8.EncodeRune writes in its place.
+func utf8Width(charset []rune) int {
+	width := 1
+	for _, c := range charset {
+		n := utf8.RuneLen(c)
+		if n < 0 {
+			n = utf8.RuneLen(utf8.RuneError)
+		}
+		width = max(width, n)
+	}
+
+	return width
+}
@@ -0,0 +1,178 @@
+package passgen
+
+import (
+	"errors"
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestGenerateN(t *testing.T) {
+	digitsOnly := []Option{WithLength(2), WithoutUppercase(), WithoutLowercase(), WithoutSymbols()}
+
+	tests := []struct {
+		name           string
+		options        []Option
+		n              int
+		batchOptions   []BatchOption
+		expectedLength int
+		expectUnique   bool
+		expectError    bool
+		errorMsg       string
+	}{
+		{
+			name:           "default batch",
+			options:        nil,
+			n:              100,
+			expectedLength: 16,
+		},
+		{
+			name:           "empty batch",
+			options:        nil,
+			n:              0,
+			expectedLength: 16,
+		},
+		{
+			name:           "unique batch",
+			options:        []Option{WithLength(12), WithMinRequirements(1, 1, 1, 1)},
+			n:              500,
+			batchOptions:   []BatchOption{WithUnique()},
+			expectedLength: 12,
+			expectUnique:   true,
+		},
+		{
+			name:           "unique batch exhausting the keyspace",
+			options:        digitsOnly,
+			n:              100,
+			batchOptions:   []BatchOption{WithUnique()},
+			expectedLength: 2,
+			expectUnique:   true,
+		},
+		{
+			name:           "non-ascii characters",
+			options:        []Option{WithLength(8), WithoutUppercase(), WithoutSymbols(), WithCharset("cyrillic", CharsetFromRange('а', 'я'), 2)},
+			n:              50,
+			batchOptions:   []BatchOption{WithUnique()},
+			expectedLength: 8,
+			expectUnique:   true,
+		},
+		{
+			name:         "keyspace too small",
+			options:      digitsOnly,
+			n:            101,
+			batchOptions: []BatchOption{WithUnique()},
+			expectError:  true,
+			errorMsg:     "cannot generate 101 unique passwords from a keyspace of 100",
+		},
+		{
+			name:        "negative batch size",
+			options:     nil,
+			n:           -1,
+			expectError: true,
+			errorMsg:    "batch size cannot be negative, got -1",
+		},
+		{
+			name:        "batch size too large",
+			options:     nil,
+			n:           math.MaxInt,
+			expectError: true,
+			errorMsg:    "batch size cannot exceed 67108864 for passwords of up to 16 bytes",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gen, err := NewGenerator(tt.options...)
+			if err != nil {
+				t.Fatalf("failed to create generator: %v", err)
+			}
+
+			passwords, err := gen.GenerateN(tt.n, tt.batchOptions...)
+
+			if tt.expectError {
+				if err == nil {
+					t.Errorf("expected error but got none")
+					return
+				}
+				if !strings.Contains(err.Error(), tt.errorMsg) {
+					t.Errorf("expected error message to contain %q, got %q", tt.errorMsg, err.Error())
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(passwords) != tt.n {
+				t.Fatalf("expected %d passwords, got %d", tt.n, len(passwords))
+			}
+
+			seen := make(map[string]bool, len(passwords))
+			for _, password := range passwords {
+				if n := len([]rune(password)); n != tt.expectedLength {
+					t.Errorf("expected length %d, got %d: %q", tt.expectedLength, n, password)
+				}
+				if err := gen.Validate(password); err != nil {
+					t.Errorf("password %q does not satisfy the policy: %v", password, err)
+				}
+				if tt.expectUnique && seen[password] {
+					t.Errorf("duplicate password %q", password)
+				}
+				seen[password] = true
+			}
+		})
+	}
+}
+
+func TestGenerateNReaderError(t *testing.T) {
+	gen, err := NewGenerator(WithRandReader(errReader{err: errors.New("device unplugged")}))
+	if err != nil {
+		t.Fatalf("failed to create generator: %v", err)
+	}
+
+	_, err = gen.GenerateN(10)
+	if err == nil || !strings.Contains(err.Error(), "failed to generate password 0") || !strings.Contains(err.Error(), "device unplugged") {
+		t.Errorf("expected wrapped reader error, got %v", err)
+	}
+}
+
+func BenchmarkGenerateN(b *testing.B) {
+	benchmarks := []struct {
+		name    string
+		options []BatchOption
+	}{
+		{
+			name:    "batch_1000",
+			options: nil,
+		},
+		{
+			name:    "batch_1000_unique",
+			options: []BatchOption{WithUnique()},
+		},
+	}
+
+	gen, err := NewGenerator()
+	if err != nil {
+		b.Fatalf("failed to create generator: %v", err)
+	}
+
+	for _, bm := range benchmarks {
+		b.Run(bm.name, func(b *testing.B) {
+			for b.Loop() {
+				if _, err := gen.GenerateN(1000, bm.options...); err != nil {
+					b.Fatalf("failed to generate passwords: %v", err)
+				}
+			}
+		})
+	}
+
+	b.Run("loop_1000", func(b *testing.B) {
+		for b.Loop() {
+			for range 1000 {
+				if _, err := gen.Generate(); err != nil {
+					b.Fatalf("failed to generate password: %v", err)
+				}
+			}
+		}
+	})
+}
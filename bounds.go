@@ -0,0 +1,153 @@
+package passgen
+
+import (
+	"crypto/rand"
+	"fmt"
+	"io"
+	"math/big"
+)
+
+// composition counts the passwords when some classes have a maximum that the
+// length can exceed: it sums, over how many characters the bounded classes
+// contribute, the ways to arrange them times the passwords the unbounded
+// classes make of the remaining positions.
+type composition struct {
+	distinct bool
+	bounded  []charClass
+	free     []charClass
+	// suffix[i][n] counts the strings of length n over bounded[i:] that meet
+	// their minimums and maximums.
+	suffix [][]*big.Int
+	// weights[n] counts the passwords with n characters from bounded classes.
+	weights []*big.Int
+	total   *big.Int
+}
+
+// newComposition bounds the classes whose maximum is below the length. It
+// returns nil when no class is bounded.
+func newComposition(classes []charClass, length int, distinct bool) *composition {
+	c := &composition{distinct: distinct}
+	for _, class := range classes {
+		if class.max < length {
+			c.bounded = append(c.bounded, class)
+		} else {
+			c.free = append(c.free, class)
+		}
+	}
+	if len(c.bounded) == 0 {
+		return nil
+	}
+
+	longest := 0
+	for _, class := range c.bounded {
+		longest = min(longest+min(class.max, length), length)
+	}
+
+	c.suffix = make([][]*big.Int, len(c.bounded)+1)
+	c.suffix[len(c.bounded)] = []*big.Int{big.NewInt(1)}
+	for i := len(c.bounded) - 1; i >= 0; i-- {
+		c.suffix[i] = make([]*big.Int, longest+1)
+		for n := range c.suffix[i] {
+			c.suffix[i][n] = c.ways(i, n)
+		}
+	}
+
+	c.weights = make([]*big.Int, longest+1)
+	c.total = new(big.Int)
+	for n := range c.weights {
+		w := new(big.Int).Binomial(int64(length), int64(n))
+		w.Mul(w, c.suffix[0][n])
+		w.Mul(w, countClasses(c.free, length-n, distinct))
+		c.weights[n] = w
+		c.total.Add(c.total, w)
+	}
+
+	return c
+}
+
+// ways counts the strings of length n over bounded[i:].
+func (c *composition) ways(i, n int) *big.Int {
+	class := c.bounded[i]
+	next := c.suffix[i+1]
+
+	total := new(big.Int)
+	if class.min > n {
+		return total
+	}
+
+	// C(n, k) and the sequences of k characters are updated as k grows.
+	binomial := new(big.Int).Binomial(int64(n), int64(class.min))
+	sequences := c.sequences(class, class.min)
+	size := big.NewInt(int64(len(class.chars)))
+	for k := class.min; k <= min(class.max, n); k++ {
+		if k > class.min {
+			binomial.Mul(binomial, big.NewInt(int64(n-k+1)))
+			binomial.Quo(binomial, big.NewInt(int64(k)))
+			if c.distinct {
+				size.SetInt64(int64(len(class.chars) - k + 1))
+			}
+			sequences.Mul(sequences, size)
+		}
+		if n-k >= len(next) {
+			continue
+		}
+
+		w := new(big.Int).Mul(binomial, sequences)
+		w.Mul(w, next[n-k])
+		total.Add(total, w)
+	}
+
+	return total
+}
+
+// sequences counts the ordered choices of k characters from the class.
+func (c *composition) sequences(class charClass, k int) *big.Int {
+	n := int64(len(class.chars))
+	if !c.distinct {
+		return new(big.Int).Exp(big.NewInt(n), big.NewInt(int64(k)), nil)
+	}
+	if int64(k) > n {
+		return new(big.Int)
+	}
+
+	return new(big.Int).MulRange(n-int64(k)+1, n)
+}
+
+// pick draws a choice with probability proportional to its weight. walk
+// calls each for every choice in order until it returns false; the weights
+// must add up to total.
+func pick(r io.Reader, total *big.Int, walk func(each func(k int, w *big.Int) bool)) (int, error) {
+	if total.Sign() == 0 {
+		return 0, fmt.Errorf("no password satisfies the minimum and maximum requirements")
+	}
+
+	x, err := rand.Int(r, total)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read random bytes: %w", err)
+	}
+
+	choice := -1
+	walk(func(k int, w *big.Int) bool {
+		choice = k
+		if x.Cmp(w) < 0 {
+			return false
+		}
+		x.Sub(x, w)
+		return true
+	})
+
+	return choice, nil
+}
+
+// countClasses counts the passwords of the given length that meet every
+// minimum and maximum, without repeated characters if distinct is set.
+func countClasses(classes []charClass, length int, distinct bool) *big.Int {
+	if c := newComposition(classes, length, distinct); c != nil {
+		return new(big.Int).Set(c.total)
+	}
+	if distinct {
+		return countDistinct(classes, length)
+	}
+
+	return countPasswords(classes, length)
+}
@@ -0,0 +1,234 @@
+package passgen
+
+import (
+	"math"
+	"math/big"
+	"strings"
+	"testing"
+)
+
+func TestMaxCounts(t *testing.T) {
+	tests := []struct {
+		name    string
+		options []Option
+		length  int
+		max     map[*Charset]int
+	}{
+		{
+			name:    "max symbols",
+			options: []Option{WithLength(32), WithMaxSymbols(2)},
+			length:  32,
+			max:     map[*Charset]int{&Symbols: 2},
+		},
+		{
+			name:    "min equals max",
+			options: []Option{WithLength(20), WithMinDigits(3), WithMaxDigits(3), WithMaxUppercase(1)},
+			length:  20,
+			max:     map[*Charset]int{&Digits: 3, &Uppercase: 1},
+		},
+		{
+			name:    "every class capped",
+			options: []Option{WithLength(10), WithMaxUppercase(2), WithMaxLowercase(4), WithMaxDigits(2), WithMaxSymbols(2)},
+			length:  10,
+			max:     map[*Charset]int{&Uppercase: 2, &Lowercase: 4, &Digits: 2, &Symbols: 2},
+		},
+		{
+			name:    "without repeats",
+			options: []Option{WithLength(16), WithNoRepeats(), WithMaxSymbols(1), WithMinDigits(2)},
+			length:  16,
+			max:     map[*Charset]int{&Symbols: 1},
+		},
+		{
+			name:    "with first character",
+			options: []Option{WithLength(8), WithMaxSymbols(1), WithFirstChar(ClassSymbols), WithMaxConsecutive(2)},
+			length:  8,
+			max:     map[*Charset]int{&Symbols: 1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gen, err := NewGenerator(tt.options...)
+			if err != nil {
+				t.Fatalf("failed to create generator: %v", err)
+			}
+
+			for range 200 {
+				password, err := gen.Generate()
+				if err != nil {
+					t.Fatalf("failed to generate password: %v", err)
+				}
+				if n := len([]rune(password)); n != tt.length {
+					t.Errorf("expected length %d, got %d: %q", tt.length, n, password)
+				}
+				for cs, max := range tt.max {
+					count := 0
+					for _, r := range password {
+						if cs.Contains(r) {
+							count++
+						}
+					}
+					if count > max {
+						t.Errorf("expected at most %d characters from %q, got %d in %q", max, cs, count, password)
+					}
+				}
+				if err := gen.Validate(password); err != nil {
+					t.Errorf("password %q does not satisfy the policy: %v", password, err)
+				}
+			}
+		})
+	}
+}
+
+func TestMaxCountsKeyspace(t *testing.T) {
+	small := []Option{WithoutUppercase(), WithoutLowercase(), WithCharset("ab", CharsetFromString("ab"), 0), WithoutAmbiguous(), WithAmbiguousChars(CharsetFromString("!@#$%^&*()_+-=[]{}|;:,.<>23456789"))}
+
+	tests := []struct {
+		name    string
+		options []Option
+	}{
+		{
+			name:    "max only",
+			options: []Option{WithLength(4), WithMaxSymbols(1)},
+		},
+		{
+			name:    "min and max",
+			options: []Option{WithLength(4), WithMinDigits(1), WithMaxDigits(2), WithMaxSymbols(1)},
+		},
+		{
+			name:    "all bounded",
+			options: []Option{WithLength(4), WithoutSymbols(), WithCharset("ab", CharsetFromString("ab"), 0), WithMaxDigits(2)},
+		},
+		{
+			name:    "with first and last character",
+			options: []Option{WithLength(4), WithMaxSymbols(1), WithMaxDigits(2), WithFirstChar(ClassSymbols, ClassDigits), WithLastChar(ClassDigits)},
+		},
+		{
+			name:    "without repeats",
+			options: []Option{WithLength(4), WithNoRepeats(), WithMaxDigits(1), WithMinSymbols(1)},
+		},
+		{
+			name:    "without repeats with first character",
+			options: []Option{WithLength(4), WithNoRepeats(), WithMaxDigits(1), WithFirstChar(ClassDigits)},
+		},
+		{
+			name:    "with repetition rules",
+			options: []Option{WithLength(4), WithMaxSymbols(2), WithMinDigits(1), WithMaxConsecutive(1), WithMaxSequential(1)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gen, err := NewGenerator(append(small, tt.options...)...)
+			if err != nil {
+				t.Fatalf("failed to create generator: %v", err)
+			}
+
+			expected := bruteForceValid(gen)
+			if got := gen.Keyspace(); got.Cmp(big.NewInt(expected)) != 0 {
+				t.Errorf("expected keyspace %d, got %v", expected, got)
+			}
+		})
+	}
+}
+
+func TestMaxCountsDistribution(t *testing.T) {
+	const samples = 20000
+
+	gen, err := NewGenerator(WithLength(4), WithoutUppercase(), WithoutLowercase(), WithMaxSymbols(2))
+	if err != nil {
+		t.Fatalf("failed to create generator: %v", err)
+	}
+
+	// Every password with at most two symbols is equally likely, so the number
+	// of symbols k follows C(4, k) * 26^k * 10^(4-k).
+	weights := make([]float64, 3)
+	total := 0.0
+	for k := range weights {
+		weights[k] = float64(new(big.Int).Binomial(4, int64(k)).Int64()) * math.Pow(26, float64(k)) * math.Pow(10, float64(4-k))
+		total += weights[k]
+	}
+
+	observed := make([]int, 3)
+	for range samples {
+		password, err := gen.Generate()
+		if err != nil {
+			t.Fatalf("failed to generate password: %v", err)
+		}
+
+		k := 0
+		for _, r := range password {
+			if Symbols.Contains(r) {
+				k++
+			}
+		}
+		observed[k]++
+	}
+
+	chi2 := 0.0
+	for k, w := range weights {
+		expected := samples * w / total
+		chi2 += (float64(observed[k]) - expected) * (float64(observed[k]) - expected) / expected
+	}
+	if critical := chiSquareCritical(len(weights)-1, chiSquareZ); chi2 > critical {
+		t.Errorf("symbol counts %v deviate from the expected distribution: chi2 = %.2f > %.2f", observed, chi2, critical)
+	}
+}
+
+func TestNewGeneratorMaxCounts(t *testing.T) {
+	tests := []struct {
+		name     string
+		options  []Option
+		errorMsg string
+	}{
+		{
+			name:     "negative maximum",
+			options:  []Option{WithMaxDigits(-1)},
+			errorMsg: "maximum digits count cannot be negative, got -1",
+		},
+		{
+			name:     "maximum below minimum",
+			options:  []Option{WithMinSymbols(3), WithMaxSymbols(2)},
+			errorMsg: "maximum symbols count (2) cannot be less than the minimum (3)",
+		},
+		{
+			name:     "maximums below length",
+			options:  []Option{WithLength(10), WithoutLowercase(), WithoutSymbols(), WithMaxUppercase(4), WithMaxDigits(5)},
+			errorMsg: "sum of maximum requirements (9) cannot be less than password length (10)",
+		},
+		{
+			name:     "combined with a mask",
+			options:  []Option{WithMask("?d?d"), WithMaxDigits(1)},
+			errorMsg: "maximum requirements cannot be combined with a mask",
+		},
+		{
+			name:     "combined with pronounceable",
+			options:  []Option{WithPronounceable(), WithMaxUppercase(1)},
+			errorMsg: "maximum requirements cannot be combined with pronounceable passwords",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewGenerator(tt.options...)
+			if err == nil {
+				t.Fatalf("expected error but got none")
+			}
+			if !strings.Contains(err.Error(), tt.errorMsg) {
+				t.Errorf("expected error message to contain %q, got %q", tt.errorMsg, err.Error())
+			}
+		})
+	}
+}
+
+func TestValidateMaxCounts(t *testing.T) {
+	gen, err := NewGenerator(WithLength(6), WithMaxSymbols(1))
+	if err != nil {
+		t.Fatalf("failed to create generator: %v", err)
+	}
+
+	expected := `password violates policy: password must contain at most 1 characters from "symbols", got 3`
+	if err := gen.Validate("ab!@#c"); err == nil || err.Error() != expected {
+		t.Errorf("expected %q, got %v", expected, err)
+	}
+}
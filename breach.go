@@ -0,0 +1,34 @@
+package passgen
+
+import (
+	"fmt"
+	"unicode/utf8"
+)
+
+// maxBreachAttempts bounds how often a password found by the breach filter
+// is redrawn before giving up. Random passwords are practically never in a
+// breach corpus, so reaching it points at a filter that reports everything.
+const maxBreachAttempts = 100
+
+// BreachFilter reports whether a password appears in a corpus of breached
+// passwords. The breach package provides filters backed by local copies of
+// the Pwned Passwords list.
+type BreachFilter interface {
+	Contains(password []byte) (bool, error)
+}
+
+// breached reports whether the breach filter contains pass.
+func (g *Generator) breached(pass []rune) (bool, error) {
+	buf := make([]byte, 0, len(pass)*g.width)
+	for _, c := range pass {
+		buf = utf8.AppendRune(buf, c)
+	}
+	defer clear(buf)
+
+	found, err := g.cfg.breach.Contains(buf)
+	if err != nil {
+		return false, fmt.Errorf("failed to check breach filter: %w", err)
+	}
+
+	return found, nil
+}
@@ -0,0 +1,177 @@
+package breach
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+)
+
+// bloomMagic starts every serialized Bloom filter.
+const bloomMagic = "PGBLOOM1"
+
+// maxBloomBits bounds the size of a filter read from disk to 128 GiB.
+const maxBloomBits = 1 << 40
+
+// Bloom is a Bloom filter of SHA-1 hashes. It never misses a password that
+// was added, and reports others as present with the false positive rate it
+// was sized for; for the generator that only means an occasional extra draw.
+// A rate of one in a million takes about 29 bits per hash.
+//
+// A Bloom is safe for concurrent lookups, but not for lookups concurrent
+// with additions.
+type Bloom struct {
+	words []uint64
+	m     uint64
+	k     uint32
+}
+
+// NewBloom returns an empty filter sized for n hashes at the given false
+// positive rate.
+func NewBloom(n uint64, falsePositiveRate float64) (*Bloom, error) {
+	if n == 0 {
+		return nil, fmt.Errorf("bloom filter size must be greater than 0")
+	}
+	if !(falsePositiveRate > 0 && falsePositiveRate < 1) {
+		return nil, fmt.Errorf("false positive rate must be between 0 and 1, got %v", falsePositiveRate)
+	}
+
+	m := math.Ceil(-float64(n) * math.Log(falsePositiveRate) / (math.Ln2 * math.Ln2))
+	if m > maxBloomBits {
+		return nil, fmt.Errorf("bloom filter must not exceed %d bits, got %.0f", uint64(maxBloomBits), m)
+	}
+	k := max(1, math.Round(m/float64(n)*math.Ln2))
+
+	return newBloom(uint64(m), uint32(k)), nil
+}
+
+func newBloom(m uint64, k uint32) *Bloom {
+	return &Bloom{words: make([]uint64, (m+63)/64), m: m, k: k}
+}
+
+// Add adds password to the filter.
+func (b *Bloom) Add(password []byte) {
+	b.add(sha1.Sum(password))
+}
+
+// AddCorpus adds every hash of a corpus of "HASH:COUNT" lines, such as a
+// sorted hash file, to the filter. Padding lines with a count of 0 are
+// skipped.
+func (b *Bloom) AddCorpus(r io.Reader) error {
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+
+		h, count, err := parseLine(scanner.Bytes())
+		if err == nil && len(h) != hashSize {
+			err = fmt.Errorf("hash must have %d hex digits, got %d", hashSize, len(h))
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read corpus line %d: %w", line, err)
+		}
+		if count == 0 {
+			continue
+		}
+
+		var sum [sha1.Size]byte
+		hex.Decode(sum[:], h)
+		b.add(sum)
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read corpus: %w", err)
+	}
+
+	return nil
+}
+
+// Contains reports whether password may be in the filter. It never fails.
+func (b *Bloom) Contains(password []byte) (bool, error) {
+	sum := sha1.Sum(password)
+
+	h1, h2 := b.hashes(sum)
+	for i := range uint64(b.k) {
+		bit := (h1 + i*h2) % b.m
+		if b.words[bit/64]&(1<<(bit%64)) == 0 {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
+
+func (b *Bloom) add(sum [sha1.Size]byte) {
+	h1, h2 := b.hashes(sum)
+	for i := range uint64(b.k) {
+		bit := (h1 + i*h2) % b.m
+		b.words[bit/64] |= 1 << (bit % 64)
+	}
+}
+
+// hashes derives the k bit positions by double hashing: SHA-1 is already
+// uniform, so two 64-bit words of it are independent hashes. The second is
+// odd so that it never degenerates to a single position.
+func (b *Bloom) hashes(sum [sha1.Size]byte) (uint64, uint64) {
+	return binary.LittleEndian.Uint64(sum[0:8]), binary.LittleEndian.Uint64(sum[8:16]) | 1
+}
+
+// WriteTo writes the filter to w in a format ReadBloom and Open read.
+func (b *Bloom) WriteTo(w io.Writer) (int64, error) {
+	bw := bufio.NewWriter(w)
+
+	header := make([]byte, 0, len(bloomMagic)+12)
+	header = append(header, bloomMagic...)
+	header = binary.LittleEndian.AppendUint64(header, b.m)
+	header = binary.LittleEndian.AppendUint32(header, b.k)
+	bw.Write(header)
+
+	var buf [8]byte
+	for _, word := range b.words {
+		binary.LittleEndian.PutUint64(buf[:], word)
+		bw.Write(buf[:])
+	}
+
+	if err := bw.Flush(); err != nil {
+		return 0, fmt.Errorf("failed to write bloom filter: %w", err)
+	}
+
+	return int64(len(header) + 8*len(b.words)), nil
+}
+
+// ReadBloom reads a filter written by WriteTo.
+func ReadBloom(r io.Reader) (*Bloom, error) {
+	br := bufio.NewReader(r)
+
+	header := make([]byte, len(bloomMagic)+12)
+	if _, err := io.ReadFull(br, header); err != nil {
+		return nil, fmt.Errorf("failed to read bloom filter: %w", err)
+	}
+	if string(header[:len(bloomMagic)]) != bloomMagic {
+		return nil, fmt.Errorf("failed to read bloom filter: invalid header")
+	}
+
+	m := binary.LittleEndian.Uint64(header[len(bloomMagic):])
+	k := binary.LittleEndian.Uint32(header[len(bloomMagic)+8:])
+	if m == 0 || m > maxBloomBits || k == 0 || k > 64 {
+		return nil, fmt.Errorf("failed to read bloom filter: invalid parameters m=%d, k=%d", m, k)
+	}
+
+	b := newBloom(m, k)
+	var buf [8]byte
+	for i := range b.words {
+		if _, err := io.ReadFull(br, buf[:]); err != nil {
+			if errors.Is(err, io.EOF) {
+				err = io.ErrUnexpectedEOF
+			}
+			return nil, fmt.Errorf("failed to read bloom filter: %w", err)
+		}
+		b.words[i] = binary.LittleEndian.Uint64(buf[:])
+	}
+
+	return b, nil
+}
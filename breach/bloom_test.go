@@ -0,0 +1,127 @@
+package breach
+
+import (
+	"bytes"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestBloomFalsePositiveRate(t *testing.T) {
+	const n = 10000
+	const rate = 0.01
+
+	b, err := NewBloom(n, rate)
+	if err != nil {
+		t.Fatalf("failed to create bloom filter: %v", err)
+	}
+	for i := range n {
+		b.Add([]byte(fmt.Sprintf("breached-%d", i)))
+	}
+
+	for i := range n {
+		if found, _ := b.Contains([]byte(fmt.Sprintf("breached-%d", i))); !found {
+			t.Fatalf("expected %q to be found", fmt.Sprintf("breached-%d", i))
+		}
+	}
+
+	// 100000 lookups at 1% expect 1000 false positives with a standard
+	// deviation of about 31.
+	falsePositives := 0
+	for i := range 10 * n {
+		if found, _ := b.Contains([]byte(fmt.Sprintf("other-%d", i))); found {
+			falsePositives++
+		}
+	}
+	if falsePositives > 1200 {
+		t.Errorf("expected a false positive rate around %v, got %d in %d", rate, falsePositives, 10*n)
+	}
+}
+
+func TestBloomRoundTrip(t *testing.T) {
+	b, err := NewBloom(100, 1e-4)
+	if err != nil {
+		t.Fatalf("failed to create bloom filter: %v", err)
+	}
+	for _, password := range breached {
+		b.Add([]byte(password))
+	}
+
+	var buf bytes.Buffer
+	n, err := b.WriteTo(&buf)
+	if err != nil {
+		t.Fatalf("failed to write bloom filter: %v", err)
+	}
+	if n != int64(buf.Len()) {
+		t.Errorf("expected %d bytes written, got %d", buf.Len(), n)
+	}
+
+	read, err := ReadBloom(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatalf("failed to read bloom filter: %v", err)
+	}
+	if read.m != b.m || read.k != b.k || !slices.Equal(read.words, b.words) {
+		t.Errorf("expected the filter to survive a round trip")
+	}
+
+	truncated := buf.Bytes()[:buf.Len()-1]
+	if _, err := ReadBloom(bytes.NewReader(truncated)); err == nil || !strings.Contains(err.Error(), "unexpected EOF") {
+		t.Errorf("expected an unexpected EOF error, got %v", err)
+	}
+	if _, err := ReadBloom(strings.NewReader("NOTBLOOM" + strings.Repeat("\x00", 12))); err == nil || !strings.Contains(err.Error(), "invalid header") {
+		t.Errorf("expected an invalid header error, got %v", err)
+	}
+	if _, err := ReadBloom(strings.NewReader(bloomMagic + strings.Repeat("\x00", 12))); err == nil || !strings.Contains(err.Error(), "invalid parameters") {
+		t.Errorf("expected an invalid parameters error, got %v", err)
+	}
+}
+
+func TestNewBloom(t *testing.T) {
+	tests := []struct {
+		name     string
+		n        uint64
+		rate     float64
+		errorMsg string
+	}{
+		{name: "empty", n: 0, rate: 0.01, errorMsg: "bloom filter size must be greater than 0"},
+		{name: "zero rate", n: 10, rate: 0, errorMsg: "false positive rate must be between 0 and 1, got 0"},
+		{name: "rate of one", n: 10, rate: 1, errorMsg: "false positive rate must be between 0 and 1, got 1"},
+		{name: "too large", n: 1 << 40, rate: 1e-9, errorMsg: "bloom filter must not exceed"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewBloom(tt.n, tt.rate)
+			if err == nil {
+				t.Fatalf("expected error but got none")
+			}
+			if !strings.Contains(err.Error(), tt.errorMsg) {
+				t.Errorf("expected error message to contain %q, got %q", tt.errorMsg, err.Error())
+			}
+		})
+	}
+}
+
+func TestBloomAddCorpusErrors(t *testing.T) {
+	b, err := NewBloom(10, 0.01)
+	if err != nil {
+		t.Fatalf("failed to create bloom filter: %v", err)
+	}
+
+	tests := []struct {
+		corpus   string
+		errorMsg string
+	}{
+		{corpus: sha1Hex("a") + ":1\nnot hex:1\n", errorMsg: "failed to read corpus line 2: invalid hash"},
+		{corpus: sha1Hex("a")[5:] + ":1\n", errorMsg: "hash must have 40 hex digits, got 35"},
+		{corpus: sha1Hex("a") + ":many\n", errorMsg: "invalid count"},
+	}
+
+	for _, tt := range tests {
+		err := b.AddCorpus(strings.NewReader(tt.corpus))
+		if err == nil || !strings.Contains(err.Error(), tt.errorMsg) {
+			t.Errorf("expected error message to contain %q, got %v", tt.errorMsg, err)
+		}
+	}
+}
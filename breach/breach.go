@@ -0,0 +1,133 @@
+// Package breach checks passwords against a local copy of a breached password
+// corpus, such as the Pwned Passwords list of Have I Been Pwned, so lookups
+// work without network access.
+//
+// Three storage formats are supported:
+//
+//   - a single text file of "HASH:COUNT" lines sorted by hash, as written by
+//     the Pwned Passwords downloader, searched on disk (File);
+//   - a directory of range files named after the first five hex digits of
+//     the hash, each listing "SUFFIX:COUNT" lines (Dir);
+//   - a Bloom filter built from either of them, which fits in memory at the
+//     cost of a configurable false positive rate (Bloom).
+//
+// Hashes are the SHA-1 of the password's UTF-8 bytes in hex, in either case.
+// Lines with a count of 0 are padding and never match.
+//
+// Every format implements passgen.BreachFilter, so it can be passed to
+// passgen.WithBreachFilter to reject generated passwords found in the corpus,
+// and Generator.Validate then reports user-chosen passwords found in it.
+package breach
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+)
+
+// Corpus is a breached password corpus opened by Open.
+type Corpus interface {
+	// Contains reports whether password is in the corpus.
+	Contains(password []byte) (bool, error)
+	Close() error
+}
+
+// Open opens the corpus at path, detecting its format: a directory of range
+// files, a Bloom filter written by Bloom.WriteTo, or a sorted hash file.
+// Bloom filters are read into memory.
+func Open(path string) (Corpus, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open corpus: %w", err)
+	}
+	if info.IsDir() {
+		return OpenDir(path)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open corpus: %w", err)
+	}
+
+	magic := make([]byte, len(bloomMagic))
+	if _, err := io.ReadFull(f, magic); err == nil && string(magic) == bloomMagic {
+		defer f.Close()
+
+		if _, err := f.Seek(0, io.SeekStart); err != nil {
+			return nil, fmt.Errorf("failed to open corpus: %w", err)
+		}
+		b, err := ReadBloom(f)
+		if err != nil {
+			return nil, err
+		}
+
+		return bloomCorpus{b}, nil
+	}
+
+	return newFile(f, info.Size()), nil
+}
+
+// bloomCorpus adapts an in-memory Bloom filter to Corpus.
+type bloomCorpus struct {
+	*Bloom
+}
+
+func (bloomCorpus) Close() error {
+	return nil
+}
+
+// hashSize is the length of a hex encoded SHA-1 hash.
+const hashSize = 2 * sha1.Size
+
+// hash returns the SHA-1 of password in uppercase hex.
+func hash(password []byte) [hashSize]byte {
+	sum := sha1.Sum(password)
+
+	var h [hashSize]byte
+	hex.Encode(h[:], sum[:])
+	toUpperHex(h[:])
+
+	return h
+}
+
+// parseLine splits a "HASH:COUNT" or "SUFFIX:COUNT" line into its hash, which
+// it uppercases in place, and its count. The count is optional and defaults
+// to 1.
+func parseLine(line []byte) ([]byte, int64, error) {
+	line = bytes.TrimRight(line, "\r")
+	h, count, found := bytes.Cut(line, []byte(":"))
+
+	n := int64(1)
+	if found {
+		var err error
+		if n, err = strconv.ParseInt(string(bytes.TrimSpace(count)), 10, 64); err != nil {
+			return nil, 0, fmt.Errorf("invalid count in line %q", line)
+		}
+	}
+
+	if len(h) == 0 || !toUpperHex(h) {
+		return nil, 0, fmt.Errorf("invalid hash in line %q", line)
+	}
+
+	return h, n, nil
+}
+
+// toUpperHex uppercases the hex digits in h and reports whether h holds only
+// hex digits.
+func toUpperHex(h []byte) bool {
+	for i, c := range h {
+		switch {
+		case c >= '0' && c <= '9', c >= 'A' && c <= 'F':
+		case c >= 'a' && c <= 'f':
+			h[i] = c - 'a' + 'A'
+		default:
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,263 @@
+package breach
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/haadi-coder/passgen"
+)
+
+var breached = []string{"password", "123456", "qwerty", "letmein", "correct horse battery staple", "pässwört"}
+
+// padding is a hash listed with a count of 0, as padded downloads do.
+const padding = "padding"
+
+var unbreached = []string{padding, "Password", "correct horse battery", "", "x7#Vq9!mZ2"}
+
+// writeCorpus writes breached and some filler hashes as a sorted hash file and
+// as a directory of range files, and returns their paths.
+func writeCorpus(t *testing.T) (string, string) {
+	t.Helper()
+
+	var lines []string
+	for i, password := range breached {
+		lines = append(lines, fmt.Sprintf("%s:%d", sha1Hex(password), i+1))
+	}
+	for i := range 500 {
+		lines = append(lines, fmt.Sprintf("%s:%d", sha1Hex(fmt.Sprintf("filler-%d", i)), i+1))
+	}
+	lines = append(lines, sha1Hex(padding)+":0")
+	slices.Sort(lines)
+
+	dir := t.TempDir()
+	file := filepath.Join(dir, "pwned-passwords-sha1-ordered-by-hash.txt")
+	if err := os.WriteFile(file, []byte(strings.Join(lines, "\r\n")+"\r\n"), 0o600); err != nil {
+		t.Fatalf("failed to write corpus: %v", err)
+	}
+
+	ranges := filepath.Join(dir, "ranges")
+	if err := os.Mkdir(ranges, 0o700); err != nil {
+		t.Fatalf("failed to create range directory: %v", err)
+	}
+	byPrefix := make(map[string][]string)
+	for _, line := range lines {
+		byPrefix[line[:prefixSize]] = append(byPrefix[line[:prefixSize]], line[prefixSize:])
+	}
+	// Every prefix has a range file, even without any hashes.
+	for _, password := range unbreached {
+		prefix := sha1Hex(password)[:prefixSize]
+		if _, ok := byPrefix[prefix]; !ok {
+			byPrefix[prefix] = nil
+		}
+	}
+	for prefix, suffixes := range byPrefix {
+		// Lowercase hex and names without an extension are read too.
+		name := prefix + ".txt"
+		if prefix[0] < '8' {
+			name = prefix
+			suffixes = []string{strings.ToLower(strings.Join(suffixes, "\n"))}
+		}
+		if err := os.WriteFile(filepath.Join(ranges, name), []byte(strings.Join(suffixes, "\n")), 0o600); err != nil {
+			t.Fatalf("failed to write range file: %v", err)
+		}
+	}
+
+	return file, ranges
+}
+
+func sha1Hex(password string) string {
+	sum := sha1.Sum([]byte(password))
+	return strings.ToUpper(hex.EncodeToString(sum[:]))
+}
+
+func writeBloom(t *testing.T, file string) string {
+	t.Helper()
+
+	b, err := NewBloom(1000, 1e-6)
+	if err != nil {
+		t.Fatalf("failed to create bloom filter: %v", err)
+	}
+	f, err := os.Open(file)
+	if err != nil {
+		t.Fatalf("failed to open corpus: %v", err)
+	}
+	defer f.Close()
+	if err := b.AddCorpus(f); err != nil {
+		t.Fatalf("failed to add corpus: %v", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "pwned.bloom")
+	out, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("failed to create bloom file: %v", err)
+	}
+	defer out.Close()
+	if _, err := b.WriteTo(out); err != nil {
+		t.Fatalf("failed to write bloom filter: %v", err)
+	}
+
+	return path
+}
+
+func TestContains(t *testing.T) {
+	file, ranges := writeCorpus(t)
+	bloom := writeBloom(t, file)
+
+	corpora := map[string]string{"file": file, "dir": ranges, "bloom": bloom}
+	for name, path := range corpora {
+		t.Run(name, func(t *testing.T) {
+			c, err := Open(path)
+			if err != nil {
+				t.Fatalf("failed to open corpus: %v", err)
+			}
+			defer c.Close()
+
+			for _, password := range breached {
+				if found, err := c.Contains([]byte(password)); err != nil || !found {
+					t.Errorf("expected %q to be found, got %v, %v", password, found, err)
+				}
+			}
+
+			for _, password := range unbreached {
+				// The bloom filter may report false positives, at a rate
+				// of one in a million here.
+				if found, err := c.Contains([]byte(password)); err != nil || found {
+					t.Errorf("expected %q not to be found, got %v, %v", password, found, err)
+				}
+			}
+		})
+	}
+}
+
+func TestOpenFormats(t *testing.T) {
+	file, ranges := writeCorpus(t)
+	bloom := writeBloom(t, file)
+
+	if c, err := Open(file); err != nil {
+		t.Errorf("failed to open file: %v", err)
+	} else if _, ok := c.(*File); !ok {
+		t.Errorf("expected a *File, got %T", c)
+	}
+	if c, err := Open(ranges); err != nil {
+		t.Errorf("failed to open directory: %v", err)
+	} else if _, ok := c.(*Dir); !ok {
+		t.Errorf("expected a *Dir, got %T", c)
+	}
+	if c, err := Open(bloom); err != nil {
+		t.Errorf("failed to open bloom filter: %v", err)
+	} else if _, ok := c.(bloomCorpus); !ok {
+		t.Errorf("expected a bloom filter, got %T", c)
+	}
+
+	if _, err := Open(filepath.Join(ranges, "missing")); err == nil {
+		t.Errorf("expected an error for a missing corpus")
+	}
+}
+
+func TestFileEdgeCases(t *testing.T) {
+	tests := []struct {
+		name     string
+		lines    []string
+		password string
+		found    bool
+		errorMsg string
+	}{
+		{name: "empty file", password: "password"},
+		{name: "single line", lines: []string{sha1Hex("password") + ":3"}, password: "password", found: true},
+		{name: "first of two lines", lines: []string{"0000000000000000000000000000000000000000:1", sha1Hex("password") + ":3"}, password: "password", found: true},
+		{name: "before the first line", lines: []string{"FFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFF:1"}, password: "password"},
+		{name: "without counts", lines: []string{strings.ToLower(sha1Hex("password"))}, password: "password", found: true},
+		{name: "malformed line", lines: []string{"not a hash:1"}, password: "password", errorMsg: "invalid hash"},
+		{name: "line too long", lines: []string{strings.Repeat("A", 300)}, password: "password", errorMsg: "exceeds 128 bytes"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "corpus.txt")
+			content := strings.Join(tt.lines, "\n")
+			if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+				t.Fatalf("failed to write corpus: %v", err)
+			}
+
+			f, err := OpenFile(path)
+			if err != nil {
+				t.Fatalf("failed to open corpus: %v", err)
+			}
+			defer f.Close()
+
+			found, err := f.Contains([]byte(tt.password))
+			if tt.errorMsg != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.errorMsg) {
+					t.Errorf("expected error message to contain %q, got %v", tt.errorMsg, err)
+				}
+				return
+			}
+			if err != nil || found != tt.found {
+				t.Errorf("expected %v, got %v, %v", tt.found, found, err)
+			}
+		})
+	}
+}
+
+func TestDirMissingRangeFile(t *testing.T) {
+	d, err := OpenDir(t.TempDir())
+	if err != nil {
+		t.Fatalf("failed to open directory: %v", err)
+	}
+
+	_, err = d.Contains([]byte("password"))
+	if err == nil || !strings.Contains(err.Error(), "failed to open range file 5BAA6") {
+		t.Errorf("expected a missing range file error, got %v", err)
+	}
+
+	if _, err := OpenDir(writeTempFile(t)); err == nil || !strings.Contains(err.Error(), "is not a directory") {
+		t.Errorf("expected a not a directory error, got %v", err)
+	}
+}
+
+func writeTempFile(t *testing.T) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "file")
+	if err := os.WriteFile(path, nil, 0o600); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+
+	return path
+}
+
+func TestWithBreachFilter(t *testing.T) {
+	_, ranges := writeCorpus(t)
+	c, err := Open(ranges)
+	if err != nil {
+		t.Fatalf("failed to open corpus: %v", err)
+	}
+
+	// A mask that only produces "password" or "Password" ...
+	gen, err := passgen.NewGenerator(passgen.WithMask("?{p}assword"), passgen.WithoutUppercase(), passgen.WithoutLowercase(),
+		passgen.WithCharset("p", passgen.CharsetFromString("pP"), 0), passgen.WithBreachFilter(c))
+	if err != nil {
+		t.Fatalf("failed to create generator: %v", err)
+	}
+
+	// ... can only produce the one that is not breached.
+	for range 20 {
+		password, err := gen.Generate()
+		if err != nil {
+			t.Fatalf("failed to generate password: %v", err)
+		}
+		if password != "Password" {
+			t.Errorf("expected %q, got %q", "Password", password)
+		}
+	}
+
+	if err := gen.Validate("password"); err == nil || !strings.Contains(err.Error(), "password appears in a breach corpus") {
+		t.Errorf("expected a breach violation, got %v", err)
+	}
+}
@@ -0,0 +1,89 @@
+package breach
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// prefixSize is the number of hex digits that name a range file.
+const prefixSize = 5
+
+// Dir is a directory of range files in the format of the Pwned Passwords
+// range API: the file named after the first five hex digits of a hash, with
+// or without a ".txt" extension, lists the remaining 35 digits of every hash
+// with that prefix as "SUFFIX:COUNT" lines. Lookups read one file.
+//
+// A corpus covers every prefix, so a missing range file is an error rather
+// than a miss. A Dir is safe for concurrent use.
+type Dir struct {
+	path string
+}
+
+// OpenDir opens the directory of range files at path.
+func OpenDir(path string) (*Dir, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open corpus: %w", err)
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("failed to open corpus: %s is not a directory", path)
+	}
+
+	return &Dir{path: path}, nil
+}
+
+// Close does nothing; range files are only open during a lookup.
+func (d *Dir) Close() error {
+	return nil
+}
+
+// Contains reports whether password is in the directory.
+func (d *Dir) Contains(password []byte) (bool, error) {
+	h := hash(password)
+	prefix, suffix := string(h[:prefixSize]), h[prefixSize:]
+
+	f, err := d.open(prefix)
+	if err != nil {
+		return false, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+
+		s, count, err := parseLine(scanner.Bytes())
+		if err != nil {
+			return false, fmt.Errorf("failed to read range file %s: %w", prefix, err)
+		}
+		if bytes.Equal(s, suffix) {
+			return count > 0, nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return false, fmt.Errorf("failed to read range file %s: %w", prefix, err)
+	}
+
+	return false, nil
+}
+
+func (d *Dir) open(prefix string) (*os.File, error) {
+	for _, name := range []string{prefix + ".txt", prefix} {
+		f, err := os.Open(filepath.Join(d.path, name))
+		if err == nil {
+			return f, nil
+		}
+		if !errors.Is(err, fs.ErrNotExist) {
+			return nil, fmt.Errorf("failed to open range file %s: %w", prefix, err)
+		}
+	}
+
+	return nil, fmt.Errorf("failed to open range file %s: %w", prefix, fs.ErrNotExist)
+}
@@ -0,0 +1,131 @@
+package breach
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+// maxLineSize bounds a "HASH:COUNT" line, including its line break.
+const maxLineSize = 128
+
+// File is a text file of "HASH:COUNT" lines sorted by hash. Lookups binary
+// search the file on disk, so even the full corpus of about a billion hashes
+// needs no memory and about thirty reads per password.
+//
+// A File is safe for concurrent use.
+type File struct {
+	r    io.ReaderAt
+	c    io.Closer
+	size int64
+}
+
+// OpenFile opens the sorted hash file at path.
+func OpenFile(path string) (*File, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open corpus: %w", err)
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("failed to open corpus: %w", err)
+	}
+
+	return newFile(f, info.Size()), nil
+}
+
+func newFile(f *os.File, size int64) *File {
+	return &File{r: f, c: f, size: size}
+}
+
+func (f *File) Close() error {
+	return f.c.Close()
+}
+
+// Contains reports whether password is in the file.
+func (f *File) Contains(password []byte) (bool, error) {
+	target := hash(password)
+
+	// The line of target, if any, starts in [lo, hi).
+	lo, hi := int64(0), f.size
+	for lo < hi {
+		mid := lo + (hi-lo)/2
+
+		start, err := f.lineStart(mid)
+		if err != nil {
+			return false, err
+		}
+		if start >= hi {
+			hi = mid
+			continue
+		}
+
+		line, err := f.line(start)
+		if err != nil {
+			return false, err
+		}
+		h, count, err := parseLine(bytes.TrimRight(line, "\n"))
+		if err != nil {
+			return false, fmt.Errorf("failed to read corpus at offset %d: %w", start, err)
+		}
+
+		switch c := bytes.Compare(h, target[:]); {
+		case c == 0:
+			return count > 0, nil
+		case c < 0:
+			lo = start + int64(len(line))
+		default:
+			hi = mid
+		}
+	}
+
+	return false, nil
+}
+
+// lineStart returns the offset of the first line starting at or after off,
+// or the size of the file if there is none.
+func (f *File) lineStart(off int64) (int64, error) {
+	if off == 0 {
+		return 0, nil
+	}
+
+	var buf [maxLineSize]byte
+	n, err := f.r.ReadAt(buf[:], off-1)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return 0, fmt.Errorf("failed to read corpus: %w", err)
+	}
+
+	i := bytes.IndexByte(buf[:n], '\n')
+	if i < 0 {
+		if n < len(buf) {
+			return f.size, nil
+		}
+		return 0, fmt.Errorf("failed to read corpus at offset %d: line exceeds %d bytes", off, maxLineSize)
+	}
+
+	return off + int64(i), nil
+}
+
+// line returns the line starting at off, including its line break unless it
+// is the last one.
+func (f *File) line(off int64) ([]byte, error) {
+	buf := make([]byte, maxLineSize)
+	n, err := f.r.ReadAt(buf, off)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("failed to read corpus: %w", err)
+	}
+
+	i := bytes.IndexByte(buf[:n], '\n')
+	if i < 0 {
+		if n < len(buf) {
+			return buf[:n], nil
+		}
+		return nil, fmt.Errorf("failed to read corpus at offset %d: line exceeds %d bytes", off, maxLineSize)
+	}
+
+	return buf[:i+1], nil
+}
@@ -0,0 +1,170 @@
+package passgen
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// setFilter is a breach filter holding a fixed set of passwords.
+type setFilter struct {
+	mu        sync.Mutex
+	passwords map[string]bool
+	lookups   int
+	err       error
+}
+
+func (f *setFilter) Contains(password []byte) (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.lookups++
+	return f.passwords[string(password)], f.err
+}
+
+func TestWithBreachFilter(t *testing.T) {
+	// Half of the 100 two-digit passwords are breached: those below 50.
+	filter := &setFilter{passwords: make(map[string]bool)}
+	for i := range 50 {
+		filter.passwords[fmt.Sprintf("%02d", i)] = true
+	}
+
+	gen, err := NewGenerator(WithLength(2), WithoutUppercase(), WithoutLowercase(), WithoutSymbols(), WithBreachFilter(filter))
+	if err != nil {
+		t.Fatalf("failed to create generator: %v", err)
+	}
+
+	passwords, err := gen.GenerateN(200)
+	if err != nil {
+		t.Fatalf("failed to generate passwords: %v", err)
+	}
+	for range 50 {
+		password, err := gen.Generate()
+		if err != nil {
+			t.Fatalf("failed to generate password: %v", err)
+		}
+		bytes, err := gen.GenerateBytes()
+		if err != nil {
+			t.Fatalf("failed to generate password: %v", err)
+		}
+		passwords = append(passwords, password, string(bytes))
+	}
+
+	for _, password := range passwords {
+		if filter.passwords[password] {
+			t.Errorf("expected no breached passwords, got %q", password)
+		}
+	}
+}
+
+func TestGenerateNUniqueWithBreachFilter(t *testing.T) {
+	// Only 9 of the 10 one-digit passwords can be drawn.
+	filter := &setFilter{passwords: map[string]bool{"0": true}}
+
+	gen, err := NewGenerator(WithLength(1), WithoutUppercase(), WithoutLowercase(), WithoutSymbols(), WithBreachFilter(filter))
+	if err != nil {
+		t.Fatalf("failed to create generator: %v", err)
+	}
+
+	if _, err := gen.GenerateN(10, WithUnique()); err == nil || !strings.Contains(err.Error(), "duplicates in a row, too few distinct passwords can be drawn") {
+		t.Errorf("expected duplicate limit error, got %v", err)
+	}
+
+	passwords, err := gen.GenerateN(9, WithUnique())
+	if err != nil {
+		t.Fatalf("failed to generate passwords: %v", err)
+	}
+	if len(passwords) != 9 {
+		t.Errorf("expected 9 passwords, got %d", len(passwords))
+	}
+}
+
+func TestWithBreachFilterNotFound(t *testing.T) {
+	filter := &setFilter{passwords: map[string]bool{"password": true}}
+
+	gen, err := NewGenerator(WithBreachFilter(filter))
+	if err != nil {
+		t.Fatalf("failed to create generator: %v", err)
+	}
+
+	for range 10 {
+		if _, err := gen.Generate(); err != nil {
+			t.Fatalf("failed to generate password: %v", err)
+		}
+	}
+	if filter.lookups != 10 {
+		t.Errorf("expected one lookup per password, got %d", filter.lookups)
+	}
+}
+
+func TestWithBreachFilterErrors(t *testing.T) {
+	everything := &setFilter{passwords: map[string]bool{"aa": true, "ab": true, "ba": true, "bb": true}}
+	failing := &setFilter{err: errors.New("disk on fire")}
+
+	tests := []struct {
+		name     string
+		filter   BreachFilter
+		errorMsg string
+	}{
+		{
+			name:     "every password breached",
+			filter:   everything,
+			errorMsg: "failed to generate a password outside the breach corpus after 100 attempts",
+		},
+		{
+			name:     "lookup failure",
+			filter:   failing,
+			errorMsg: "failed to check breach filter: disk on fire",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gen, err := NewGenerator(WithLength(2), WithCharset("ab", CharsetFromString("ab"), 0),
+				WithoutUppercase(), WithoutLowercase(), WithoutDigits(), WithoutSymbols(), WithBreachFilter(tt.filter))
+			if err != nil {
+				t.Fatalf("failed to create generator: %v", err)
+			}
+
+			_, err = gen.Generate()
+			if err == nil {
+				t.Fatalf("expected error but got none")
+			}
+			if !strings.Contains(err.Error(), tt.errorMsg) {
+				t.Errorf("expected error message to contain %q, got %q", tt.errorMsg, err.Error())
+			}
+		})
+	}
+}
+
+func TestValidateBreached(t *testing.T) {
+	filter := &setFilter{passwords: map[string]bool{"correcthorse": true}}
+
+	gen, err := NewGenerator(WithLength(8), WithoutUppercase(), WithoutDigits(), WithoutSymbols(), WithBreachFilter(filter))
+	if err != nil {
+		t.Fatalf("failed to create generator: %v", err)
+	}
+
+	if err := gen.Validate("batterystaple"); err != nil {
+		t.Errorf("expected no violations, got %v", err)
+	}
+
+	err = gen.Validate("correcthorse")
+	var policyErr *PolicyError
+	if !errors.As(err, &policyErr) {
+		t.Fatalf("expected a *PolicyError, got %v", err)
+	}
+	if len(policyErr.Violations) != 1 || policyErr.Violations[0].Rule != RuleBreached {
+		t.Errorf("expected a single %s violation, got %v", RuleBreached, policyErr.Violations)
+	}
+	if msg := policyErr.Violations[0].String(); msg != "password appears in a breach corpus" {
+		t.Errorf("unexpected message %q", msg)
+	}
+
+	filter.err = errors.New("disk on fire")
+	if err := gen.Validate("batterystaple"); err == nil || errors.As(err, &policyErr) {
+		t.Errorf("expected the lookup error, got %v", err)
+	}
+}
@@ -0,0 +1,96 @@
+package passgen
+
+import (
+	"slices"
+	"unicode/utf8"
+)
+
+// Charset is an immutable, sorted set of unique characters.
+type Charset struct {
+	runes []rune
+}
+
+var (
+	Uppercase = NewCharset(uppers...)
+	Lowercase = NewCharset(lowers...)
+	Digits    = NewCharset(digits...)
+	Symbols   = NewCharset(symbols...)
+
+	// Ambiguous holds the look-alike characters excluded by WithoutAmbiguous.
+	Ambiguous = CharsetFromString("0Oo1lI5S|`'\"")
+)
+
+func NewCharset(runes ...rune) Charset {
+	sorted := slices.Clone(runes)
+	slices.Sort(sorted)
+
+	return Charset{runes: slices.Compact(sorted)}
+}
+
+func CharsetFromString(s string) Charset {
+	return NewCharset([]rune(s)...)
+}
+
+// CharsetFromRange returns the characters from lo to hi inclusive, limited
+// to the code points 0 through utf8.MaxRune. The result is empty if lo > hi.
+func CharsetFromRange(lo, hi rune) Charset {
+	lo, hi = max(lo, 0), min(hi, utf8.MaxRune)
+	if lo > hi {
+		return Charset{}
+	}
+
+	runes := make([]rune, 0, hi-lo+1)
+	for r := lo; r <= hi; r++ {
+		runes = append(runes, r)
+	}
+
+	return Charset{runes: runes}
+}
+
+func (c Charset) Union(others ...Charset) Charset {
+	runes := slices.Clone(c.runes)
+	for _, other := range others {
+		runes = append(runes, other.runes...)
+	}
+
+	return NewCharset(runes...)
+}
+
+func (c Charset) Difference(other Charset) Charset {
+	runes := make([]rune, 0, len(c.runes))
+	for _, r := range c.runes {
+		if !other.Contains(r) {
+			runes = append(runes, r)
+		}
+	}
+
+	return Charset{runes: runes}
+}
+
+func (c Charset) Intersection(other Charset) Charset {
+	runes := make([]rune, 0, min(len(c.runes), len(other.runes)))
+	for _, r := range c.runes {
+		if other.Contains(r) {
+			runes = append(runes, r)
+		}
+	}
+
+	return Charset{runes: runes}
+}
+
+func (c Charset) Contains(r rune) bool {
+	_, found := slices.BinarySearch(c.runes, r)
+	return found
+}
+
+func (c Charset) Len() int {
+	return len(c.runes)
+}
+
+func (c Charset) Runes() []rune {
+	return slices.Clone(c.runes)
+}
+
+func (c Charset) String() string {
+	return string(c.runes)
+}
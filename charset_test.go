@@ -0,0 +1,128 @@
+package passgen
+
+import (
+	"math"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestCharset(t *testing.T) {
+	tests := []struct {
+		name     string
+		charset  Charset
+		expected string
+	}{
+		{
+			name:     "from_string_sorts_and_dedupes",
+			charset:  CharsetFromString("cabbac"),
+			expected: "abc",
+		},
+		{
+			name:     "from_runes",
+			charset:  NewCharset('z', 'x', 'y', 'x'),
+			expected: "xyz",
+		},
+		{
+			name:     "from_range",
+			charset:  CharsetFromRange('a', 'e'),
+			expected: "abcde",
+		},
+		{
+			name:     "from_single_rune_range",
+			charset:  CharsetFromRange('a', 'a'),
+			expected: "a",
+		},
+		{
+			name:     "from_reversed_range",
+			charset:  CharsetFromRange('e', 'a'),
+			expected: "",
+		},
+		{
+			name:     "from_range_beyond_max_rune",
+			charset:  CharsetFromRange(utf8.MaxRune-1, math.MaxInt32),
+			expected: "\U0010FFFE\U0010FFFF",
+		},
+		{
+			name:     "from_negative_range",
+			charset:  CharsetFromRange(-3, 1),
+			expected: "\x00\x01",
+		},
+		{
+			name:     "empty_string",
+			charset:  CharsetFromString(""),
+			expected: "",
+		},
+		{
+			name:     "non_ascii",
+			charset:  CharsetFromString("жёа"),
+			expected: "ажё",
+		},
+		{
+			name:     "union",
+			charset:  CharsetFromString("abc").Union(CharsetFromString("cde"), CharsetFromString("xa")),
+			expected: "abcdex",
+		},
+		{
+			name:     "union_with_nothing",
+			charset:  CharsetFromString("ba").Union(),
+			expected: "ab",
+		},
+		{
+			name:     "difference",
+			charset:  CharsetFromString("abcdef").Difference(CharsetFromString("bdz")),
+			expected: "acef",
+		},
+		{
+			name:     "intersection",
+			charset:  CharsetFromString("abcdef").Intersection(CharsetFromString("fdbz")),
+			expected: "bdf",
+		},
+		{
+			name:     "disjoint_intersection",
+			charset:  Digits.Intersection(Uppercase),
+			expected: "",
+		},
+		{
+			name:     "predefined_digits",
+			charset:  Digits,
+			expected: "0123456789",
+		},
+		{
+			name:     "predefined_symbols",
+			charset:  Symbols,
+			expected: "!#$%&()*+,-.:;<=>?@[]^_{|}",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.charset.String(); got != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, got)
+			}
+			if got := tt.charset.Len(); got != len([]rune(tt.expected)) {
+				t.Errorf("expected length %d, got %d", len([]rune(tt.expected)), got)
+			}
+			for _, r := range tt.expected {
+				if !tt.charset.Contains(r) {
+					t.Errorf("expected charset to contain %q", r)
+				}
+			}
+		})
+	}
+}
+
+func TestCharsetImmutable(t *testing.T) {
+	cs := CharsetFromString("abc")
+
+	runes := cs.Runes()
+	runes[0] = 'z'
+
+	if cs.String() != "abc" {
+		t.Errorf("modifying Runes() result changed the charset: %q", cs.String())
+	}
+
+	_ = cs.Union(CharsetFromString("d"))
+	if cs.String() != "abc" {
+		t.Errorf("Union changed the receiver: %q", cs.String())
+	}
+}
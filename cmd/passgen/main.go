@@ -0,0 +1,324 @@
+// Command passgen generates random passwords from the command line.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/haadi-coder/passgen"
+	"github.com/haadi-coder/passgen/breach"
+)
+
+const (
+	exitOK = iota
+	exitFailure
+	exitUsage
+)
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+// errFlags marks errors from parsing the flags, which the flag set has
+// already printed along with the usage.
+var errFlags = errors.New("invalid flags")
+
+type cliConfig struct {
+	opts []passgen.Option
+	// edits change settings of the policy that no option turns off.
+	edits     []func(*passgen.Config)
+	batchOpts []passgen.BatchOption
+	count     int
+	randFile  string
+	breach    string
+	config    string
+}
+
+func run(args []string, stdout, stderr io.Writer) int {
+	cfg, err := parseArgs(args, stderr)
+	if errors.Is(err, flag.ErrHelp) {
+		return exitOK
+	}
+	if errors.Is(err, errFlags) {
+		return exitUsage
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "passgen: %v\n", err)
+		return exitUsage
+	}
+
+	opts := cfg.opts
+	if cfg.randFile != "" {
+		f, err := os.Open(cfg.randFile)
+		if err != nil {
+			fmt.Fprintf(stderr, "passgen: failed to open randomness source: %v\n", err)
+			return exitFailure
+		}
+		defer f.Close()
+
+		opts = append(opts, passgen.WithRandReader(f))
+	}
+
+	if cfg.breach != "" {
+		corpus, err := breach.Open(cfg.breach)
+		if err != nil {
+			fmt.Fprintf(stderr, "passgen: %v\n", err)
+			return exitFailure
+		}
+		defer corpus.Close()
+
+		opts = append(opts, passgen.WithBreachFilter(corpus))
+	}
+
+	policy := passgen.DefaultConfig()
+	if cfg.config != "" {
+		policy, err = passgen.LoadConfig(cfg.config)
+		if err != nil {
+			fmt.Fprintf(stderr, "passgen: %v\n", err)
+			return exitFailure
+		}
+	}
+	for _, edit := range cfg.edits {
+		edit(&policy)
+	}
+
+	gen, err := passgen.NewGeneratorFromConfig(policy, opts...)
+	if err != nil {
+		fmt.Fprintf(stderr, "passgen: invalid options: %v\n", unwrapAll(err))
+		return exitUsage
+	}
+
+	passwords, err := gen.GenerateN(cfg.count, cfg.batchOpts...)
+	if err != nil {
+		fmt.Fprintf(stderr, "passgen: %v\n", err)
+		return exitFailure
+	}
+
+	for _, password := range passwords {
+		fmt.Fprintln(stdout, password)
+	}
+
+	return exitOK
+}
+
+func parseArgs(args []string, stderr io.Writer) (*cliConfig, error) {
+	fs := flag.NewFlagSet("passgen", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: passgen [flags]\n\nFlags:\n")
+		fs.PrintDefaults()
+	}
+
+	var (
+		count          = fs.Int("n", 1, "number of passwords to generate")
+		unique         = fs.Bool("unique", false, "never print the same password twice")
+		length         = fs.Int("length", 16, "password length (1-10000)")
+		minEntropy     = fs.Float64("min-entropy", 0, "derive the shortest length reaching this many bits of entropy (overrides -length)")
+		noUppercase    = fs.Bool("no-uppercase", false, "exclude uppercase letters")
+		noLowercase    = fs.Bool("no-lowercase", false, "exclude lowercase letters")
+		noDigits       = fs.Bool("no-digits", false, "exclude digits")
+		noSymbols      = fs.Bool("no-symbols", false, "exclude symbols")
+		minUppercase   = fs.Int("min-uppercase", 0, "minimum uppercase letters")
+		minLowercase   = fs.Int("min-lowercase", 0, "minimum lowercase letters")
+		minDigits      = fs.Int("min-digits", 0, "minimum digits")
+		minSymbols     = fs.Int("min-symbols", 0, "minimum symbols")
+		maxUppercase   = fs.Int("max-uppercase", 0, "maximum uppercase letters (0 for no limit)")
+		maxLowercase   = fs.Int("max-lowercase", 0, "maximum lowercase letters (0 for no limit)")
+		maxDigits      = fs.Int("max-digits", 0, "maximum digits (0 for no limit)")
+		maxSymbols     = fs.Int("max-symbols", 0, "maximum symbols (0 for no limit)")
+		noAmbiguous    = fs.Bool("no-ambiguous", false, "exclude look-alike characters such as 0/O/o and 1/l/I")
+		ambiguousChars = fs.String("ambiguous-chars", "", "replace the look-alike table and exclude its characters (implies -no-ambiguous)")
+		pronounceable  = fs.Bool("pronounceable", false, "alternate consonants and vowels; only the minimum digits and symbols are inserted")
+		firstChar      = fs.String("first-char", "", "comma-separated classes the first character is drawn from, e.g. uppercase,lowercase")
+		lastChar       = fs.String("last-char", "", "comma-separated classes the last character is drawn from")
+		maxConsecutive = fs.Int("max-consecutive", 0, "maximum identical characters in a row (0 for no limit)")
+		maxSequential  = fs.Int("max-sequential", 0, "maximum sequential characters such as abc or 321 in a row (0 for no limit)")
+		noRepeats      = fs.Bool("no-repeats", false, "use every character at most once")
+		mask           = fs.String("mask", "", "generate from a hashcat-style mask such as ?u?l?l?l?d?d (overrides -length)")
+		randFile       = fs.String("rand-file", "", "read randomness from this file or device instead of crypto/rand")
+		breachCorpus   = fs.String("breach-corpus", "", "redraw passwords found in this Pwned Passwords hash file, range directory or Bloom filter")
+		configFile     = fs.String("config", "", "load the password policy from a .json, .yaml or .toml file; other flags override it")
+	)
+
+	var weights []passgen.Option
+	fs.Func("class-weights", "weight the fill characters as `upper,lower,digit,symbol`, e.g. 1,8,1,0.5", func(value string) error {
+		opt, err := parseWeights(value)
+		if err != nil {
+			return err
+		}
+
+		weights = append(weights[:0], opt)
+		return nil
+	})
+
+	var charsets []passgen.Option
+	fs.Func("charset", "add a custom character class as `name:min:chars` (repeatable)", func(value string) error {
+		opt, err := parseCharset(value)
+		if err != nil {
+			return err
+		}
+
+		charsets = append(charsets, opt)
+		return nil
+	})
+
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("%w: %w", errFlags, err)
+	}
+	if fs.NArg() > 0 {
+		return nil, fmt.Errorf("unexpected arguments: %s", strings.Join(fs.Args(), " "))
+	}
+	if *count <= 0 {
+		return nil, fmt.Errorf("-n must be greater than 0, got %d", *count)
+	}
+
+	// Only flags given on the command line are applied, so that they
+	// override a policy file instead of resetting it to the defaults.
+	set := make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) { set[f.Name] = true })
+
+	var opts []passgen.Option
+	for _, flagOpt := range []struct {
+		name string
+		opt  passgen.Option
+	}{
+		{"length", passgen.WithLength(*length)},
+		{"min-entropy", passgen.WithMinEntropy(*minEntropy)},
+		{"min-uppercase", passgen.WithMinUppercase(*minUppercase)},
+		{"min-lowercase", passgen.WithMinLowercase(*minLowercase)},
+		{"min-digits", passgen.WithMinDigits(*minDigits)},
+		{"min-symbols", passgen.WithMinSymbols(*minSymbols)},
+		{"max-uppercase", passgen.WithMaxUppercase(*maxUppercase)},
+		{"max-lowercase", passgen.WithMaxLowercase(*maxLowercase)},
+		{"max-digits", passgen.WithMaxDigits(*maxDigits)},
+		{"max-symbols", passgen.WithMaxSymbols(*maxSymbols)},
+		{"max-consecutive", passgen.WithMaxConsecutive(*maxConsecutive)},
+		{"max-sequential", passgen.WithMaxSequential(*maxSequential)},
+	} {
+		if set[flagOpt.name] {
+			opts = append(opts, flagOpt.opt)
+		}
+	}
+
+	// Boolean flags given as false turn their setting back, so that they can
+	// undo a policy file too.
+	for _, flagOpt := range []struct {
+		name    string
+		value   bool
+		on, off passgen.Option
+	}{
+		{"no-uppercase", *noUppercase, passgen.WithoutUppercase(), passgen.WithUppercase()},
+		{"no-lowercase", *noLowercase, passgen.WithoutLowercase(), passgen.WithLowercase()},
+		{"no-digits", *noDigits, passgen.WithoutDigits(), passgen.WithDigits()},
+		{"no-symbols", *noSymbols, passgen.WithoutSymbols(), passgen.WithSymbols()},
+		{"no-ambiguous", *noAmbiguous, passgen.WithoutAmbiguous(), passgen.WithAmbiguous()},
+	} {
+		switch {
+		case !set[flagOpt.name]:
+		case flagOpt.value:
+			opts = append(opts, flagOpt.on)
+		default:
+			opts = append(opts, flagOpt.off)
+		}
+	}
+
+	if *ambiguousChars != "" {
+		if set["no-ambiguous"] && !*noAmbiguous {
+			return nil, fmt.Errorf("-ambiguous-chars cannot be combined with -no-ambiguous=false")
+		}
+		opts = append(opts, passgen.WithAmbiguousChars(passgen.CharsetFromString(*ambiguousChars)))
+		if !set["no-ambiguous"] {
+			opts = append(opts, passgen.WithoutAmbiguous())
+		}
+	}
+
+	var edits []func(*passgen.Config)
+	if set["pronounceable"] {
+		edits = append(edits, func(c *passgen.Config) { c.Pronounceable = *pronounceable })
+	}
+	if set["no-repeats"] {
+		edits = append(edits, func(c *passgen.Config) { c.NoRepeats = *noRepeats })
+	}
+
+	if *mask != "" {
+		opts = append(opts, passgen.WithMask(*mask))
+	}
+	if *firstChar != "" {
+		opts = append(opts, passgen.WithFirstChar(strings.Split(*firstChar, ",")...))
+	}
+	if *lastChar != "" {
+		opts = append(opts, passgen.WithLastChar(strings.Split(*lastChar, ",")...))
+	}
+
+	var batchOpts []passgen.BatchOption
+	if *unique {
+		batchOpts = append(batchOpts, passgen.WithUnique())
+	}
+
+	return &cliConfig{
+		opts:      append(append(opts, weights...), charsets...),
+		edits:     edits,
+		batchOpts: batchOpts,
+		count:     *count,
+		randFile:  *randFile,
+		breach:    *breachCorpus,
+		config:    *configFile,
+	}, nil
+}
+
+func parseCharset(value string) (passgen.Option, error) {
+	name, rest, ok := strings.Cut(value, ":")
+	if !ok {
+		return nil, fmt.Errorf("charset must be name:min:chars, got %q", value)
+	}
+
+	minStr, chars, ok := strings.Cut(rest, ":")
+	if !ok {
+		return nil, fmt.Errorf("charset must be name:min:chars, got %q", value)
+	}
+
+	minCount, err := strconv.Atoi(minStr)
+	if err != nil {
+		return nil, fmt.Errorf("charset %q has invalid minimum %q", name, minStr)
+	}
+
+	return passgen.WithCharset(name, passgen.CharsetFromString(chars), minCount), nil
+}
+
+func parseWeights(value string) (passgen.Option, error) {
+	parts := strings.Split(value, ",")
+	if len(parts) != 4 {
+		return nil, fmt.Errorf("class weights must be upper,lower,digit,symbol, got %q", value)
+	}
+
+	weights := make([]float64, len(parts))
+	for i, part := range parts {
+		w, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid class weight %q", part)
+		}
+		weights[i] = w
+	}
+
+	return passgen.WithClassWeights(weights[0], weights[1], weights[2], weights[3]), nil
+}
+
+// unwrapAll strips the "failed to ..." wrappers added by the library and
+// returns the underlying validation message.
+func unwrapAll(err error) error {
+	for {
+		inner := errors.Unwrap(err)
+		if inner == nil {
+			return err
+		}
+		err = inner
+	}
+}
@@ -0,0 +1,389 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestRun(t *testing.T) {
+	tests := []struct {
+		name         string
+		args         []string
+		expectedCode int
+		expectedRows int
+		length       int
+		allowed      string
+		stderr       string
+	}{
+		{
+			name:         "defaults",
+			args:         nil,
+			expectedCode: exitOK,
+			expectedRows: 1,
+			length:       16,
+		},
+		{
+			name:         "many passwords",
+			args:         []string{"-n", "5", "--length", "24"},
+			expectedCode: exitOK,
+			expectedRows: 5,
+			length:       24,
+		},
+		{
+			name:         "digits only",
+			args:         []string{"--no-uppercase", "--no-lowercase", "--no-symbols", "--length", "8", "--min-digits", "8"},
+			expectedCode: exitOK,
+			expectedRows: 1,
+			length:       8,
+			allowed:      "0123456789",
+		},
+		{
+			name:         "custom charset",
+			args:         []string{"--no-uppercase", "--no-lowercase", "--no-digits", "--no-symbols", "--charset", "hex:2:0123456789abcdef", "--charset", "colon:1::"},
+			expectedCode: exitOK,
+			expectedRows: 1,
+			length:       16,
+			allowed:      "0123456789abcdef:",
+		},
+		{
+			name:         "without ambiguous",
+			args:         []string{"--no-ambiguous", "--ambiguous-chars", "abc", "--no-uppercase", "--no-digits", "--no-symbols", "-n", "20"},
+			expectedCode: exitOK,
+			expectedRows: 20,
+			length:       16,
+			allowed:      "defghijklmnopqrstuvwxyz",
+		},
+		{
+			name:         "ambiguous chars imply no ambiguous",
+			args:         []string{"--ambiguous-chars", "abc", "--no-uppercase", "--no-digits", "--no-symbols", "-n", "20"},
+			expectedCode: exitOK,
+			expectedRows: 20,
+			length:       16,
+			allowed:      "defghijklmnopqrstuvwxyz",
+		},
+		{
+			name:         "disabled class enabled again",
+			args:         []string{"--no-digits", "--no-digits=false", "--no-uppercase", "--no-lowercase", "--no-symbols"},
+			expectedCode: exitOK,
+			expectedRows: 1,
+			length:       16,
+			allowed:      "0123456789",
+		},
+		{
+			name:         "pronounceable",
+			args:         []string{"--pronounceable", "--length", "10", "--min-digits", "2", "-n", "5"},
+			expectedCode: exitOK,
+			expectedRows: 5,
+			length:       10,
+			allowed:      "abdefghijklmnoprstuvwz0123456789",
+		},
+		{
+			name:         "mask",
+			args:         []string{"--mask", "?d?d-?d?d", "-n", "5"},
+			expectedCode: exitOK,
+			expectedRows: 5,
+			length:       5,
+			allowed:      "0123456789-",
+		},
+		{
+			name:         "first and last character",
+			args:         []string{"--first-char", "uppercase,lowercase", "--last-char", "digits", "--no-symbols", "-n", "5"},
+			expectedCode: exitOK,
+			expectedRows: 5,
+			length:       16,
+		},
+		{
+			name:         "repetition rules",
+			args:         []string{"--max-consecutive", "1", "--max-sequential", "2", "--length", "20", "-n", "5"},
+			expectedCode: exitOK,
+			expectedRows: 5,
+			length:       20,
+		},
+		{
+			name:         "no repeats",
+			args:         []string{"--no-repeats", "--length", "10", "--no-uppercase", "--no-lowercase", "--no-symbols"},
+			expectedCode: exitOK,
+			expectedRows: 1,
+			length:       10,
+			allowed:      "0123456789",
+		},
+		{
+			name:         "max counts",
+			args:         []string{"--max-symbols", "0", "--max-uppercase", "2", "--max-lowercase", "2", "--max-digits", "2", "--no-symbols", "--length", "6"},
+			expectedCode: exitOK,
+			expectedRows: 1,
+			length:       6,
+		},
+		{
+			name:         "class weights",
+			args:         []string{"--class-weights", "1,8,1,0.5", "--min-lowercase", "2", "-n", "3"},
+			expectedCode: exitOK,
+			expectedRows: 3,
+			length:       16,
+		},
+		{
+			name:         "invalid class weights",
+			args:         []string{"--class-weights", "1,8,1"},
+			expectedCode: exitUsage,
+			stderr:       "class weights must be upper,lower,digit,symbol",
+		},
+		{
+			name:         "min entropy",
+			args:         []string{"--min-entropy", "64", "--no-uppercase", "--no-lowercase", "--no-symbols"},
+			expectedCode: exitOK,
+			expectedRows: 1,
+			length:       20,
+		},
+		{
+			name:         "unique passwords",
+			args:         []string{"-n", "10", "--unique", "--length", "1", "--no-uppercase", "--no-lowercase", "--no-symbols"},
+			expectedCode: exitOK,
+			expectedRows: 10,
+			length:       1,
+			allowed:      "0123456789",
+		},
+		{
+			name:         "unique passwords exceed keyspace",
+			args:         []string{"-n", "11", "--unique", "--length", "1", "--no-uppercase", "--no-lowercase", "--no-symbols"},
+			expectedCode: exitFailure,
+			stderr:       "passgen: cannot generate 11 unique passwords from a keyspace of 10\n",
+		},
+		{
+			name:         "invalid configuration",
+			args:         []string{"--length", "0"},
+			expectedCode: exitUsage,
+			stderr:       "passgen: invalid options: password length must be greater than 0, got 0\n",
+		},
+		{
+			name:         "conflicting options",
+			args:         []string{"--no-digits", "--min-digits", "2"},
+			expectedCode: exitUsage,
+			stderr:       "passgen: invalid options: digits are disabled but minimum digits requirement is 2\n",
+		},
+		{
+			name:         "ambiguous chars with ambiguous characters allowed",
+			args:         []string{"--ambiguous-chars", "abc", "--no-ambiguous=false"},
+			expectedCode: exitUsage,
+			stderr:       "passgen: -ambiguous-chars cannot be combined with -no-ambiguous=false\n",
+		},
+		{
+			name:         "invalid count",
+			args:         []string{"-n", "0"},
+			expectedCode: exitUsage,
+			stderr:       "passgen: -n must be greater than 0, got 0\n",
+		},
+		{
+			name:         "invalid charset",
+			args:         []string{"--charset", "hex"},
+			expectedCode: exitUsage,
+			stderr:       `invalid value "hex" for flag -charset: charset must be name:min:chars, got "hex"`,
+		},
+		{
+			name:         "invalid charset minimum",
+			args:         []string{"--charset", "hex:x:abc"},
+			expectedCode: exitUsage,
+			stderr:       `charset "hex" has invalid minimum "x"`,
+		},
+		{
+			name:         "unknown flag",
+			args:         []string{"--bogus"},
+			expectedCode: exitUsage,
+			stderr:       "flag provided but not defined: -bogus",
+		},
+		{
+			name:         "positional arguments",
+			args:         []string{"extra"},
+			expectedCode: exitUsage,
+			stderr:       "passgen: unexpected arguments: extra\n",
+		},
+		{
+			name:         "help",
+			args:         []string{"-h"},
+			expectedCode: exitOK,
+			stderr:       "Usage: passgen [flags]",
+		},
+		{
+			name:         "missing rand file",
+			args:         []string{"--rand-file", filepath.Join(os.TempDir(), "passgen-does-not-exist")},
+			expectedCode: exitFailure,
+			stderr:       "passgen: failed to open randomness source",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+
+			code := run(tt.args, &stdout, &stderr)
+			if code != tt.expectedCode {
+				t.Fatalf("expected exit code %d, got %d (stderr: %s)", tt.expectedCode, code, stderr.String())
+			}
+			if tt.stderr != "" && !strings.Contains(stderr.String(), tt.stderr) {
+				t.Errorf("expected stderr to contain %q, got %q", tt.stderr, stderr.String())
+			}
+			if n := strings.Count(stderr.String(), tt.stderr); tt.stderr != "" && n > 1 {
+				t.Errorf("expected %q once in stderr, got it %d times", tt.stderr, n)
+			}
+
+			if tt.expectedRows == 0 {
+				return
+			}
+
+			rows := strings.Split(strings.TrimSuffix(stdout.String(), "\n"), "\n")
+			if len(rows) != tt.expectedRows {
+				t.Fatalf("expected %d passwords, got %d: %q", tt.expectedRows, len(rows), stdout.String())
+			}
+			for _, row := range rows {
+				if len(row) != tt.length {
+					t.Errorf("expected length %d, got %d: %q", tt.length, len(row), row)
+				}
+				if tt.allowed == "" {
+					continue
+				}
+				for _, r := range row {
+					if !strings.ContainsRune(tt.allowed, r) {
+						t.Errorf("password %q contains unexpected character %q", row, r)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestRunRandFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "random")
+	if err := os.WriteFile(path, bytes.Repeat([]byte{0x5a, 0xa5, 0x3c}, 1024), 0o600); err != nil {
+		t.Fatalf("failed to write random file: %v", err)
+	}
+
+	var first, second, stderr bytes.Buffer
+	if code := run([]string{"--rand-file", path}, &first, &stderr); code != exitOK {
+		t.Fatalf("expected exit code %d, got %d (stderr: %s)", exitOK, code, stderr.String())
+	}
+	if code := run([]string{"--rand-file", path}, &second, &stderr); code != exitOK {
+		t.Fatalf("expected exit code %d, got %d (stderr: %s)", exitOK, code, stderr.String())
+	}
+
+	if first.String() != second.String() {
+		t.Errorf("expected identical output from the same randomness file, got %q and %q", first.String(), second.String())
+	}
+
+	short := filepath.Join(t.TempDir(), "short")
+	if err := os.WriteFile(short, []byte{1, 2, 3}, 0o600); err != nil {
+		t.Fatalf("failed to write random file: %v", err)
+	}
+	stderr.Reset()
+	if code := run([]string{"--rand-file", short}, &first, &stderr); code != exitFailure {
+		t.Errorf("expected exit code %d, got %d", exitFailure, code)
+	}
+	if !strings.Contains(stderr.String(), "failed to read random bytes") {
+		t.Errorf("expected read error, got %q", stderr.String())
+	}
+}
+
+func TestRunBreachCorpus(t *testing.T) {
+	// The two-digit passwords below 50 are breached.
+	var lines []string
+	for i := range 50 {
+		sum := sha1.Sum(fmt.Appendf(nil, "%02d", i))
+		lines = append(lines, strings.ToUpper(hex.EncodeToString(sum[:]))+":1")
+	}
+	slices.Sort(lines)
+
+	path := filepath.Join(t.TempDir(), "pwned.txt")
+	if err := os.WriteFile(path, []byte(strings.Join(lines, "\n")), 0o600); err != nil {
+		t.Fatalf("failed to write corpus: %v", err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	args := []string{"--breach-corpus", path, "-n", "50", "--length", "2", "--no-uppercase", "--no-lowercase", "--no-symbols"}
+	if code := run(args, &stdout, &stderr); code != exitOK {
+		t.Fatalf("expected exit code %d, got %d (stderr: %s)", exitOK, code, stderr.String())
+	}
+	for _, password := range strings.Fields(stdout.String()) {
+		if password < "50" {
+			t.Errorf("expected no breached passwords, got %q", password)
+		}
+	}
+
+	stderr.Reset()
+	if code := run([]string{"--breach-corpus", filepath.Join(t.TempDir(), "missing")}, &stdout, &stderr); code != exitFailure {
+		t.Errorf("expected exit code %d, got %d", exitFailure, code)
+	}
+	if !strings.Contains(stderr.String(), "failed to open corpus") {
+		t.Errorf("expected open error, got %q", stderr.String())
+	}
+}
+
+func TestRunConfig(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "policy.yaml")
+	policy := "length: 10\nuppercase: false\nlowercase: false\nsymbols: false\n"
+	if err := os.WriteFile(path, []byte(policy), 0o600); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"--config", path}, &stdout, &stderr); code != exitOK {
+		t.Fatalf("expected exit code %d, got %d (stderr: %s)", exitOK, code, stderr.String())
+	}
+	if password := strings.TrimSpace(stdout.String()); !regexp.MustCompile(`^[0-9]{10}$`).MatchString(password) {
+		t.Errorf("expected 10 digits, got %q", password)
+	}
+
+	stdout.Reset()
+	if code := run([]string{"--config", path, "--length", "4"}, &stdout, &stderr); code != exitOK {
+		t.Fatalf("expected exit code %d, got %d (stderr: %s)", exitOK, code, stderr.String())
+	}
+	if password := strings.TrimSpace(stdout.String()); !regexp.MustCompile(`^[0-9]{4}$`).MatchString(password) {
+		t.Errorf("expected flags to override the config, got %q", password)
+	}
+
+	stdout.Reset()
+	if code := run([]string{"--config", path, "--no-lowercase=false", "--no-digits"}, &stdout, &stderr); code != exitOK {
+		t.Fatalf("expected exit code %d, got %d (stderr: %s)", exitOK, code, stderr.String())
+	}
+	if password := strings.TrimSpace(stdout.String()); !regexp.MustCompile(`^[a-z]{10}$`).MatchString(password) {
+		t.Errorf("expected flags to enable lowercase letters again, got %q", password)
+	}
+
+	pronounceable := filepath.Join(dir, "pronounceable.json")
+	if err := os.WriteFile(pronounceable, []byte(`{"length": 12, "lowercase": true, "pronounceable": true, "no_repeats": true}`), 0o600); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+	stdout.Reset()
+	if code := run([]string{"--config", pronounceable, "--pronounceable=false", "--no-repeats=false", "--length", "30", "--no-uppercase", "--no-lowercase", "--no-symbols", "--no-digits=false"}, &stdout, &stderr); code != exitOK {
+		t.Fatalf("expected exit code %d, got %d (stderr: %s)", exitOK, code, stderr.String())
+	}
+	if password := strings.TrimSpace(stdout.String()); !regexp.MustCompile(`^[0-9]{30}$`).MatchString(password) {
+		t.Errorf("expected flags to turn off pronounceable passwords and no repeats, got %q", password)
+	}
+
+	invalid := filepath.Join(dir, "invalid.toml")
+	if err := os.WriteFile(invalid, []byte("length = 0\n"), 0o600); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+	stderr.Reset()
+	if code := run([]string{"--config", invalid}, &stdout, &stderr); code != exitUsage {
+		t.Errorf("expected exit code %d, got %d", exitUsage, code)
+	}
+	if !strings.Contains(stderr.String(), "password length must be greater than 0") {
+		t.Errorf("expected validation error, got %q", stderr.String())
+	}
+
+	stderr.Reset()
+	if code := run([]string{"--config", filepath.Join(dir, "missing.json")}, &stdout, &stderr); code != exitFailure {
+		t.Errorf("expected exit code %d, got %d", exitFailure, code)
+	}
+	if !strings.Contains(stderr.String(), "failed to read config") {
+		t.Errorf("expected read error, got %q", stderr.String())
+	}
+}
@@ -1,9 +1,38 @@
 package passgen
 
-import "fmt"
+import (
+	"crypto/rand"
+	"fmt"
+	"io"
+	"math"
+	"slices"
+	"unicode/utf8"
+)
+
+const maxLength = 10000
+
+// noLimit is the maximum of a class without a maximum requirement.
+const noLimit = math.MaxInt
+
+// Names of the built-in character classes, as used by WithFirstChar,
+// WithLastChar and in policy violations.
+const (
+	ClassUppercase = "uppercase"
+	ClassLowercase = "lowercase"
+	ClassDigits    = "digits"
+	ClassSymbols   = "symbols"
+)
+
+type charClass struct {
+	name  string
+	chars []rune
+	min   int
+	max   int
+}
 
 type config struct {
-	length int
+	length     int
+	minEntropy float64
 
 	useUppercase bool
 	useLowercase bool
@@ -14,6 +43,34 @@ type config struct {
 	minLowercase int
 	minDigits    int
 	minSymbols   int
+
+	maxUppercase int
+	maxLowercase int
+	maxDigits    int
+	maxSymbols   int
+
+	charsets []charClass
+
+	excludeAmbiguous bool
+	ambiguous        Charset
+
+	pronounceable bool
+	mask          string
+
+	firstChar []string
+	lastChar  []string
+
+	maxConsecutive int
+	maxSequential  int
+	noRepeats      bool
+
+	// weights holds the uppercase, lowercase, digit and symbol weights of
+	// the fill characters, or nil for uniform filling.
+	weights []float64
+
+	breach BreachFilter
+
+	rand io.Reader
 }
 
 func defaultConfig() *config {
@@ -23,51 +80,425 @@ func defaultConfig() *config {
 		useLowercase: true,
 		useDigits:    true,
 		useSymbols:   true,
+		ambiguous:    Ambiguous,
+		rand:         rand.Reader,
 	}
 }
 
+// validate checks the configuration and derives the length for a minimum
+// entropy or a mask. It reports every invalid setting at once: the settings
+// are checked in stages, and a stage only runs when the ones before it
+// passed, since it builds on them.
 func (c *config) validate() error {
-	if c.length <= 0 {
-		return fmt.Errorf("password length must be greater than 0, got %d", c.length)
+	if errs := c.validateSettings(); len(errs) > 0 {
+		return errs
+	}
+
+	classes := c.classes()
+	anchors, errs := c.validateClasses(classes)
+	if len(errs) > 0 {
+		return errs
+	}
+
+	if errs := c.validateLength(classes, anchors); len(errs) > 0 {
+		return errs
+	}
+
+	return nil
+}
+
+// validateSettings checks every setting on its own and the combinations of
+// options that cannot be used together.
+func (c *config) validateSettings() configErrors {
+	var errs configErrors
+	add := func(field string, value any, rule error, format string, args ...any) {
+		errs = append(errs, newConfigError(field, value, rule, format, args...))
+	}
+
+	if math.IsNaN(c.minEntropy) || math.IsInf(c.minEntropy, 0) || c.minEntropy < 0 {
+		add(FieldMinEntropy, c.minEntropy, ErrOutOfRange, "minimum entropy must be a non-negative number, got %v", c.minEntropy)
+	}
+
+	// With a minimum entropy or a mask the length is derived at the end of
+	// validation.
+	if c.minEntropy == 0 && c.mask == "" {
+		if c.length <= 0 {
+			add(FieldLength, c.length, ErrOutOfRange, "password length must be greater than 0, got %d", c.length)
+		}
+		if c.length > maxLength {
+			add(FieldLength, c.length, ErrOutOfRange, "password length must not exceed %d, got %d", maxLength, c.length)
+		}
 	}
-	if c.length > 10000 {
-		return fmt.Errorf("password length must not exceed 10000, got %d", c.length)
+
+	builtins := []struct {
+		name               string
+		enabled            bool
+		min, max           int
+		minField, maxField string
+		disabled           string
+	}{
+		{"uppercase", c.useUppercase, c.minUppercase, c.maxUppercase, FieldMinUppercase, FieldMaxUppercase, "uppercase characters are"},
+		{"lowercase", c.useLowercase, c.minLowercase, c.maxLowercase, FieldMinLowercase, FieldMaxLowercase, "lowercase characters are"},
+		{"digits", c.useDigits, c.minDigits, c.maxDigits, FieldMinDigits, FieldMaxDigits, "digits are"},
+		{"symbols", c.useSymbols, c.minSymbols, c.maxSymbols, FieldMinSymbols, FieldMaxSymbols, "symbols are"},
+	}
+	for _, b := range builtins {
+		if b.min < 0 {
+			add(b.minField, b.min, ErrOutOfRange, "minimum %s count cannot be negative, got %d", b.name, b.min)
+		}
+		if b.max < 0 {
+			add(b.maxField, b.max, ErrOutOfRange, "maximum %s count cannot be negative, got %d", b.name, b.max)
+		}
+		if b.max > 0 && b.max < b.min {
+			add(b.maxField, b.max, ErrMaxBelowMin, "maximum %s count (%d) cannot be less than the minimum (%d)", b.name, b.max, b.min)
+		}
+		if !b.enabled && b.min > 0 {
+			add(b.minField, b.min, ErrDisabledClass, "%s disabled but minimum %s requirement is %d", b.disabled, b.name, b.min)
+		}
 	}
 
-	if c.minUppercase < 0 {
-		return fmt.Errorf("minimum uppercase count cannot be negative, got %d", c.minUppercase)
+	if c.maxConsecutive < 0 {
+		add(FieldMaxConsecutive, c.maxConsecutive, ErrOutOfRange, "maximum consecutive identical characters cannot be negative, got %d", c.maxConsecutive)
 	}
-	if c.minLowercase < 0 {
-		return fmt.Errorf("minimum lowercase count cannot be negative, got %d", c.minLowercase)
+	if c.maxSequential < 0 {
+		add(FieldMaxSequential, c.maxSequential, ErrOutOfRange, "maximum sequential characters cannot be negative, got %d", c.maxSequential)
+	}
+
+	for i, cs := range c.charsets {
+		switch {
+		case cs.name == "":
+			add(charsetField(i, "name"), cs.name, ErrInvalidCharset, "charset name must not be empty")
+		case isBuiltinClass(cs.name):
+			add(charsetField(i, "name"), cs.name, ErrInvalidCharset, "charset name %q is reserved", cs.name)
+		}
+		if len(cs.chars) == 0 {
+			add(charsetField(i, "chars"), "", ErrInvalidCharset, "charset %q must not be empty", cs.name)
+		}
+		if j := slices.IndexFunc(cs.chars, func(r rune) bool { return !utf8.ValidRune(r) }); j >= 0 {
+			add(charsetField(i, "chars"), cs.chars[j], ErrInvalidCharset, "charset %q contains %U, which is not a valid character", cs.name, cs.chars[j])
+		}
+		if cs.min < 0 {
+			add(charsetField(i, "min"), cs.min, ErrOutOfRange, "minimum count for charset %q cannot be negative, got %d", cs.name, cs.min)
+		}
 	}
-	if c.minDigits < 0 {
-		return fmt.Errorf("minimum digits count cannot be negative, got %d", c.minDigits)
+
+	if !c.useUppercase && !c.useLowercase && !c.useDigits && !c.useSymbols && len(c.charsets) == 0 {
+		add("", nil, ErrNoCharacters, "at least one character set must be enabled")
+	}
+
+	if c.weights != nil {
+		if err := validateWeights(c.weights); err != nil {
+			add(FieldClassWeights, c.weights, ErrOutOfRange, "%v", err)
+		}
 	}
-	if c.minSymbols < 0 {
-		return fmt.Errorf("minimum symbols count cannot be negative, got %d", c.minSymbols)
+
+	hasMax := c.maxUppercase > 0 || c.maxLowercase > 0 || c.maxDigits > 0 || c.maxSymbols > 0
+	hasRepetition := c.maxConsecutive > 0 || c.maxSequential > 0
+	hasAnchors := len(c.firstChar) > 0 || len(c.lastChar) > 0
+	anchorField, anchorValue := FieldFirstChar, c.firstChar
+	if len(c.firstChar) == 0 {
+		anchorField, anchorValue = FieldLastChar, c.lastChar
 	}
 
-	if !c.useUppercase && !c.useLowercase && !c.useDigits && !c.useSymbols {
-		return fmt.Errorf("at least one character set must be enabled")
+	if c.pronounceable {
+		if !c.useUppercase && !c.useLowercase {
+			add(FieldPronounceable, true, ErrConflictingOptions, "pronounceable passwords require uppercase or lowercase letters")
+		}
+		if hasRepetition {
+			add(FieldPronounceable, true, ErrConflictingOptions, "repetition rules cannot be combined with pronounceable passwords")
+		}
+		if hasMax {
+			add(FieldPronounceable, true, ErrConflictingOptions, "maximum requirements cannot be combined with pronounceable passwords")
+		}
+		if c.noRepeats {
+			add(FieldNoRepeats, true, ErrConflictingOptions, "unique characters cannot be combined with pronounceable passwords")
+		}
+		if hasAnchors {
+			add(anchorField, anchorValue, ErrConflictingOptions, "first and last character constraints cannot be combined with pronounceable passwords")
+		}
 	}
 
-	if !c.useUppercase && c.minUppercase > 0 {
-		return fmt.Errorf("uppercase characters are disabled but minimum uppercase requirement is %d", c.minUppercase)
+	if c.mask != "" {
+		if c.minEntropy > 0 {
+			add(FieldMinEntropy, c.minEntropy, ErrConflictingOptions, "minimum entropy cannot be combined with a mask")
+		}
+		if c.pronounceable {
+			add(FieldPronounceable, true, ErrConflictingOptions, "pronounceable passwords cannot be combined with a mask")
+		}
+		if totalMin := c.minUppercase + c.minLowercase + c.minDigits + c.minSymbols + c.charsetMinimums(); totalMin > 0 {
+			add(FieldMask, c.mask, ErrConflictingOptions, "minimum requirements cannot be combined with a mask, got %d", totalMin)
+		}
+		if hasMax {
+			add(FieldMask, c.mask, ErrConflictingOptions, "maximum requirements cannot be combined with a mask")
+		}
+		if c.noRepeats {
+			add(FieldNoRepeats, true, ErrConflictingOptions, "unique characters cannot be combined with a mask")
+		}
+		if hasAnchors {
+			add(anchorField, anchorValue, ErrConflictingOptions, "first and last character constraints cannot be combined with a mask")
+		}
 	}
-	if !c.useLowercase && c.minLowercase > 0 {
-		return fmt.Errorf("lowercase characters are disabled but minimum lowercase requirement is %d", c.minLowercase)
+
+	if c.noRepeats && c.maxSequential > 0 {
+		add(FieldNoRepeats, true, ErrConflictingOptions, "unique characters cannot be combined with a maximum sequential run")
 	}
-	if !c.useDigits && c.minDigits > 0 {
-		return fmt.Errorf("digits are disabled but minimum digits requirement is %d", c.minDigits)
+
+	if c.weights != nil {
+		conflicts := []struct {
+			set  bool
+			name string
+		}{
+			{c.mask != "", "a mask"},
+			{c.pronounceable, "pronounceable passwords"},
+			{hasAnchors, "first and last character constraints"},
+			{hasMax, "maximum requirements"},
+			{hasRepetition, "repetition rules"},
+			{c.noRepeats, "unique characters"},
+		}
+		for _, conflict := range conflicts {
+			if conflict.set {
+				add(FieldClassWeights, c.weights, ErrConflictingOptions, "class weights cannot be combined with %s", conflict.name)
+			}
+		}
 	}
-	if !c.useSymbols && c.minSymbols > 0 {
-		return fmt.Errorf("symbols are disabled but minimum symbols requirement is %d", c.minSymbols)
+
+	return errs
+}
+
+// validateClasses checks the enabled classes once ambiguous characters are
+// excluded, the mask and the first and last character constraints.
+func (c *config) validateClasses(classes []charClass) (*anchors, configErrors) {
+	var errs configErrors
+	add := func(field string, value any, rule error, format string, args ...any) {
+		errs = append(errs, newConfigError(field, value, rule, format, args...))
+	}
+
+	for i, a := range classes {
+		for _, b := range classes[i+1:] {
+			if overlap := NewCharset(a.chars...).Intersection(NewCharset(b.chars...)); overlap.Len() > 0 {
+				add(c.classField(b.name, "chars"), overlap.String(), ErrOverlappingCharsets, "charset %q overlaps with %q", b.name, a.name)
+			}
+		}
+	}
+
+	poolSize := 0
+	for _, class := range classes {
+		if len(class.chars) == 0 && class.min > 0 {
+			add(c.classField(class.name, "min"), class.min, ErrNoCharacters, "charset %q is empty after excluding ambiguous characters but minimum requirement is %d", class.name, class.min)
+		}
+		if c.noRepeats && class.min > len(class.chars) {
+			add(c.classField(class.name, "min"), class.min, ErrUnsatisfiable, "charset %q has %d characters but minimum requirement is %d without repeats", class.name, len(class.chars), class.min)
+		}
+
+		poolSize += len(class.chars)
+	}
+	if poolSize == 0 {
+		add(FieldExcludeAmbiguous, c.excludeAmbiguous, ErrNoCharacters, "no characters left after excluding ambiguous characters")
+	}
+
+	if c.pronounceable {
+		s := newSyllables(c, classes)
+		if len(s.vowels) == 0 || len(s.consonants) == 0 {
+			add(FieldPronounceable, true, ErrNoCharacters, "no vowels or consonants left after excluding ambiguous characters")
+		}
+	}
+
+	if c.mask != "" {
+		mask, err := parseMask(c.mask, classes)
+		switch {
+		case err != nil:
+			add(FieldMask, c.mask, ErrInvalidMask, "invalid mask %q: %v", c.mask, err)
+		case len(mask) > maxLength:
+			add(FieldMask, c.mask, ErrOutOfRange, "password length must not exceed %d, got %d", maxLength, len(mask))
+		default:
+			c.length = len(mask)
+		}
+	}
+
+	anchors, err := c.anchors(classes)
+	if err != nil {
+		errs = append(errs, err)
+	}
+
+	return anchors, errs
+}
+
+// validateLength derives the length for a minimum entropy and checks that
+// passwords of the length can meet every requirement.
+func (c *config) validateLength(classes []charClass, anchors *anchors) configErrors {
+	totalMin, poolSize := 0, 0
+	for _, class := range classes {
+		totalMin += class.min
+		poolSize += len(class.chars)
+	}
+
+	if c.minEntropy > 0 {
+		length, ok := lengthForEntropy(c.entropy(classes), poolSize, totalMin, c.minEntropy)
+		if !ok {
+			return configErrors{newConfigError(FieldMinEntropy, c.minEntropy, ErrUnsatisfiable, "minimum entropy of %v bits requires a password longer than %d", c.minEntropy, maxLength)}
+		}
+		c.length = length
+	}
+
+	var errs configErrors
+	add := func(field string, value any, rule error, format string, args ...any) {
+		errs = append(errs, newConfigError(field, value, rule, format, args...))
 	}
 
-	totalMin := c.minUppercase + c.minLowercase + c.minDigits + c.minSymbols
 	if totalMin > c.length {
-		return fmt.Errorf("sum of minimum requirements (%d) cannot exceed password length (%d)", totalMin, c.length)
+		add(FieldLength, c.length, ErrUnsatisfiable, "sum of minimum requirements (%d) cannot exceed password length (%d)", totalMin, c.length)
 	}
 
-	return nil
+	if c.noRepeats && c.length > poolSize {
+		add(FieldLength, c.length, ErrUnsatisfiable, "password length %d exceeds the %d available characters without repeats", c.length, poolSize)
+	}
+
+	// capacity is the longest password the maximums allow, capped once it
+	// exceeds every valid length.
+	capacity := 0
+	for _, class := range classes {
+		available := len(class.chars) * c.length
+		if c.noRepeats {
+			available = len(class.chars)
+		}
+		capacity = min(capacity+min(class.max, available), maxLength+1)
+	}
+	if capacity < c.length {
+		add(FieldLength, c.length, ErrUnsatisfiable, "sum of maximum requirements (%d) cannot be less than password length (%d)", capacity, c.length)
+	}
+	if c.maxConsecutive > 0 && poolSize == 1 && c.length > c.maxConsecutive {
+		add(FieldMaxConsecutive, c.maxConsecutive, ErrUnsatisfiable, "a single available character cannot fill %d positions with at most %d in a row", c.length, c.maxConsecutive)
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+
+	// Counting the passwords only makes sense once the simple bounds hold.
+	if c.pronounceable && c.counter(classes)(c.length).Sign() == 0 {
+		add(FieldPronounceable, true, ErrUnsatisfiable, "no pronounceable password of length %d satisfies the minimum requirements", c.length)
+	}
+	// Every placement left has passwords, since the remaining minimums and
+	// maximums fit the free positions.
+	if anchors != nil && len(anchors.placements(classes, c.length)) == 0 {
+		field, value := FieldFirstChar, c.firstChar
+		if len(c.firstChar) == 0 {
+			field, value = FieldLastChar, c.lastChar
+		}
+		add(field, value, ErrUnsatisfiable, "no password of length %d satisfies the first and last character constraints and the minimum requirements", c.length)
+	}
+
+	// Repetition rules whose exact sampler is too large are met by redrawing,
+	// which needs enough of the passwords to meet them.
+	if c.maxSequential > 0 || c.maxConsecutive > 0 && !c.noRepeats {
+		rep := newRepetition(classes, c.allowed(classes, c.length), c.length, c.maxConsecutive, c.maxSequential)
+		if rep.tableBytes() > maxRepetitionTable {
+			field, value := FieldMaxConsecutive, c.maxConsecutive
+			if c.maxConsecutive == 0 {
+				field, value = FieldMaxSequential, c.maxSequential
+			}
+
+			draw, neighbours, ok := c.repetitionShares(classes)
+			switch share := max(draw, neighbours); {
+			case !ok:
+				add(field, value, ErrUnsatisfiable, "passwords of length %d are too long to check that random draws meet the repetition rules", c.length)
+			case share < -math.Log(repetitionAcceptance):
+				add(field, value, ErrUnsatisfiable, "only about 1 in 10^%.1f passwords of length %d meets the repetition rules, too few to draw one at random", -share/math.Ln10, c.length)
+			}
+		}
+	}
+
+	return errs
+}
+
+// charsetMinimums returns the sum of the minimums of the custom charsets.
+func (c *config) charsetMinimums() int {
+	total := 0
+	for _, cs := range c.charsets {
+		total += cs.min
+	}
+
+	return total
+}
+
+// classField returns the field of a class setting: the minimum of a built-in
+// class, or the given part of a custom charset.
+func (c *config) classField(name, part string) string {
+	switch name {
+	case ClassUppercase:
+		return FieldMinUppercase
+	case ClassLowercase:
+		return FieldMinLowercase
+	case ClassDigits:
+		return FieldMinDigits
+	case ClassSymbols:
+		return FieldMinSymbols
+	}
+
+	i := slices.IndexFunc(c.charsets, func(cs charClass) bool { return cs.name == name })
+	return charsetField(i, part)
+}
+
+func charsetField(i int, part string) string {
+	return fmt.Sprintf("%s[%d].%s", FieldCharsets, i, part)
+}
+
+// classes returns the enabled character classes in the order they are
+// drawn from: the built-in classes first, then custom charsets.
+func (c *config) classes() []charClass {
+	classes := make([]charClass, 0, 4+len(c.charsets))
+	if c.useUppercase {
+		classes = append(classes, charClass{name: ClassUppercase, chars: uppers, min: c.minUppercase, max: limit(c.maxUppercase)})
+	}
+	if c.useLowercase {
+		classes = append(classes, charClass{name: ClassLowercase, chars: lowers, min: c.minLowercase, max: limit(c.maxLowercase)})
+	}
+	if c.useDigits {
+		classes = append(classes, charClass{name: ClassDigits, chars: digits, min: c.minDigits, max: limit(c.maxDigits)})
+	}
+	if c.useSymbols {
+		classes = append(classes, charClass{name: ClassSymbols, chars: symbols, min: c.minSymbols, max: limit(c.maxSymbols)})
+	}
+
+	for _, cs := range c.charsets {
+		cs.max = noLimit
+		classes = append(classes, cs)
+	}
+
+	if c.excludeAmbiguous {
+		for i := range classes {
+			classes[i].chars = excludeChars(classes[i].chars, c.ambiguous)
+		}
+	}
+
+	return classes
+}
+
+// limit converts a configured maximum, where zero means none, to a class
+// maximum.
+func limit(max int) int {
+	if max == 0 {
+		return noLimit
+	}
+
+	return max
+}
+
+func excludeChars(chars []rune, exclude Charset) []rune {
+	kept := make([]rune, 0, len(chars))
+	for _, r := range chars {
+		if !exclude.Contains(r) {
+			kept = append(kept, r)
+		}
+	}
+
+	return kept
+}
+
+func isBuiltinClass(name string) bool {
+	switch name {
+	case ClassUppercase, ClassLowercase, ClassDigits, ClassSymbols:
+		return true
+	}
+
+	return false
 }
@@ -1,8 +1,10 @@
 package passgen
 
 import (
+	"math"
 	"strings"
 	"testing"
+	"unicode/utf8"
 )
 
 func TestConfigValidate(t *testing.T) {
@@ -358,6 +360,220 @@ func TestConfigValidate(t *testing.T) {
 			expectError: true,
 			errorMsg:    "password length must be greater than 0, got -1",
 		},
+
+		{
+			name: "valid_custom_charset_only",
+			config: &config{
+				length:   10,
+				charsets: []charClass{{name: "hex", chars: []rune("0123456789abcdef"), min: 2}},
+			},
+			expectError: false,
+		},
+		{
+			name: "valid_custom_charset_with_builtin",
+			config: &config{
+				length:       10,
+				useUppercase: true,
+				charsets:     []charClass{{name: "safe", chars: []rune("!#"), min: 2}},
+			},
+			expectError: false,
+		},
+		{
+			name: "empty_charset_name",
+			config: &config{
+				length:   10,
+				charsets: []charClass{{name: "", chars: []rune("ab")}},
+			},
+			expectError: true,
+			errorMsg:    "charset name must not be empty",
+		},
+		{
+			name: "reserved_charset_name",
+			config: &config{
+				length:   10,
+				charsets: []charClass{{name: "symbols", chars: []rune("!#")}},
+			},
+			expectError: true,
+			errorMsg:    `charset name "symbols" is reserved`,
+		},
+		{
+			name: "empty_custom_charset",
+			config: &config{
+				length:       10,
+				useUppercase: true,
+				charsets:     []charClass{{name: "empty"}},
+			},
+			expectError: true,
+			errorMsg:    `charset "empty" must not be empty`,
+		},
+		{
+			name: "surrogate_in_custom_charset",
+			config: &config{
+				length:   10,
+				charsets: []charClass{{name: "sur", chars: []rune{'a', 0xD800}}},
+			},
+			expectError: true,
+			errorMsg:    `charset "sur" contains U+D800, which is not a valid character`,
+		},
+		{
+			name: "rune_beyond_unicode_in_custom_charset",
+			config: &config{
+				length:   10,
+				charsets: []charClass{{name: "big", chars: []rune{utf8.MaxRune + 1}}},
+			},
+			expectError: true,
+			errorMsg:    `charset "big" contains U+110000, which is not a valid character`,
+		},
+		{
+			name: "negative_custom_charset_min",
+			config: &config{
+				length:   10,
+				charsets: []charClass{{name: "hex", chars: []rune("abc"), min: -1}},
+			},
+			expectError: true,
+			errorMsg:    `minimum count for charset "hex" cannot be negative, got -1`,
+		},
+		{
+			name: "custom_charset_overlaps_builtin",
+			config: &config{
+				length:    10,
+				useDigits: true,
+				charsets:  []charClass{{name: "hex", chars: []rune("0123456789abcdef")}},
+			},
+			expectError: true,
+			errorMsg:    `charset "hex" overlaps with "digits"`,
+		},
+		{
+			name: "custom_charsets_overlap",
+			config: &config{
+				length: 10,
+				charsets: []charClass{
+					{name: "first", chars: []rune("abc")},
+					{name: "second", chars: []rune("cde")},
+				},
+			},
+			expectError: true,
+			errorMsg:    `charset "second" overlaps with "first"`,
+		},
+		{
+			name: "custom_charset_min_exceeds_length",
+			config: &config{
+				length:       5,
+				useUppercase: true,
+				minUppercase: 2,
+				charsets:     []charClass{{name: "safe", chars: []rune("!#"), min: 4}},
+			},
+			expectError: true,
+			errorMsg:    "sum of minimum requirements (6) cannot exceed password length (5)",
+		},
+
+		{
+			name: "valid_exclude_ambiguous",
+			config: &config{
+				length:           10,
+				useUppercase:     true,
+				useDigits:        true,
+				minDigits:        5,
+				excludeAmbiguous: true,
+				ambiguous:        Ambiguous,
+			},
+			expectError: false,
+		},
+		{
+			name: "class_empty_after_exclusion_with_min",
+			config: &config{
+				length:           10,
+				useUppercase:     true,
+				useDigits:        true,
+				minDigits:        2,
+				excludeAmbiguous: true,
+				ambiguous:        Digits,
+			},
+			expectError: true,
+			errorMsg:    `charset "digits" is empty after excluding ambiguous characters but minimum requirement is 2`,
+		},
+		{
+			name: "class_empty_after_exclusion_without_min",
+			config: &config{
+				length:           10,
+				useUppercase:     true,
+				useDigits:        true,
+				excludeAmbiguous: true,
+				ambiguous:        Digits,
+			},
+			expectError: false,
+		},
+		{
+			name: "custom_charset_empty_after_exclusion",
+			config: &config{
+				length:           10,
+				useUppercase:     true,
+				charsets:         []charClass{{name: "bars", chars: []rune("|`"), min: 1}},
+				excludeAmbiguous: true,
+				ambiguous:        Ambiguous,
+			},
+			expectError: true,
+			errorMsg:    `charset "bars" is empty after excluding ambiguous characters but minimum requirement is 1`,
+		},
+		{
+			name: "pool_empty_after_exclusion",
+			config: &config{
+				length:           10,
+				useDigits:        true,
+				excludeAmbiguous: true,
+				ambiguous:        Digits,
+			},
+			expectError: true,
+			errorMsg:    "no characters left after excluding ambiguous characters",
+		},
+
+		{
+			name: "valid_min_entropy_ignores_length",
+			config: &config{
+				length:     0,
+				minEntropy: 64,
+				useDigits:  true,
+			},
+			expectError: false,
+		},
+		{
+			name: "negative_min_entropy",
+			config: &config{
+				length:     10,
+				minEntropy: -1,
+				useDigits:  true,
+			},
+			expectError: true,
+			errorMsg:    "minimum entropy must be a non-negative number, got -1",
+		},
+		{
+			name: "nan_min_entropy",
+			config: &config{
+				length:     10,
+				minEntropy: math.NaN(),
+				useDigits:  true,
+			},
+			expectError: true,
+			errorMsg:    "minimum entropy must be a non-negative number, got NaN",
+		},
+		{
+			name: "min_entropy_exceeds_max_length",
+			config: &config{
+				minEntropy: 40000,
+				useDigits:  true,
+			},
+			expectError: true,
+			errorMsg:    "minimum entropy of 40000 bits requires a password longer than 10000",
+		},
+		{
+			name: "min_entropy_with_single_character",
+			config: &config{
+				minEntropy: 1,
+				charsets:   []charClass{{name: "x", chars: []rune("x")}},
+			},
+			expectError: true,
+			errorMsg:    "minimum entropy of 1 bits requires a password longer than 10000",
+		},
 	}
 
 	for _, tt := range tests {
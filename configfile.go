@@ -0,0 +1,280 @@
+package passgen
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// Formats of serialized configurations.
+const (
+	FormatJSON = "json"
+	FormatYAML = "yaml"
+	FormatTOML = "toml"
+)
+
+// Config is a password policy that can be stored in JSON, YAML or TOML files.
+// Its keys match the Field constants of ConfigError, so every violation
+// reported by NewGeneratorFromConfig names the key at fault. Zero maximums
+// and limits mean none, as with the corresponding options.
+type Config struct {
+	Length     int     `json:"length" yaml:"length" toml:"length"`
+	MinEntropy float64 `json:"min_entropy,omitempty" yaml:"min_entropy,omitempty" toml:"min_entropy,omitzero"`
+
+	Uppercase bool `json:"uppercase" yaml:"uppercase" toml:"uppercase"`
+	Lowercase bool `json:"lowercase" yaml:"lowercase" toml:"lowercase"`
+	Digits    bool `json:"digits" yaml:"digits" toml:"digits"`
+	Symbols   bool `json:"symbols" yaml:"symbols" toml:"symbols"`
+
+	MinUppercase int `json:"min_uppercase,omitempty" yaml:"min_uppercase,omitempty" toml:"min_uppercase,omitzero"`
+	MinLowercase int `json:"min_lowercase,omitempty" yaml:"min_lowercase,omitempty" toml:"min_lowercase,omitzero"`
+	MinDigits    int `json:"min_digits,omitempty" yaml:"min_digits,omitempty" toml:"min_digits,omitzero"`
+	MinSymbols   int `json:"min_symbols,omitempty" yaml:"min_symbols,omitempty" toml:"min_symbols,omitzero"`
+
+	MaxUppercase int `json:"max_uppercase,omitempty" yaml:"max_uppercase,omitempty" toml:"max_uppercase,omitzero"`
+	MaxLowercase int `json:"max_lowercase,omitempty" yaml:"max_lowercase,omitempty" toml:"max_lowercase,omitzero"`
+	MaxDigits    int `json:"max_digits,omitempty" yaml:"max_digits,omitempty" toml:"max_digits,omitzero"`
+	MaxSymbols   int `json:"max_symbols,omitempty" yaml:"max_symbols,omitempty" toml:"max_symbols,omitzero"`
+
+	// ClassWeights weights the fill characters as WithClassWeights does, or
+	// is nil for uniform filling.
+	ClassWeights *ClassWeights `json:"class_weights,omitempty" yaml:"class_weights,omitempty" toml:"class_weights,omitempty"`
+
+	Charsets []CharsetConfig `json:"charsets,omitempty" yaml:"charsets,omitempty" toml:"charsets,omitempty"`
+
+	ExcludeAmbiguous bool `json:"exclude_ambiguous,omitempty" yaml:"exclude_ambiguous,omitempty" toml:"exclude_ambiguous,omitempty"`
+	// AmbiguousChars replaces the look-alike table used by ExcludeAmbiguous.
+	// Empty keeps Ambiguous.
+	AmbiguousChars string `json:"ambiguous_chars,omitempty" yaml:"ambiguous_chars,omitempty" toml:"ambiguous_chars,omitempty"`
+
+	Pronounceable bool   `json:"pronounceable,omitempty" yaml:"pronounceable,omitempty" toml:"pronounceable,omitempty"`
+	Mask          string `json:"mask,omitempty" yaml:"mask,omitempty" toml:"mask,omitempty"`
+
+	FirstChar []string `json:"first_char,omitempty" yaml:"first_char,omitempty" toml:"first_char,omitempty"`
+	LastChar  []string `json:"last_char,omitempty" yaml:"last_char,omitempty" toml:"last_char,omitempty"`
+
+	MaxConsecutive int  `json:"max_consecutive,omitempty" yaml:"max_consecutive,omitempty" toml:"max_consecutive,omitzero"`
+	MaxSequential  int  `json:"max_sequential,omitempty" yaml:"max_sequential,omitempty" toml:"max_sequential,omitzero"`
+	NoRepeats      bool `json:"no_repeats,omitempty" yaml:"no_repeats,omitempty" toml:"no_repeats,omitempty"`
+}
+
+// ClassWeights holds the weights of the built-in classes.
+type ClassWeights struct {
+	Uppercase float64 `json:"uppercase" yaml:"uppercase" toml:"uppercase"`
+	Lowercase float64 `json:"lowercase" yaml:"lowercase" toml:"lowercase"`
+	Digits    float64 `json:"digits" yaml:"digits" toml:"digits"`
+	Symbols   float64 `json:"symbols" yaml:"symbols" toml:"symbols"`
+}
+
+// CharsetConfig is a custom character class, as added by WithCharset.
+type CharsetConfig struct {
+	Name  string `json:"name" yaml:"name" toml:"name"`
+	Chars string `json:"chars" yaml:"chars" toml:"chars"`
+	Min   int    `json:"min,omitempty" yaml:"min,omitempty" toml:"min,omitzero"`
+}
+
+// DefaultConfig returns the policy NewGenerator uses without options.
+// UnmarshalConfig starts from it, so keys missing from a file keep their
+// defaults.
+func DefaultConfig() Config {
+	return defaultConfig().export()
+}
+
+// NewGeneratorFromConfig creates a generator for the policy. The options are
+// applied after it, for settings that cannot be serialized such as
+// WithBreachFilter and WithRandReader. The policy is validated exactly like
+// the options of NewGenerator.
+func NewGeneratorFromConfig(c Config, opts ...Option) (*Generator, error) {
+	return NewGenerator(append([]Option{c.apply}, opts...)...)
+}
+
+// Config returns the generator's policy. The length is the one derived from
+// a minimum entropy or a mask, if any.
+func (g *Generator) Config() Config {
+	return g.cfg.export()
+}
+
+func (c Config) apply(cfg *config) {
+	cfg.length = c.Length
+	cfg.minEntropy = c.MinEntropy
+
+	cfg.useUppercase = c.Uppercase
+	cfg.useLowercase = c.Lowercase
+	cfg.useDigits = c.Digits
+	cfg.useSymbols = c.Symbols
+
+	cfg.minUppercase = c.MinUppercase
+	cfg.minLowercase = c.MinLowercase
+	cfg.minDigits = c.MinDigits
+	cfg.minSymbols = c.MinSymbols
+
+	cfg.maxUppercase = c.MaxUppercase
+	cfg.maxLowercase = c.MaxLowercase
+	cfg.maxDigits = c.MaxDigits
+	cfg.maxSymbols = c.MaxSymbols
+
+	cfg.weights = nil
+	if w := c.ClassWeights; w != nil {
+		cfg.weights = []float64{w.Uppercase, w.Lowercase, w.Digits, w.Symbols}
+	}
+
+	// Charsets are kept as listed, even with duplicate names, so that
+	// violations can name them by index.
+	cfg.charsets = make([]charClass, len(c.Charsets))
+	for i, cs := range c.Charsets {
+		cfg.charsets[i] = charClass{name: cs.Name, chars: CharsetFromString(cs.Chars).Runes(), min: cs.Min}
+	}
+
+	cfg.excludeAmbiguous = c.ExcludeAmbiguous
+	cfg.ambiguous = Ambiguous
+	if c.AmbiguousChars != "" {
+		cfg.ambiguous = CharsetFromString(c.AmbiguousChars)
+	}
+
+	cfg.pronounceable = c.Pronounceable
+	cfg.mask = c.Mask
+
+	cfg.firstChar = slices.Clone(c.FirstChar)
+	cfg.lastChar = slices.Clone(c.LastChar)
+
+	cfg.maxConsecutive = c.MaxConsecutive
+	cfg.maxSequential = c.MaxSequential
+	cfg.noRepeats = c.NoRepeats
+}
+
+func (cfg *config) export() Config {
+	c := Config{
+		Length:           cfg.length,
+		MinEntropy:       cfg.minEntropy,
+		Uppercase:        cfg.useUppercase,
+		Lowercase:        cfg.useLowercase,
+		Digits:           cfg.useDigits,
+		Symbols:          cfg.useSymbols,
+		MinUppercase:     cfg.minUppercase,
+		MinLowercase:     cfg.minLowercase,
+		MinDigits:        cfg.minDigits,
+		MinSymbols:       cfg.minSymbols,
+		MaxUppercase:     cfg.maxUppercase,
+		MaxLowercase:     cfg.maxLowercase,
+		MaxDigits:        cfg.maxDigits,
+		MaxSymbols:       cfg.maxSymbols,
+		ExcludeAmbiguous: cfg.excludeAmbiguous,
+		Pronounceable:    cfg.pronounceable,
+		Mask:             cfg.mask,
+		FirstChar:        slices.Clone(cfg.firstChar),
+		LastChar:         slices.Clone(cfg.lastChar),
+		MaxConsecutive:   cfg.maxConsecutive,
+		MaxSequential:    cfg.maxSequential,
+		NoRepeats:        cfg.noRepeats,
+	}
+
+	if w := cfg.weights; w != nil {
+		c.ClassWeights = &ClassWeights{Uppercase: w[0], Lowercase: w[1], Digits: w[2], Symbols: w[3]}
+	}
+
+	for _, cs := range cfg.charsets {
+		c.Charsets = append(c.Charsets, CharsetConfig{Name: cs.name, Chars: string(cs.chars), Min: cs.min})
+	}
+
+	if cfg.ambiguous.String() != Ambiguous.String() {
+		c.AmbiguousChars = cfg.ambiguous.String()
+	}
+
+	return c
+}
+
+// MarshalConfig encodes the policy in the format.
+func MarshalConfig(c Config, format string) ([]byte, error) {
+	switch format {
+	case FormatJSON:
+		return json.MarshalIndent(c, "", "  ")
+	case FormatYAML:
+		return yaml.Marshal(c)
+	case FormatTOML:
+		var buf bytes.Buffer
+		if err := toml.NewEncoder(&buf).Encode(c); err != nil {
+			return nil, fmt.Errorf("failed to encode config: %w", err)
+		}
+		return buf.Bytes(), nil
+	default:
+		return nil, fmt.Errorf("unknown config format %q", format)
+	}
+}
+
+// UnmarshalConfig decodes a policy in the format. Keys missing from data keep
+// their defaults and unknown keys are an error, so that a misspelt setting is
+// not silently ignored. The policy is validated by NewGeneratorFromConfig.
+func UnmarshalConfig(data []byte, format string) (Config, error) {
+	c := DefaultConfig()
+
+	switch format {
+	case FormatJSON:
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(&c); err != nil {
+			return Config{}, fmt.Errorf("failed to decode config: %w", err)
+		}
+	case FormatYAML:
+		dec := yaml.NewDecoder(bytes.NewReader(data))
+		dec.KnownFields(true)
+		// An empty document leaves the defaults.
+		if err := dec.Decode(&c); err != nil && !errors.Is(err, io.EOF) {
+			return Config{}, fmt.Errorf("failed to decode config: %w", err)
+		}
+	case FormatTOML:
+		md, err := toml.NewDecoder(bytes.NewReader(data)).Decode(&c)
+		if err != nil {
+			return Config{}, fmt.Errorf("failed to decode config: %w", err)
+		}
+		if undecoded := md.Undecoded(); len(undecoded) > 0 {
+			return Config{}, fmt.Errorf("failed to decode config: unknown key %q", undecoded[0].String())
+		}
+	default:
+		return Config{}, fmt.Errorf("unknown config format %q", format)
+	}
+
+	return c, nil
+}
+
+// LoadConfig reads a policy from a file, choosing the format by its
+// extension: .json, .yaml, .yml or .toml.
+func LoadConfig(path string) (Config, error) {
+	format, err := formatOf(path)
+	if err != nil {
+		return Config{}, err
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Config{}, fmt.Errorf("failed to read config: %w", err)
+	}
+
+	c, err := UnmarshalConfig(data, format)
+	if err != nil {
+		return Config{}, fmt.Errorf("%s: %w", path, err)
+	}
+
+	return c, nil
+}
+
+func formatOf(path string) (string, error) {
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".json":
+		return FormatJSON, nil
+	case ".yaml", ".yml":
+		return FormatYAML, nil
+	case ".toml":
+		return FormatTOML, nil
+	default:
+		return "", fmt.Errorf("unknown config file extension %q, want .json, .yaml, .yml or .toml", ext)
+	}
+}
@@ -0,0 +1,246 @@
+package passgen
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestConfigRoundTrip(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Length = 20
+	cfg.MinDigits = 2
+	cfg.MaxSymbols = 3
+	cfg.ClassWeights = &ClassWeights{Uppercase: 1, Lowercase: 8, Digits: 1, Symbols: 0.5}
+	cfg.Charsets = []CharsetConfig{{Name: "greek", Chars: "αβγδ", Min: 1}}
+	cfg.ExcludeAmbiguous = true
+	cfg.AmbiguousChars = "0O1l"
+	cfg.FirstChar = []string{ClassUppercase, ClassLowercase}
+	cfg.MaxConsecutive = 2
+
+	for _, format := range []string{FormatJSON, FormatYAML, FormatTOML} {
+		t.Run(format, func(t *testing.T) {
+			data, err := MarshalConfig(cfg, format)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			got, err := UnmarshalConfig(data, format)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, cfg) {
+				t.Errorf("expected %+v, got %+v", cfg, got)
+			}
+		})
+	}
+}
+
+func TestUnmarshalConfig(t *testing.T) {
+	tests := []struct {
+		name     string
+		format   string
+		data     string
+		want     func(*Config)
+		errorMsg string
+	}{
+		{
+			name:   "json_partial",
+			format: FormatJSON,
+			data:   `{"length": 24, "symbols": false, "min_digits": 2}`,
+			want: func(c *Config) {
+				c.Length = 24
+				c.Symbols = false
+				c.MinDigits = 2
+			},
+		},
+		{
+			name:   "yaml_partial",
+			format: FormatYAML,
+			data:   "length: 24\nsymbols: false\nfirst_char: [uppercase]\ncharsets:\n  - name: hex\n    chars: abcdef\n    min: 1\n",
+			want: func(c *Config) {
+				c.Length = 24
+				c.Symbols = false
+				c.FirstChar = []string{ClassUppercase}
+				c.Charsets = []CharsetConfig{{Name: "hex", Chars: "abcdef", Min: 1}}
+			},
+		},
+		{
+			name:   "toml_partial",
+			format: FormatTOML,
+			data:   "length = 24\nsymbols = false\n\n[class_weights]\nuppercase = 1\nlowercase = 8\ndigits = 1\nsymbols = 0.5\n",
+			want: func(c *Config) {
+				c.Length = 24
+				c.Symbols = false
+				c.ClassWeights = &ClassWeights{Uppercase: 1, Lowercase: 8, Digits: 1, Symbols: 0.5}
+			},
+		},
+		{
+			name:   "empty_yaml_keeps_defaults",
+			format: FormatYAML,
+			data:   "",
+			want:   func(*Config) {},
+		},
+		{
+			name:     "json_unknown_key",
+			format:   FormatJSON,
+			data:     `{"lenght": 24}`,
+			errorMsg: `unknown field "lenght"`,
+		},
+		{
+			name:     "yaml_unknown_key",
+			format:   FormatYAML,
+			data:     "lenght: 24\n",
+			errorMsg: "field lenght not found",
+		},
+		{
+			name:     "toml_unknown_key",
+			format:   FormatTOML,
+			data:     "lenght = 24\n",
+			errorMsg: `unknown key "lenght"`,
+		},
+		{
+			name:     "json_wrong_type",
+			format:   FormatJSON,
+			data:     `{"length": "long"}`,
+			errorMsg: "failed to decode config",
+		},
+		{
+			name:     "unknown_format",
+			format:   "ini",
+			errorMsg: `unknown config format "ini"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := UnmarshalConfig([]byte(tt.data), tt.format)
+
+			if tt.errorMsg != "" {
+				if err == nil {
+					t.Fatal("expected error but got none")
+				}
+				if !strings.Contains(err.Error(), tt.errorMsg) {
+					t.Errorf("expected error message to contain %q, got %q", tt.errorMsg, err.Error())
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			want := DefaultConfig()
+			tt.want(&want)
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("expected %+v, got %+v", want, got)
+			}
+		})
+	}
+}
+
+func TestNewGeneratorFromConfig(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Length = 12
+	cfg.Symbols = false
+	cfg.MinDigits = 3
+	cfg.FirstChar = []string{ClassUppercase}
+
+	gen, err := NewGeneratorFromConfig(cfg)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want, err := NewGenerator(WithLength(12), WithoutSymbols(), WithMinDigits(3), WithFirstChar(ClassUppercase))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if gen.Keyspace().Cmp(want.Keyspace()) != 0 {
+		t.Errorf("expected keyspace %v, got %v", want.Keyspace(), gen.Keyspace())
+	}
+	if !reflect.DeepEqual(want.Config(), cfg) {
+		t.Errorf("expected config %+v, got %+v", cfg, want.Config())
+	}
+
+	password, err := gen.Generate()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := gen.Validate(password); err != nil {
+		t.Errorf("generated password %q violates the policy: %v", password, err)
+	}
+}
+
+func TestNewGeneratorFromConfigOptions(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Length = 8
+
+	gen, err := NewGeneratorFromConfig(cfg, WithLength(10))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := gen.Config().Length; got != 10 {
+		t.Errorf("expected options to override the config, got length %d", got)
+	}
+}
+
+func TestNewGeneratorFromConfigErrors(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Length = 0
+	cfg.Digits = false
+	cfg.MinDigits = 2
+	cfg.Charsets = []CharsetConfig{{Name: "ok", Chars: "αβ"}, {Name: "", Chars: "γ"}}
+
+	_, err := NewGeneratorFromConfig(cfg)
+	if err == nil {
+		t.Fatal("expected error but got none")
+	}
+
+	fields := make(map[string]error)
+	for _, e := range ConfigErrors(err) {
+		fields[e.Field] = e.Rule
+	}
+	want := map[string]error{
+		FieldLength:        ErrOutOfRange,
+		FieldMinDigits:     ErrDisabledClass,
+		"charsets[1].name": ErrInvalidCharset,
+	}
+	if !reflect.DeepEqual(fields, want) {
+		t.Errorf("expected violations %v, got %v", want, fields)
+	}
+}
+
+func TestLoadConfig(t *testing.T) {
+	dir := t.TempDir()
+
+	files := map[string]string{
+		"policy.json": `{"length": 30}`,
+		"policy.yaml": "length: 30\n",
+		"policy.yml":  "length: 30\n",
+		"policy.toml": "length = 30\n",
+	}
+	for name, data := range files {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+			t.Fatal(err)
+		}
+
+		cfg, err := LoadConfig(path)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", name, err)
+			continue
+		}
+		if cfg.Length != 30 {
+			t.Errorf("%s: expected length 30, got %d", name, cfg.Length)
+		}
+	}
+
+	if _, err := LoadConfig(filepath.Join(dir, "policy.ini")); err == nil || !strings.Contains(err.Error(), "unknown config file extension") {
+		t.Errorf("expected unknown extension error, got %v", err)
+	}
+	if _, err := LoadConfig(filepath.Join(dir, "missing.json")); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("expected a not-exist error, got %v", err)
+	}
+}
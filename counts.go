@@ -0,0 +1,337 @@
+package passgen
+
+import (
+	"crypto/rand"
+	"fmt"
+	"io"
+	"math"
+	"math/big"
+	"sort"
+)
+
+// maxCountAttempts bounds how often counts redraws the number of characters
+// of every class. Draws are accepted with a probability in the order of one
+// over the square root of the number of classes, so it is never reached in
+// practice.
+const maxCountAttempts = 10000
+
+// tiltScale is the denominator of the tilt λ of counts. λ only affects how
+// often draws are accepted, so it is rounded to a fraction with small terms.
+const tiltScale = 1 << 16
+
+// counts draws how many characters each of its groups contributes to a
+// password of a fixed length, with probability proportional to the number of
+// passwords with those counts.
+//
+// With k_i characters from group i there are length! * prod f_i(k_i)
+// passwords, where f_i(k) = size_i^k / k!, or C(size_i, k) without repeats.
+// The counts are thus distributed like independent variables K_i with
+// P(K_i = k) proportional to λ^k f_i(k) between the group's minimum and
+// maximum, conditioned on adding up to the length, for any λ > 0. counts
+// draws every K_i but the last one by rejection from a flat envelope with
+// geometric tails, derives the last one from the length and accepts it with
+// probability λ^k f(k) relative to its mode. λ is chosen so that the modes
+// add up to the length, which keeps the acceptance high.
+//
+// Every acceptance test is a chain of Bernoulli trials on ratios of small
+// integers, λ^(k+1) f(k+1) / λ^k f(k) and the like, so the draw is exact and
+// its cost grows with the square root of the length instead of requiring a
+// table of big numbers.
+type counts struct {
+	length   int
+	distinct bool
+	groups   []countGroup
+	// last is the group whose count is derived from the others.
+	last int
+	// tilt is λ * tiltScale.
+	tilt uint64
+}
+
+type countGroup struct {
+	size   int
+	lo, hi int
+	// mode is the most likely count. The envelope is flat between left and
+	// right and falls geometrically beyond.
+	mode, left, right int
+	// A uniform value below flat picks the flat part of the envelope, one
+	// below upper the part above right and any other one the part below
+	// left; total is the total mass.
+	flat, upper, total *big.Int
+}
+
+// newCounts returns counts for groups with the given sizes, minimums and
+// maximums. Their minimums must not add up to more than the length and
+// their maximums to no less.
+func newCounts(sizes, mins, maxs []int, length int, distinct bool) *counts {
+	c := &counts{length: length, distinct: distinct}
+	for i, size := range sizes {
+		hi := min(maxs[i], length)
+		if distinct {
+			hi = min(hi, size)
+		}
+		c.groups = append(c.groups, countGroup{size: size, lo: mins[i], hi: hi})
+	}
+
+	c.tilt = c.chooseTilt()
+
+	for i := range c.groups {
+		g := &c.groups[i]
+		g.mode = g.lo + sort.Search(g.hi-g.lo, func(j int) bool {
+			num, den := c.ratio(g, g.lo+j)
+			return num <= den
+		})
+
+		// The envelope is flat for about one standard deviation, which is
+		// no more than the square root of the mode, on either side.
+		width := int(math.Ceil(math.Sqrt(float64(g.mode + 1))))
+		g.left = max(g.lo, g.mode-width)
+		g.right = min(g.hi, g.mode+width)
+
+		// The tails hold rho/(1-rho) of the flat envelope's height, where
+		// rho is the ratio by which they fall.
+		upperNum, upperDen := uint64(0), uint64(1)
+		if g.right < g.hi {
+			num, den := c.ratio(g, g.right)
+			upperNum, upperDen = num, den-num
+		}
+		lowerNum, lowerDen := uint64(0), uint64(1)
+		if g.left > g.lo {
+			num, den := c.ratio(g, g.left-1)
+			lowerNum, lowerDen = den, num-den
+		}
+
+		g.flat = new(big.Int).SetUint64(uint64(g.right - g.left + 1))
+		g.flat.Mul(g.flat, new(big.Int).SetUint64(upperDen))
+		g.flat.Mul(g.flat, new(big.Int).SetUint64(lowerDen))
+		upper := new(big.Int).Mul(new(big.Int).SetUint64(upperNum), new(big.Int).SetUint64(lowerDen))
+		g.upper = upper.Add(upper, g.flat)
+		lower := new(big.Int).Mul(new(big.Int).SetUint64(lowerNum), new(big.Int).SetUint64(upperDen))
+		g.total = lower.Add(lower, g.upper)
+
+		if g.right-g.left > c.groups[c.last].right-c.groups[c.last].left {
+			c.last = i
+		}
+	}
+
+	return c
+}
+
+// chooseTilt returns λ * tiltScale such that the modes of the groups add up
+// to about the length.
+func (c *counts) chooseTilt() uint64 {
+	modes := func(lambda float64) float64 {
+		sum := 0.0
+		for _, g := range c.groups {
+			mode := lambda * float64(g.size)
+			if c.distinct {
+				mode = lambda * float64(g.size+1) / (1 + lambda)
+			}
+			sum += min(max(mode, float64(g.lo)), float64(g.hi))
+		}
+		return sum
+	}
+
+	// λ stays within [1/tiltScale, 2^20] so that the ratios fit in 64 bits.
+	lo, hi := math.Log(1.0/tiltScale), math.Log(1<<20)
+	for range 100 {
+		mid := (lo + hi) / 2
+		if modes(math.Exp(mid)) < float64(c.length) {
+			lo = mid
+		} else {
+			hi = mid
+		}
+	}
+
+	return max(1, uint64(math.Round(math.Exp(hi)*tiltScale)))
+}
+
+// ratio returns λ^(k+1) f(k+1) / λ^k f(k) for the group as a fraction.
+func (c *counts) ratio(g *countGroup, k int) (num, den uint64) {
+	return c.tilt * c.grow(g, k), tiltScale * uint64(k+1)
+}
+
+// grow returns size, or size - k without repeats: the ways to add a
+// character to k of the group, up to the factor k+1 of the arrangements.
+func (c *counts) grow(g *countGroup, k int) uint64 {
+	if c.distinct {
+		return uint64(g.size - k)
+	}
+
+	return uint64(g.size)
+}
+
+// draw fills k with the count of every group.
+func (c *counts) draw(r io.Reader, k []int) error {
+	for range maxCountAttempts {
+		rest := c.length
+		for i := range c.groups {
+			if i == c.last {
+				continue
+			}
+
+			var err error
+			if k[i], err = c.drawGroup(r, &c.groups[i]); err != nil {
+				return err
+			}
+			rest -= k[i]
+		}
+
+		g := &c.groups[c.last]
+		if rest < g.lo || rest > g.hi {
+			continue
+		}
+
+		ok, err := c.fromMode(r, g, rest)
+		if err != nil {
+			return err
+		}
+		if ok {
+			k[c.last] = rest
+			return nil
+		}
+	}
+
+	return fmt.Errorf("failed to draw the class counts after %d attempts", maxCountAttempts)
+}
+
+// drawGroup draws the count of a group on its own.
+func (c *counts) drawGroup(r io.Reader, g *countGroup) (int, error) {
+	for {
+		x, err := rand.Int(r, g.total)
+		if err != nil {
+			return 0, fmt.Errorf("failed to read random bytes: %w", err)
+		}
+
+		var k int
+		var ok bool
+		switch {
+		case x.Cmp(g.flat) < 0:
+			i, err := randomIndex(r, g.right-g.left+1)
+			if err != nil {
+				return 0, err
+			}
+			k = g.left + i
+			ok, err = c.fromMode(r, g, k)
+			if err != nil {
+				return 0, err
+			}
+		case x.Cmp(g.upper) < 0:
+			k, ok, err = c.upperTail(r, g)
+			if err != nil {
+				return 0, err
+			}
+		default:
+			k, ok, err = c.lowerTail(r, g)
+			if err != nil {
+				return 0, err
+			}
+		}
+
+		if ok {
+			return k, nil
+		}
+	}
+}
+
+// upperTail draws a count above right from the geometric envelope and
+// reports whether it is accepted.
+func (c *counts) upperTail(r io.Reader, g *countGroup) (int, bool, error) {
+	rhoNum, rhoDen := c.ratio(g, g.right)
+
+	k := g.right + 1
+	for {
+		more, err := bernoulli(r, rhoNum, rhoDen)
+		if err != nil || !more {
+			if err != nil {
+				return 0, false, err
+			}
+			break
+		}
+		if k++; k > g.hi {
+			return 0, false, nil
+		}
+	}
+
+	// The envelope at k is the height at the mode times rho^(k-right), and
+	// every ratio beyond right is at most rho.
+	ok, err := c.fromMode(r, g, g.right)
+	if err != nil || !ok {
+		return 0, false, err
+	}
+	for i := g.right; i < k; i++ {
+		ok, err := bernoulli(r, c.grow(g, i)*uint64(g.right+1), c.grow(g, g.right)*uint64(i+1))
+		if err != nil || !ok {
+			return 0, false, err
+		}
+	}
+
+	return k, true, nil
+}
+
+// lowerTail is upperTail for a count below left.
+func (c *counts) lowerTail(r io.Reader, g *countGroup) (int, bool, error) {
+	rhoDen, rhoNum := c.ratio(g, g.left-1)
+
+	k := g.left - 1
+	for {
+		more, err := bernoulli(r, rhoNum, rhoDen)
+		if err != nil || !more {
+			if err != nil {
+				return 0, false, err
+			}
+			break
+		}
+		if k--; k < g.lo {
+			return 0, false, nil
+		}
+	}
+
+	ok, err := c.fromMode(r, g, g.left)
+	if err != nil || !ok {
+		return 0, false, err
+	}
+	for i := k; i < g.left; i++ {
+		ok, err := bernoulli(r, c.grow(g, g.left-1)*uint64(i+1), c.grow(g, i)*uint64(g.left))
+		if err != nil || !ok {
+			return 0, false, err
+		}
+	}
+
+	return k, true, nil
+}
+
+// fromMode reports true with probability λ^k f(k) / λ^mode f(mode). Every
+// ratio on the way from the mode is at most 1.
+func (c *counts) fromMode(r io.Reader, g *countGroup, k int) (bool, error) {
+	for i := g.mode; i < k; i++ {
+		num, den := c.ratio(g, i)
+		if ok, err := bernoulli(r, num, den); err != nil || !ok {
+			return false, err
+		}
+	}
+	for i := k; i < g.mode; i++ {
+		num, den := c.ratio(g, i)
+		if ok, err := bernoulli(r, den, num); err != nil || !ok {
+			return false, err
+		}
+	}
+
+	return true, nil
+}
+
+// bernoulli reports true with probability num/den.
+func bernoulli(r io.Reader, num, den uint64) (bool, error) {
+	if num >= den {
+		return true, nil
+	}
+	if num == 0 {
+		return false, nil
+	}
+
+	x, err := randomIndex(r, int(den))
+	if err != nil {
+		return false, err
+	}
+
+	return uint64(x) < num, nil
+}
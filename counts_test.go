@@ -0,0 +1,146 @@
+package passgen
+
+import (
+	"crypto/rand"
+	"fmt"
+	"math/big"
+	"testing"
+)
+
+func TestCountsDistribution(t *testing.T) {
+	const samples = 20000
+
+	tests := []struct {
+		name     string
+		sizes    []int
+		mins     []int
+		maxs     []int
+		length   int
+		distinct bool
+	}{
+		{
+			name:   "minimums",
+			sizes:  []int{10, 26, 52},
+			mins:   []int{3, 2, 0},
+			maxs:   []int{noLimit, noLimit, noLimit},
+			length: 8,
+		},
+		{
+			name:   "minimums and maximums",
+			sizes:  []int{10, 26, 26, 26},
+			mins:   []int{1, 0, 2, 0},
+			maxs:   []int{3, 2, noLimit, noLimit},
+			length: 7,
+		},
+		{
+			name:   "heavy minimum",
+			sizes:  []int{10, 84},
+			mins:   []int{6, 0},
+			maxs:   []int{noLimit, noLimit},
+			length: 9,
+		},
+		{
+			name:   "forced counts",
+			sizes:  []int{2, 3, 5},
+			mins:   []int{2, 3, 1},
+			maxs:   []int{2, 3, noLimit},
+			length: 6,
+		},
+		{
+			name:     "distinct",
+			sizes:    []int{4, 3, 6},
+			mins:     []int{1, 2, 0},
+			maxs:     []int{noLimit, noLimit, noLimit},
+			length:   7,
+			distinct: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			weights, total := countWeights(tt.sizes, tt.mins, tt.maxs, tt.length, tt.distinct)
+			c := newCounts(tt.sizes, tt.mins, tt.maxs, tt.length, tt.distinct)
+
+			observed := make(map[string]int)
+			k := make([]int, len(tt.sizes))
+			for range samples {
+				if err := c.draw(rand.Reader, k); err != nil {
+					t.Fatalf("failed to draw counts: %v", err)
+				}
+				key := fmt.Sprint(k)
+				if _, ok := weights[key]; !ok {
+					t.Fatalf("drew impossible counts %v", k)
+				}
+				observed[key]++
+			}
+
+			chi2 := 0.0
+			for key, w := range weights {
+				expected, _ := new(big.Rat).SetFrac(new(big.Int).Mul(w, big.NewInt(samples)), total).Float64()
+				d := float64(observed[key]) - expected
+				chi2 += d * d / expected
+			}
+			if len(weights) == 1 {
+				return
+			}
+			if critical := chiSquareCritical(len(weights)-1, chiSquareZ); chi2 > critical {
+				t.Errorf("counts deviate from the expected distribution: chi2 = %.2f > %.2f", chi2, critical)
+			}
+		})
+	}
+}
+
+func TestCountsLong(t *testing.T) {
+	c := newCounts([]int{26, 26, 10, 26}, []int{0, 0, 5000, 0}, []int{noLimit, noLimit, noLimit, 3}, 10000, false)
+
+	k := make([]int, 4)
+	for range 20 {
+		if err := c.draw(rand.Reader, k); err != nil {
+			t.Fatalf("failed to draw counts: %v", err)
+		}
+		if k[0]+k[1]+k[2]+k[3] != 10000 || k[2] < 5000 || k[3] > 3 {
+			t.Fatalf("counts %v do not meet the requirements", k)
+		}
+	}
+}
+
+// countWeights returns the number of passwords for every feasible count
+// vector, keyed by its fmt.Sprint form, and their total.
+func countWeights(sizes, mins, maxs []int, length int, distinct bool) (map[string]*big.Int, *big.Int) {
+	weights := make(map[string]*big.Int)
+	total := new(big.Int)
+
+	k := make([]int, len(sizes))
+	var walk func(i, rest int)
+	walk = func(i, rest int) {
+		if i == len(sizes)-1 {
+			if rest < mins[i] || rest > maxs[i] {
+				return
+			}
+			k[i] = rest
+
+			w := new(big.Int).MulRange(1, int64(length))
+			for j, n := range k {
+				w.Quo(w, new(big.Int).MulRange(1, int64(n)))
+				if distinct {
+					w.Mul(w, new(big.Int).MulRange(int64(sizes[j]-n+1), int64(sizes[j])))
+				} else {
+					w.Mul(w, new(big.Int).Exp(big.NewInt(int64(sizes[j])), big.NewInt(int64(n)), nil))
+				}
+			}
+			if w.Sign() > 0 {
+				weights[fmt.Sprint(k)] = w
+				total.Add(total, w)
+			}
+			return
+		}
+
+		for n := mins[i]; n <= min(maxs[i], rest); n++ {
+			k[i] = n
+			walk(i+1, rest-n)
+		}
+	}
+	walk(0, length)
+
+	return weights, total
+}
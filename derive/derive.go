@@ -0,0 +1,260 @@
+// Package derive computes stable passwords from a master secret, a site, a
+// username and a counter, so service account passwords can be recreated on
+// demand instead of being stored.
+//
+// The inputs are stretched with a memory-hard KDF (Argon2id by default, or
+// scrypt) and the derived key seeds a ChaCha20 keystream. Candidate
+// passwords are read from the keystream and the first one the policy
+// accepts is the password, so it follows the same policy as a generated one:
+// length, classes, minimums and every other generator option.
+//
+// A password depends on every input, the KDF parameters and the policy. It
+// does not depend on how passgen.Generator samples passwords: the mapping
+// from the keystream to a password belongs to this package and is versioned
+// along with the encoding of the inputs.
+package derive
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/haadi-coder/passgen"
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/chacha20"
+	"golang.org/x/crypto/scrypt"
+)
+
+// Argon2id parameters recommended by RFC 9106 for memory-constrained
+// environments.
+const (
+	DefaultArgon2Time    = 3
+	DefaultArgon2Memory  = 64 * 1024
+	DefaultArgon2Threads = 4
+)
+
+// saltPrefix separates the salts of this scheme from any other use of the
+// same master secret. Its version changes if the encoding of the inputs or
+// the mapping from the keystream to a password in draw ever does; v1 drew
+// passwords with passgen.Generator.
+const saltPrefix = "passgen/derive/v2"
+
+// minAcceptance bounds the candidates per password New allows a policy to
+// need on average. Password gives up after maxAttempts, which then happens
+// about once in e^50 passwords.
+const (
+	minAcceptance = 1000
+	maxAttempts   = 50 * minAcceptance
+)
+
+const keySize = chacha20.KeySize
+
+type Option func(*config)
+
+type config struct {
+	kdf    func(secret, salt []byte) ([]byte, error)
+	err    error
+	policy []passgen.Option
+}
+
+func defaultConfig() *config {
+	return &config{kdf: argon2id(DefaultArgon2Time, DefaultArgon2Memory, DefaultArgon2Threads)}
+}
+
+// WithArgon2id stretches the inputs with Argon2id using the given number of
+// passes, memory in KiB and threads.
+func WithArgon2id(time, memory uint32, threads uint8) Option {
+	return func(c *config) {
+		switch {
+		case time == 0:
+			c.err = fmt.Errorf("argon2id time must be greater than 0")
+		case threads == 0:
+			c.err = fmt.Errorf("argon2id threads must be greater than 0")
+		case memory < 8*uint32(threads):
+			c.err = fmt.Errorf("argon2id memory must be at least 8 KiB per thread, got %d KiB for %d threads", memory, threads)
+		default:
+			c.kdf = argon2id(time, memory, threads)
+			c.err = nil
+		}
+	}
+}
+
+// WithScrypt stretches the inputs with scrypt using the CPU/memory cost n,
+// which must be a power of two greater than 1, the block size r and the
+// parallelization p.
+func WithScrypt(n, r, p int) Option {
+	return func(c *config) {
+		switch {
+		case n <= 1 || n&(n-1) != 0:
+			c.err = fmt.Errorf("scrypt cost must be a power of two greater than 1, got %d", n)
+		case r <= 0 || p <= 0:
+			c.err = fmt.Errorf("scrypt block size and parallelization must be greater than 0, got r=%d, p=%d", r, p)
+		case uint64(r)*uint64(p) >= 1<<30:
+			c.err = fmt.Errorf("scrypt block size times parallelization must be less than 2^30, got %d", r*p)
+		default:
+			c.kdf = func(secret, salt []byte) ([]byte, error) {
+				return scrypt.Key(secret, salt, n, r, p, keySize)
+			}
+			c.err = nil
+		}
+	}
+}
+
+// WithPolicy sets the generator options the passwords are derived with. A
+// random reader among them is ignored.
+func WithPolicy(opts ...passgen.Option) Option {
+	return func(c *config) {
+		c.policy = opts
+	}
+}
+
+func argon2id(time, memory uint32, threads uint8) func(secret, salt []byte) ([]byte, error) {
+	return func(secret, salt []byte) ([]byte, error) {
+		return argon2.IDKey(secret, salt, time, memory, threads, keySize), nil
+	}
+}
+
+type Deriver struct {
+	master []byte
+	cfg    *config
+	// gen checks candidates against the policy; it never draws passwords.
+	gen    *passgen.Generator
+	pool   []rune
+	length int
+}
+
+// New returns a Deriver for the master secret, which must not be empty.
+func New(master []byte, opts ...Option) (*Deriver, error) {
+	cfg := defaultConfig()
+
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	if cfg.err != nil {
+		return nil, fmt.Errorf("failed to validate deriver: %w", cfg.err)
+	}
+	if len(master) == 0 {
+		return nil, fmt.Errorf("failed to validate deriver: master secret must not be empty")
+	}
+	gen, err := passgen.NewGenerator(cfg.policy...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to validate deriver policy: %w", err)
+	}
+
+	policy := gen.Config()
+	if policy.ClassWeights != nil {
+		return nil, fmt.Errorf("failed to validate deriver policy: class weights cannot be used with derived passwords")
+	}
+
+	// Candidates are drawn uniformly from the pool, so the policy must accept
+	// enough of them.
+	pool := gen.Charset().Runes()
+	share := gen.Entropy() - float64(policy.Length)*math.Log2(float64(len(pool)))
+	if share < -math.Log2(minAcceptance) {
+		return nil, fmt.Errorf("failed to validate deriver policy: only about 1 in 2^%.0f candidate passwords meets it, too few to derive one", -share)
+	}
+
+	return &Deriver{
+		master: append([]byte(nil), master...),
+		cfg:    cfg,
+		gen:    gen,
+		pool:   pool,
+		length: policy.Length,
+	}, nil
+}
+
+// Password derives the password for username at site. Incrementing the
+// counter rotates the password without changing the master secret. The site
+// and username are used as given, so callers should normalize them, for
+// example by lowercasing host names.
+func (d *Deriver) Password(site, username string, counter uint32) (string, error) {
+	if site == "" {
+		return "", fmt.Errorf("site must not be empty")
+	}
+
+	key, err := d.cfg.kdf(d.master, salt(site, username, counter))
+	if err != nil {
+		return "", fmt.Errorf("failed to derive key: %w", err)
+	}
+
+	stream, err := newKeystream(key)
+	if err != nil {
+		return "", fmt.Errorf("failed to derive key: %w", err)
+	}
+
+	return d.draw(stream)
+}
+
+// draw returns the first candidate password from the keystream that the
+// policy accepts. A candidate has the policy's length, and each of its
+// characters is pool[x mod n] for the next little-endian uint64 x of the
+// keystream, where pool holds the n characters the policy allows in code
+// point order. Values of x from the largest multiple of n up are skipped, so
+// every character is equally likely. Changing any of this changes every
+// password and must bump the version in saltPrefix.
+func (d *Deriver) draw(stream *keystream) (string, error) {
+	n := uint64(len(d.pool))
+	skip := (math.MaxUint64%n + 1) % n
+
+	pass := make([]rune, d.length)
+	var buf [8]byte
+	for range maxAttempts {
+		for i := 0; i < len(pass); {
+			stream.Read(buf[:])
+			if x := binary.LittleEndian.Uint64(buf[:]); x <= math.MaxUint64-skip {
+				pass[i] = d.pool[x%n]
+				i++
+			}
+		}
+
+		err := d.gen.Validate(string(pass))
+		if err == nil {
+			return string(pass), nil
+		}
+		var policyErr *passgen.PolicyError
+		if !errors.As(err, &policyErr) {
+			return "", err
+		}
+	}
+
+	return "", fmt.Errorf("failed to derive a password meeting the policy after %d attempts", maxAttempts)
+}
+
+// salt encodes the inputs unambiguously: every string is prefixed with its
+// length, so ("ab", "c") and ("a", "bc") give different salts.
+func salt(site, username string, counter uint32) []byte {
+	b := make([]byte, 0, len(saltPrefix)+len(site)+len(username)+12)
+	b = append(b, saltPrefix...)
+	b = binary.BigEndian.AppendUint32(b, uint32(len(site)))
+	b = append(b, site...)
+	b = binary.BigEndian.AppendUint32(b, uint32(len(username)))
+	b = append(b, username...)
+	b = binary.BigEndian.AppendUint32(b, counter)
+
+	return b
+}
+
+// keystream is an endless deterministic byte stream: the ChaCha20 keystream
+// for the derived key and a zero nonce. Every key is used for one password
+// only, so the nonce never repeats under a key.
+type keystream struct {
+	cipher *chacha20.Cipher
+}
+
+func newKeystream(key []byte) (*keystream, error) {
+	cipher, err := chacha20.NewUnauthenticatedCipher(key, make([]byte, chacha20.NonceSize))
+	if err != nil {
+		return nil, err
+	}
+
+	return &keystream{cipher: cipher}, nil
+}
+
+func (k *keystream) Read(p []byte) (int, error) {
+	clear(p)
+	k.cipher.XORKeyStream(p, p)
+
+	return len(p), nil
+}
@@ -0,0 +1,253 @@
+package derive
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/haadi-coder/passgen"
+)
+
+var master = []byte("correct horse battery staple")
+
+// fast keeps the KDF cheap in tests; real deployments use the defaults.
+var fast = WithArgon2id(1, 64, 1)
+
+func TestPasswordVectors(t *testing.T) {
+	tests := []struct {
+		name     string
+		options  []Option
+		expected string
+	}{
+		{
+			name:     "argon2id with default policy",
+			options:  []Option{fast},
+			expected: "a3D=0@!BZ-B7ONP6",
+		},
+		{
+			name:     "scrypt with minimums",
+			options:  []Option{WithScrypt(1024, 8, 1), WithPolicy(passgen.WithLength(12), passgen.WithMinRequirements(1, 1, 1, 1))},
+			expected: "81Q%2B(ke:X(",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d, err := New(master, tt.options...)
+			if err != nil {
+				t.Fatalf("failed to create deriver: %v", err)
+			}
+
+			for range 2 {
+				password, err := d.Password("example.com", "alice", 1)
+				if err != nil {
+					t.Fatalf("failed to derive password: %v", err)
+				}
+				if password != tt.expected {
+					t.Errorf("expected %q, got %q", tt.expected, password)
+				}
+			}
+		})
+	}
+}
+
+// TestDrawVector pins the mapping from the keystream to a password, which
+// must not change without a new version in saltPrefix.
+func TestDrawVector(t *testing.T) {
+	tests := []struct {
+		name     string
+		policy   []passgen.Option
+		expected string
+	}{
+		{
+			name:     "digits",
+			policy:   []passgen.Option{passgen.WithLength(12), passgen.WithoutUppercase(), passgen.WithoutLowercase(), passgen.WithoutSymbols()},
+			expected: "087021031498",
+		},
+		{
+			name: "pool size a power of two",
+			policy: []passgen.Option{
+				passgen.WithLength(12), passgen.WithoutUppercase(), passgen.WithoutLowercase(), passgen.WithoutSymbols(), passgen.WithoutDigits(),
+				passgen.WithCharset("hex", passgen.CharsetFromString("0123456789abcdef"), 0),
+			},
+			expected: "60d8a7a3f8b2",
+		},
+		{
+			name:     "default policy",
+			expected: "lL(!FSh7;<ipEcg&",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d, err := New(master, fast, WithPolicy(tt.policy...))
+			if err != nil {
+				t.Fatalf("failed to create deriver: %v", err)
+			}
+
+			stream, err := newKeystream(make([]byte, keySize))
+			if err != nil {
+				t.Fatalf("failed to create keystream: %v", err)
+			}
+			password, err := d.draw(stream)
+			if err != nil {
+				t.Fatalf("failed to draw password: %v", err)
+			}
+			if password != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, password)
+			}
+		})
+	}
+}
+
+func TestPasswordInputs(t *testing.T) {
+	d, err := New(master, fast)
+	if err != nil {
+		t.Fatalf("failed to create deriver: %v", err)
+	}
+	other, err := New([]byte("another master secret"), fast)
+	if err != nil {
+		t.Fatalf("failed to create deriver: %v", err)
+	}
+
+	base, err := d.Password("example.com", "alice", 1)
+	if err != nil {
+		t.Fatalf("failed to derive password: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		deriver  *Deriver
+		site     string
+		username string
+		counter  uint32
+	}{
+		{name: "site", deriver: d, site: "example.org", username: "alice", counter: 1},
+		{name: "username", deriver: d, site: "example.com", username: "bob", counter: 1},
+		{name: "counter", deriver: d, site: "example.com", username: "alice", counter: 2},
+		{name: "master secret", deriver: other, site: "example.com", username: "alice", counter: 1},
+		{name: "boundary between site and username", deriver: d, site: "example.coma", username: "lice", counter: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			password, err := tt.deriver.Password(tt.site, tt.username, tt.counter)
+			if err != nil {
+				t.Fatalf("failed to derive password: %v", err)
+			}
+			if password == base {
+				t.Errorf("expected a different password than %q", base)
+			}
+		})
+	}
+}
+
+func TestPasswordPolicy(t *testing.T) {
+	policy := []passgen.Option{
+		passgen.WithLength(10),
+		passgen.WithMinRequirements(2, 2, 2, 1),
+		passgen.WithoutAmbiguous(),
+		passgen.WithFirstChar(passgen.ClassLowercase),
+	}
+
+	d, err := New(master, fast, WithPolicy(policy...))
+	if err != nil {
+		t.Fatalf("failed to create deriver: %v", err)
+	}
+	gen, err := passgen.NewGenerator(policy...)
+	if err != nil {
+		t.Fatalf("failed to create generator: %v", err)
+	}
+
+	for counter := range uint32(50) {
+		password, err := d.Password("example.com", "svc-backup", counter)
+		if err != nil {
+			t.Fatalf("failed to derive password: %v", err)
+		}
+		if n := len([]rune(password)); n != 10 {
+			t.Errorf("expected length 10, got %d: %q", n, password)
+		}
+		if err := gen.Validate(password); err != nil {
+			t.Errorf("password %q does not satisfy the policy: %v", password, err)
+		}
+	}
+}
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name     string
+		master   []byte
+		options  []Option
+		errorMsg string
+	}{
+		{
+			name:     "empty master secret",
+			master:   nil,
+			errorMsg: "master secret must not be empty",
+		},
+		{
+			name:     "argon2id without passes",
+			master:   master,
+			options:  []Option{WithArgon2id(0, 64, 1)},
+			errorMsg: "argon2id time must be greater than 0",
+		},
+		{
+			name:     "argon2id with too little memory",
+			master:   master,
+			options:  []Option{WithArgon2id(1, 16, 4)},
+			errorMsg: "argon2id memory must be at least 8 KiB per thread, got 16 KiB for 4 threads",
+		},
+		{
+			name:     "scrypt cost not a power of two",
+			master:   master,
+			options:  []Option{WithScrypt(1000, 8, 1)},
+			errorMsg: "scrypt cost must be a power of two greater than 1, got 1000",
+		},
+		{
+			name:     "scrypt without parallelization",
+			master:   master,
+			options:  []Option{WithScrypt(1024, 8, 0)},
+			errorMsg: "scrypt block size and parallelization must be greater than 0, got r=8, p=0",
+		},
+		{
+			name:     "invalid policy",
+			master:   master,
+			options:  []Option{WithPolicy(passgen.WithLength(0))},
+			errorMsg: "failed to validate deriver policy",
+		},
+		{
+			name:     "class weights",
+			master:   master,
+			options:  []Option{WithPolicy(passgen.WithClassWeights(1, 1, 1, 1))},
+			errorMsg: "class weights cannot be used with derived passwords",
+		},
+		{
+			name:     "policy rarely met by candidates",
+			master:   master,
+			options:  []Option{WithPolicy(passgen.WithLength(16), passgen.WithPronounceable())},
+			errorMsg: "candidate passwords meets it, too few to derive one",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := New(tt.master, tt.options...)
+			if err == nil {
+				t.Fatalf("expected error but got none")
+			}
+			if !strings.Contains(err.Error(), tt.errorMsg) {
+				t.Errorf("expected error message to contain %q, got %q", tt.errorMsg, err.Error())
+			}
+		})
+	}
+}
+
+func TestPasswordEmptySite(t *testing.T) {
+	d, err := New(master, fast)
+	if err != nil {
+		t.Fatalf("failed to create deriver: %v", err)
+	}
+
+	if _, err := d.Password("", "alice", 1); err == nil || err.Error() != "site must not be empty" {
+		t.Errorf("expected %q, got %v", "site must not be empty", err)
+	}
+}
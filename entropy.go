@@ -0,0 +1,447 @@
+package passgen
+
+import (
+	"math"
+	"math/big"
+)
+
+// Keyspace returns the exact number of distinct passwords the generator can
+// produce: strings of the configured length over the enabled classes in which
+// every class appears at least as often as its minimum requirement.
+func (g *Generator) Keyspace() *big.Int {
+	return new(big.Int).Set(g.keyspace())
+}
+
+// Entropy returns the entropy in bits of a generated password: log2 of
+// Keyspace, since every password in the keyspace is equally likely, or, with
+// WithClassWeights, the Shannon entropy of the weighted distribution, which
+// is lower. Class requirements are counted in floating point rather than
+// through Keyspace, which is costly for long passwords.
+func (g *Generator) Entropy() float64 {
+	return g.entropy()
+}
+
+// countPasswords counts the strings of the given length over the disjoint
+// classes that satisfy every minimum. It applies inclusion–exclusion over the
+// events "class i appears fewer than min_i times", grouping the subsets S of
+// those events by the total size r of their classes: the strings where every
+// class in S falls short and the other positions are drawn from the rest of
+// the pool only depend on S through how its classes fill j positions and
+// through r. ways[r][j] accumulates, over the subsets with size r,
+// (-1)^|S| times the number of ways to fill exactly j labelled positions
+// with every class of S short, which adds each class in polynomial time
+// instead of doubling the number of subsets.
+func countPasswords(classes []charClass, length int) *big.Int {
+	poolSize := 0
+	for _, class := range classes {
+		poolSize += len(class.chars)
+	}
+
+	ways := make([][]*big.Int, poolSize+1)
+	ways[0] = []*big.Int{big.NewInt(1)}
+
+	for _, class := range classes {
+		if class.min == 0 {
+			continue
+		}
+		n := len(class.chars)
+		size := big.NewInt(int64(n))
+
+		// Walk r downwards so that ways[r] still excludes the class when it
+		// is added to ways[r+n].
+		for r := poolSize - n; r >= 0; r-- {
+			if ways[r] == nil {
+				continue
+			}
+
+			dst := ways[r+n]
+			for len(dst) < min(len(ways[r])+class.min-1, length+1) {
+				dst = append(dst, new(big.Int))
+			}
+
+			for j, w := range ways[r] {
+				if w.Sign() == 0 {
+					continue
+				}
+
+				// coef walks C(j+k, k) * size^k for k = 0, 1, ...
+				coef := big.NewInt(1)
+				for k := 0; k < class.min && j+k <= length; k++ {
+					if k > 0 {
+						coef.Mul(coef, big.NewInt(int64(j+k)))
+						coef.Mul(coef, size)
+						coef.Quo(coef, big.NewInt(int64(k)))
+					}
+
+					dst[j+k].Sub(dst[j+k], new(big.Int).Mul(coef, w))
+				}
+			}
+
+			ways[r+n] = dst
+		}
+	}
+
+	total := new(big.Int)
+	for r, w := range ways {
+		if w != nil {
+			total.Add(total, countShortfalls(w, length, poolSize-r))
+		}
+	}
+
+	return total
+}
+
+// countShortfalls returns the sum over j of ways[j] * C(length, j) *
+// rest^(length-j): the strings whose other positions are drawn from the rest
+// of the pool.
+func countShortfalls(ways []*big.Int, length, rest int) *big.Int {
+	// Walk j downwards so that both C(length, j) and rest^(length-j) can be
+	// updated incrementally.
+	total := new(big.Int)
+	restSize := big.NewInt(int64(rest))
+	binom := new(big.Int).Binomial(int64(length), int64(len(ways)-1))
+	power := new(big.Int).Exp(restSize, big.NewInt(int64(length-len(ways)+1)), nil)
+
+	for j := len(ways) - 1; j >= 0; j-- {
+		term := new(big.Int).Mul(binom, power)
+		total.Add(total, term.Mul(term, ways[j]))
+
+		if j > 0 {
+			binom.Mul(binom, big.NewInt(int64(j)))
+			binom.Quo(binom, big.NewInt(int64(length-j+1)))
+			power.Mul(power, restSize)
+		}
+	}
+
+	return total
+}
+
+// counter returns the function that sizes the keyspace for a given length.
+func (c *config) counter(classes []charClass) func(length int) *big.Int {
+	// Without repeats no character can be repeated in a row either.
+	if c.maxSequential > 0 || c.maxConsecutive > 0 && !c.noRepeats {
+		return func(length int) *big.Int {
+			return countRepetition(classes, c.allowed(classes, length), length, c.maxConsecutive, c.maxSequential)
+		}
+	}
+	if c.mask != "" {
+		mask, _ := parseMask(c.mask, classes)
+		return func(int) *big.Int {
+			return countMasked(mask)
+		}
+	}
+	if c.pronounceable {
+		return newSyllables(c, classes).count
+	}
+	if a, _ := c.anchors(classes); a != nil {
+		return func(length int) *big.Int {
+			return a.count(classes, length)
+		}
+	}
+
+	return func(length int) *big.Int {
+		return countClasses(classes, length, c.noRepeats)
+	}
+}
+
+// log2Counter returns the function that computes log2 of the keyspace for a
+// given length. Plain class requirements are counted in floating point, which
+// stays fast where the exact count grows to tens of thousands of digits.
+func (c *config) log2Counter(classes []charClass) func(length int) float64 {
+	repetition := c.maxSequential > 0 || c.maxConsecutive > 0 && !c.noRepeats
+	if !repetition && c.mask == "" && !c.pronounceable {
+		if a, _ := c.anchors(classes); a != nil {
+			return func(length int) float64 {
+				return a.log2Count(classes, length)
+			}
+		}
+		return func(length int) float64 {
+			return log2Classes(classes, length, c.noRepeats)
+		}
+	}
+
+	if repetition {
+		return func(length int) float64 {
+			rep := newRepetition(classes, c.allowed(classes, length), length, c.maxConsecutive, c.maxSequential)
+			if rep.byClass() {
+				return rep.logCountByClass() / math.Ln2
+			}
+			return rep.logCount() / math.Ln2
+		}
+	}
+
+	count := c.counter(classes)
+	return func(length int) float64 {
+		return log2(count(length))
+	}
+}
+
+// entropy returns the function that computes the entropy for a given length.
+func (c *config) entropy(classes []charClass) func(length int) float64 {
+	if c.weights != nil {
+		weights := c.classWeights(classes)
+		return func(length int) float64 {
+			return weightedEntropy(classes, weights, length)
+		}
+	}
+
+	return c.log2Counter(classes)
+}
+
+// lengthForEntropy returns the smallest length, no shorter than totalMin, whose
+// entropy reaches the given number of bits. The entropy grows with the length
+// and never exceeds length*log2(poolSize), so it gallops upwards from that
+// estimate and then bisects.
+func lengthForEntropy(entropy func(length int) float64, poolSize, totalMin int, bits float64) (int, bool) {
+	reaches := func(length int) bool {
+		return entropy(length) >= bits
+	}
+
+	lo := max(totalMin, 1)
+	if poolSize > 1 {
+		lo = max(lo, int(math.Ceil(bits/math.Log2(float64(poolSize)))))
+	}
+	if lo > maxLength {
+		return 0, false
+	}
+
+	hi, step := lo, 1
+	for !reaches(hi) {
+		if hi == maxLength {
+			return 0, false
+		}
+		lo = hi + 1
+		hi = min(hi+step, maxLength)
+		step *= 2
+	}
+
+	for lo < hi {
+		mid := lo + (hi-lo)/2
+		if reaches(mid) {
+			hi = mid
+		} else {
+			lo = mid + 1
+		}
+	}
+
+	return hi, true
+}
+
+func log2(n *big.Int) float64 {
+	if n.Sign() <= 0 {
+		return math.Inf(-1)
+	}
+
+	mant := new(big.Float)
+	exp := new(big.Float).SetInt(n).MantExp(mant)
+	m, _ := mant.Float64()
+
+	return float64(exp) + math.Log2(m)
+}
+
+// logTail is the natural logarithm of the smallest coefficient, relative to
+// the largest, that log2Classes keeps. Dropping the smaller ones changes the
+// count by far less than float64 rounding.
+const logTail = -70
+
+// log2Classes returns log2 of countClasses(classes, length, distinct) in
+// floating point, in time that grows with the square root of the length
+// rather than with the size of the exact count.
+//
+// The count is length! times the coefficient of x^length in the product over
+// the classes of sum_k a_k x^k, where k runs from the minimum to the maximum
+// count of the class and a_k is n^k/k! for a class of n characters, or
+// C(n, k) without repeats. Every coefficient is positive, so the product can
+// be computed in floating point without cancellation. Substituting s*x for x
+// with s chosen so that the terms around x^length dominate lets every factor
+// keep only the coefficients near its largest one, scaled to it.
+func log2Classes(classes []charClass, length int, distinct bool) float64 {
+	var bounded []classBounds
+	lo, hi, pool := 0, 0, 0
+	for _, class := range classes {
+		b := classBounds{size: len(class.chars), lo: class.min, hi: min(class.max, length), distinct: distinct}
+		if distinct || b.size == 0 {
+			b.hi = min(b.hi, b.size)
+		}
+		if b.lo > b.hi {
+			return math.Inf(-1)
+		}
+
+		lo += b.lo
+		hi += b.hi
+		if b.hi > 0 {
+			bounded = append(bounded, b)
+			pool += b.size
+		}
+	}
+	if lo > length || hi < length {
+		return math.Inf(-1)
+	}
+	if length == 0 {
+		return 0
+	}
+
+	// The mean power of the scaled product grows with s; bisect for the s
+	// that puts it at the length.
+	below := math.Log(float64(length)/float64(pool)) - 80
+	above := below + 160
+	for range 100 {
+		logS := (below + above) / 2
+
+		mean := 0.0
+		for _, b := range bounded {
+			mean += b.factor(logS).mean()
+		}
+		if math.Abs(mean-float64(length)) < 0.5 {
+			below, above = logS, logS
+			break
+		}
+		if mean < float64(length) {
+			below = logS
+		} else {
+			above = logS
+		}
+	}
+	logS := (below + above) / 2
+
+	product := logPolynomial{coef: []float64{1}}
+	for _, b := range bounded {
+		product = product.mul(b.factor(logS), length)
+	}
+
+	i := length - product.lo
+	if i < 0 || i >= len(product.coef) || product.coef[i] == 0 {
+		return log2(countClasses(classes, length, distinct))
+	}
+
+	return (lgamma(length+1) - float64(length)*logS + product.scale + math.Log(product.coef[i])) / math.Ln2
+}
+
+// classBounds describes the factor sum_k a_k x^k of a class of size
+// characters drawn from lo to hi times.
+type classBounds struct {
+	size     int
+	lo, hi   int
+	distinct bool
+}
+
+// ratio returns a_{k+1}*s / a_k.
+func (b classBounds) ratio(k int, s float64) float64 {
+	if b.distinct {
+		return s * float64(b.size-k) / float64(k+1)
+	}
+
+	return s * float64(b.size) / float64(k+1)
+}
+
+// logTerm returns log(a_k) + k*logS.
+func (b classBounds) logTerm(k int, logS float64) float64 {
+	term := float64(k) * logS
+	if b.distinct {
+		return term + lgamma(b.size+1) - lgamma(k+1) - lgamma(b.size-k+1)
+	}
+	if k == 0 {
+		return term
+	}
+
+	return term + float64(k)*math.Log(float64(b.size)) - lgamma(k+1)
+}
+
+// factor returns the class's factor with x scaled by e^logS, keeping the
+// coefficients around the largest one.
+func (b classBounds) factor(logS float64) logPolynomial {
+	s := math.Exp(logS)
+
+	// The coefficients rise while the ratio exceeds one and fall after.
+	guess := s * float64(b.size)
+	if b.distinct {
+		guess = (s*float64(b.size) - 1) / (s + 1)
+	}
+	top := int(max(float64(b.lo), min(float64(b.hi), math.Floor(guess))))
+	for top < b.hi && b.ratio(top, s) > 1 {
+		top++
+	}
+	for top > b.lo && b.ratio(top-1, s) < 1 {
+		top--
+	}
+
+	tail := math.Exp(logTail)
+	var falling, rising []float64
+	for k, a := top, 1.0; k > b.lo; k-- {
+		if a /= b.ratio(k-1, s); a < tail {
+			break
+		}
+		falling = append(falling, a)
+	}
+	for k, a := top, 1.0; k < b.hi; k++ {
+		if a *= b.ratio(k, s); a < tail {
+			break
+		}
+		rising = append(rising, a)
+	}
+
+	coef := make([]float64, 0, len(falling)+1+len(rising))
+	for i := len(falling) - 1; i >= 0; i-- {
+		coef = append(coef, falling[i])
+	}
+	coef = append(coef, 1)
+	coef = append(coef, rising...)
+
+	return logPolynomial{lo: top - len(falling), coef: coef, scale: b.logTerm(top, logS)}
+}
+
+// logPolynomial is a polynomial whose coefficients of x^lo, x^(lo+1), ...
+// are e^scale times coef.
+type logPolynomial struct {
+	lo    int
+	coef  []float64
+	scale float64
+}
+
+func (p logPolynomial) mean() float64 {
+	sum, weighted := 0.0, 0.0
+	for i, c := range p.coef {
+		sum += c
+		weighted += float64(p.lo+i) * c
+	}
+
+	return weighted / sum
+}
+
+// mul returns the product of p and q up to x^limit, scaled to its largest
+// coefficient and without the negligible ones at either end.
+func (p logPolynomial) mul(q logPolynomial, limit int) logPolynomial {
+	lo := p.lo + q.lo
+	coef := make([]float64, max(min(len(p.coef)+len(q.coef)-1, limit-lo+1), 0))
+	for i, a := range p.coef {
+		for j, b := range q.coef {
+			if i+j >= len(coef) {
+				break
+			}
+			coef[i+j] += a * b
+		}
+	}
+
+	largest := 0.0
+	for _, c := range coef {
+		largest = max(largest, c)
+	}
+	if largest == 0 {
+		return logPolynomial{lo: lo}
+	}
+
+	tail := largest * math.Exp(logTail)
+	first, last := 0, len(coef)
+	for first < last && coef[first] < tail {
+		first++
+	}
+	for last > first && coef[last-1] < tail {
+		last--
+	}
+	coef = coef[first:last]
+	for i := range coef {
+		coef[i] /= largest
+	}
+
+	return logPolynomial{lo: lo + first, coef: coef, scale: p.scale + q.scale + math.Log(largest)}
+}
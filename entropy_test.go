@@ -0,0 +1,365 @@
+package passgen
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"testing"
+)
+
+func TestKeyspace(t *testing.T) {
+	tests := []struct {
+		name     string
+		options  []Option
+		expected *big.Int
+	}{
+		{
+			name:     "default configuration",
+			options:  nil,
+			expected: new(big.Int).Exp(big.NewInt(88), big.NewInt(16), nil),
+		},
+		{
+			name:     "digits only",
+			options:  []Option{WithLength(6), WithoutUppercase(), WithoutLowercase(), WithoutSymbols()},
+			expected: big.NewInt(1000000),
+		},
+		{
+			// 36^2 - 26^2: all strings minus those without a digit.
+			name:     "one required digit",
+			options:  []Option{WithLength(2), WithoutUppercase(), WithoutSymbols(), WithMinDigits(1)},
+			expected: big.NewInt(36*36 - 26*26),
+		},
+		{
+			// Every position is fixed to a class, so the count is 4!/(1!1!1!1!) * 26*26*10*26.
+			name:     "min requirements equal to length",
+			options:  []Option{WithLength(4), WithMinRequirements(1, 1, 1, 1)},
+			expected: big.NewInt(24 * 26 * 26 * 10 * 26),
+		},
+		{
+			name:     "without ambiguous",
+			options:  []Option{WithLength(3), WithoutUppercase(), WithoutLowercase(), WithoutSymbols(), WithoutAmbiguous()},
+			expected: big.NewInt(7 * 7 * 7),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gen, err := NewGenerator(tt.options...)
+			if err != nil {
+				t.Fatalf("failed to create generator: %v", err)
+			}
+
+			if got := gen.Keyspace(); got.Cmp(tt.expected) != 0 {
+				t.Errorf("expected keyspace %v, got %v", tt.expected, got)
+			}
+
+			expectedBits, _ := new(big.Float).SetInt(tt.expected).Float64()
+			if got := gen.Entropy(); math.Abs(got-math.Log2(expectedBits)) > 1e-9 {
+				t.Errorf("expected entropy %v, got %v", math.Log2(expectedBits), got)
+			}
+		})
+	}
+}
+
+func TestKeyspaceMatchesBruteForce(t *testing.T) {
+	base := []Option{WithoutUppercase(), WithoutLowercase(), WithoutDigits(), WithoutSymbols()}
+
+	tests := []struct {
+		name    string
+		options []Option
+	}{
+		{
+			name: "single min",
+			options: []Option{
+				WithLength(5),
+				WithCharset("a", CharsetFromString("ab"), 2),
+				WithCharset("c", CharsetFromString("cde"), 0),
+			},
+		},
+		{
+			name: "all constrained",
+			options: []Option{
+				WithLength(6),
+				WithCharset("a", CharsetFromString("ab"), 1),
+				WithCharset("c", CharsetFromString("cde"), 2),
+				WithCharset("f", CharsetFromString("f"), 1),
+			},
+		},
+		{
+			name: "mins equal to length",
+			options: []Option{
+				WithLength(5),
+				WithCharset("a", CharsetFromString("ab"), 3),
+				WithCharset("c", CharsetFromString("cde"), 2),
+			},
+		},
+		{
+			name: "unconstrained",
+			options: []Option{
+				WithLength(4),
+				WithCharset("a", CharsetFromString("abcd"), 0),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gen, err := NewGenerator(append(base, tt.options...)...)
+			if err != nil {
+				t.Fatalf("failed to create generator: %v", err)
+			}
+
+			expected := bruteForceCount(gen.classes, gen.cfg.length)
+			if got := gen.Keyspace(); got.Cmp(big.NewInt(expected)) != 0 {
+				t.Errorf("expected keyspace %d, got %v", expected, got)
+			}
+		})
+	}
+}
+
+func TestKeyspaceReturnsCopy(t *testing.T) {
+	gen, err := NewGenerator(WithLength(4))
+	if err != nil {
+		t.Fatalf("failed to create generator: %v", err)
+	}
+
+	gen.Keyspace().SetInt64(0)
+	if gen.Keyspace().Sign() == 0 {
+		t.Error("modifying the returned keyspace changed the generator")
+	}
+}
+
+func TestKeyspaceManyCharsets(t *testing.T) {
+	// Filling exactly one position from each of 70 two-character charsets
+	// gives 70! * 2^70 passwords.
+	const n = 70
+
+	opts := []Option{WithLength(n), WithoutUppercase(), WithoutLowercase(), WithoutDigits(), WithoutSymbols()}
+	for i := range n {
+		chars := CharsetFromString(string([]rune{rune(0x400 + 2*i), rune(0x401 + 2*i)}))
+		opts = append(opts, WithCharset(fmt.Sprintf("set%d", i), chars, 1))
+	}
+
+	gen, err := NewGenerator(opts...)
+	if err != nil {
+		t.Fatalf("failed to create generator: %v", err)
+	}
+
+	expected := new(big.Int).MulRange(1, n)
+	expected.Lsh(expected, n)
+	if got := gen.Keyspace(); got.Cmp(expected) != 0 {
+		t.Errorf("expected keyspace %v, got %v", expected, got)
+	}
+
+	gen, err = NewGenerator(append(opts[:len(opts):len(opts)], WithMinEntropy(512))...)
+	if err != nil {
+		t.Fatalf("failed to create generator: %v", err)
+	}
+	if got := gen.Entropy(); got < 512 {
+		t.Errorf("expected at least 512 bits, got %v", got)
+	}
+}
+
+func TestEntropyLargeConfig(t *testing.T) {
+	gen, err := NewGenerator(WithLength(1000), WithMinRequirements(200, 200, 200, 200))
+	if err != nil {
+		t.Fatalf("failed to create generator: %v", err)
+	}
+
+	// Requiring 200 digits where about 114 are expected costs roughly
+	// 1000 * KL(0.2 || 10/88) ≈ 45 bits compared to the unconstrained keyspace.
+	naive := 1000 * math.Log2(88)
+	if got := gen.Entropy(); got >= naive-40 || got < naive-60 {
+		t.Errorf("expected entropy between %v and %v, got %v", naive-60, naive-40, got)
+	}
+}
+
+func TestEntropyMatchesKeyspace(t *testing.T) {
+	tests := []struct {
+		name    string
+		options []Option
+	}{
+		{
+			name:    "min requirements",
+			options: []Option{WithLength(40), WithMinRequirements(3, 5, 7, 9)},
+		},
+		{
+			name:    "minimums far above the expected counts",
+			options: []Option{WithLength(300), WithMinDigits(250), WithMinSymbols(20)},
+		},
+		{
+			name:    "maximums",
+			options: []Option{WithLength(200), WithMinDigits(10), WithMaxUppercase(3), WithMaxSymbols(1)},
+		},
+		{
+			name:    "no repeats",
+			options: []Option{WithLength(60), WithNoRepeats(), WithMinRequirements(2, 2, 2, 2)},
+		},
+		{
+			name:    "no repeats using every character",
+			options: []Option{WithLength(36), WithoutUppercase(), WithoutSymbols(), WithNoRepeats()},
+		},
+		{
+			name:    "max consecutive with minimums and maximums",
+			options: []Option{WithLength(40), WithMaxConsecutive(2), WithMinRequirements(2, 2, 2, 2), WithMaxSymbols(4)},
+		},
+		{
+			name:    "max sequential",
+			options: []Option{WithLength(20), WithMaxSequential(2), WithMinDigits(3)},
+		},
+		{
+			name:    "first and last characters",
+			options: []Option{WithLength(30), WithMinRequirements(2, 2, 2, 2), WithMaxSymbols(5), WithFirstChar(ClassUppercase, ClassDigits), WithLastChar(ClassSymbols)},
+		},
+		{
+			name:    "custom charsets without ambiguous",
+			options: []Option{WithLength(50), WithoutAmbiguous(), WithCharset("greek", CharsetFromString("αβγδε"), 4)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gen, err := NewGenerator(tt.options...)
+			if err != nil {
+				t.Fatalf("failed to create generator: %v", err)
+			}
+
+			expected := log2(gen.Keyspace())
+			if got := gen.Entropy(); math.Abs(got-expected) > 1e-9 {
+				t.Errorf("expected entropy %v, got %v", expected, got)
+			}
+		})
+	}
+}
+
+func TestEntropyLongPassword(t *testing.T) {
+	gen, err := NewGenerator(WithLength(10000), WithMinRequirements(2000, 2000, 2000, 2000))
+	if err != nil {
+		t.Fatalf("failed to create generator: %v", err)
+	}
+
+	// As in TestEntropyLargeConfig, about 45 bits per 1000 characters.
+	naive := 10000 * math.Log2(88)
+	if got := gen.Entropy(); got >= naive-400 || got < naive-500 {
+		t.Errorf("expected entropy between %v and %v, got %v", naive-500, naive-400, got)
+	}
+
+	if _, err := gen.GenerateN(2, WithUnique()); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func bruteForceCount(classes []charClass, length int) int64 {
+	var pool []rune
+	classOf := make(map[rune]int)
+	for i, class := range classes {
+		pool = append(pool, class.chars...)
+		for _, r := range class.chars {
+			classOf[r] = i
+		}
+	}
+
+	var count int64
+	idx := make([]int, length)
+	for {
+		counts := make([]int, len(classes))
+		for _, i := range idx {
+			counts[classOf[pool[i]]]++
+		}
+
+		valid := true
+		for i, class := range classes {
+			if counts[i] < class.min {
+				valid = false
+			}
+		}
+		if valid {
+			count++
+		}
+
+		pos := 0
+		for pos < length {
+			idx[pos]++
+			if idx[pos] < len(pool) {
+				break
+			}
+			idx[pos] = 0
+			pos++
+		}
+		if pos == length {
+			return count
+		}
+	}
+}
+
+func TestWithMinEntropy(t *testing.T) {
+	tests := []struct {
+		name           string
+		options        []Option
+		bits           float64
+		expectedLength int
+	}{
+		{
+			// 128 / log2(88) ≈ 19.8
+			name:           "default classes",
+			bits:           128,
+			expectedLength: 20,
+		},
+		{
+			// 64 / log2(10) ≈ 19.3
+			name:           "digits only",
+			options:        []Option{WithoutUppercase(), WithoutLowercase(), WithoutSymbols()},
+			bits:           64,
+			expectedLength: 20,
+		},
+		{
+			name:           "overrides length",
+			options:        []Option{WithLength(100)},
+			bits:           20,
+			expectedLength: 4,
+		},
+		{
+			name:           "minimums are at least the length",
+			options:        []Option{WithMinRequirements(3, 3, 3, 3)},
+			bits:           1,
+			expectedLength: 12,
+		},
+		{
+			// 12 characters give 77.5 bits without minimums but only 77.1 with them.
+			name:           "minimums reduce entropy",
+			options:        []Option{WithMinRequirements(1, 1, 1, 1)},
+			bits:           77.3,
+			expectedLength: 13,
+		},
+		{
+			name:           "without ambiguous",
+			options:        []Option{WithoutUppercase(), WithoutLowercase(), WithoutSymbols(), WithoutAmbiguous()},
+			bits:           30,
+			expectedLength: 11,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gen, err := NewGenerator(append(tt.options, WithMinEntropy(tt.bits))...)
+			if err != nil {
+				t.Fatalf("failed to create generator: %v", err)
+			}
+
+			if gen.cfg.length != tt.expectedLength {
+				t.Errorf("expected length %d, got %d", tt.expectedLength, gen.cfg.length)
+			}
+			if got := gen.Entropy(); got < tt.bits {
+				t.Errorf("expected at least %v bits, got %v", tt.bits, got)
+			}
+
+			password, err := gen.Generate()
+			if err != nil {
+				t.Fatalf("failed to generate password: %v", err)
+			}
+			if len(password) != tt.expectedLength {
+				t.Errorf("expected password length %d, got %d", tt.expectedLength, len(password))
+			}
+		})
+	}
+}
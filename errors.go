@@ -0,0 +1,134 @@
+package passgen
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Sentinel errors for invalid generator configurations. Every *ConfigError
+// matches one of them with errors.Is.
+var (
+	// ErrOutOfRange reports a number that is negative, not positive where it
+	// must be, not a number or too large.
+	ErrOutOfRange = errors.New("value out of range")
+	// ErrMaxBelowMin reports a maximum count below the matching minimum.
+	ErrMaxBelowMin = errors.New("maximum below minimum")
+	// ErrInvalidCharset reports a custom charset with an empty or reserved
+	// name, without characters or with a rune that is not a valid character.
+	ErrInvalidCharset = errors.New("invalid charset")
+	// ErrOverlappingCharsets reports a character that belongs to two
+	// classes.
+	ErrOverlappingCharsets = errors.New("overlapping charsets")
+	// ErrNoCharacters reports that no class is enabled, or that excluding
+	// ambiguous characters leaves nothing to draw from.
+	ErrNoCharacters = errors.New("no characters available")
+	// ErrDisabledClass reports a requirement on a class that is disabled or
+	// does not exist.
+	ErrDisabledClass = errors.New("class disabled or unknown")
+	// ErrInvalidMask reports a mask that cannot be parsed.
+	ErrInvalidMask = errors.New("invalid mask")
+	// ErrConflictingOptions reports two options that cannot be combined.
+	ErrConflictingOptions = errors.New("conflicting options")
+	// ErrUnsatisfiable reports requirements no password of the configured
+	// length can meet.
+	ErrUnsatisfiable = errors.New("unsatisfiable requirements")
+)
+
+// Field names reported in ConfigError.Field. Custom charsets are reported as
+// "charsets[i].name", "charsets[i].chars" and "charsets[i].min", where i is
+// the index of the charset among those added with WithCharset.
+const (
+	FieldLength           = "length"
+	FieldMinEntropy       = "min_entropy"
+	FieldMinUppercase     = "min_uppercase"
+	FieldMinLowercase     = "min_lowercase"
+	FieldMinDigits        = "min_digits"
+	FieldMinSymbols       = "min_symbols"
+	FieldMaxUppercase     = "max_uppercase"
+	FieldMaxLowercase     = "max_lowercase"
+	FieldMaxDigits        = "max_digits"
+	FieldMaxSymbols       = "max_symbols"
+	FieldClassWeights     = "class_weights"
+	FieldCharsets         = "charsets"
+	FieldExcludeAmbiguous = "exclude_ambiguous"
+	FieldPronounceable    = "pronounceable"
+	FieldMask             = "mask"
+	FieldFirstChar        = "first_char"
+	FieldLastChar         = "last_char"
+	FieldMaxConsecutive   = "max_consecutive"
+	FieldMaxSequential    = "max_sequential"
+	FieldNoRepeats        = "no_repeats"
+)
+
+// ConfigError describes one invalid setting of a generator configuration.
+type ConfigError struct {
+	// Field names the setting, one of the Field constants. It is empty when
+	// the configuration as a whole is at fault.
+	Field string
+	// Value is the offending value of the setting.
+	Value any
+	// Rule is the sentinel error the setting violates.
+	Rule error
+
+	msg string
+}
+
+func newConfigError(field string, value any, rule error, format string, args ...any) *ConfigError {
+	return &ConfigError{Field: field, Value: value, Rule: rule, msg: fmt.Sprintf(format, args...)}
+}
+
+func (e *ConfigError) Error() string {
+	return e.msg
+}
+
+func (e *ConfigError) Unwrap() error {
+	return e.Rule
+}
+
+// configErrors lists every invalid setting found by validate, so callers see
+// all of them at once. errors.Is and errors.As look at each of them.
+type configErrors []*ConfigError
+
+func (e configErrors) Error() string {
+	msgs := make([]string, len(e))
+	for i, err := range e {
+		msgs[i] = err.Error()
+	}
+
+	return strings.Join(msgs, "; ")
+}
+
+func (e configErrors) Unwrap() []error {
+	errs := make([]error, len(e))
+	for i, err := range e {
+		errs[i] = err
+	}
+
+	return errs
+}
+
+// ConfigErrors returns every *ConfigError in err, in the order the settings
+// were checked. NewGenerator reports all invalid settings at once; errors.As
+// only finds the first of them.
+func ConfigErrors(err error) []*ConfigError {
+	var found []*ConfigError
+
+	var walk func(err error)
+	walk = func(err error) {
+		switch e := err.(type) {
+		case nil:
+		case *ConfigError:
+			found = append(found, e)
+		case interface{ Unwrap() []error }:
+			for _, inner := range e.Unwrap() {
+				walk(inner)
+			}
+		case interface{ Unwrap() error }:
+			walk(e.Unwrap())
+		}
+	}
+	walk(err)
+
+	return found
+}
@@ -0,0 +1,146 @@
+package passgen
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestConfigErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		options []Option
+		field   string
+		value   any
+		rule    error
+	}{
+		{
+			name:    "length_zero",
+			options: []Option{WithLength(0)},
+			field:   FieldLength,
+			value:   0,
+			rule:    ErrOutOfRange,
+		},
+		{
+			name:    "negative_min_digits",
+			options: []Option{WithMinDigits(-2)},
+			field:   FieldMinDigits,
+			value:   -2,
+			rule:    ErrOutOfRange,
+		},
+		{
+			name:    "disabled_symbols_with_minimum",
+			options: []Option{WithoutSymbols(), WithMinSymbols(1)},
+			field:   FieldMinSymbols,
+			value:   1,
+			rule:    ErrDisabledClass,
+		},
+		{
+			name:    "overlapping_charset",
+			options: []Option{WithCharset("hex", CharsetFromString("0123456789abcdef"), 0)},
+			field:   "charsets[0].chars",
+			rule:    ErrOverlappingCharsets,
+		},
+		{
+			name:    "surrogate_charset",
+			options: []Option{WithLength(6), WithoutUppercase(), WithoutLowercase(), WithoutDigits(), WithoutSymbols(), WithCharset("sur", CharsetFromRange(0xD800, 0xD802), 0)},
+			field:   "charsets[0].chars",
+			value:   rune(0xD800),
+			rule:    ErrInvalidCharset,
+		},
+		{
+			name:    "invalid_mask",
+			options: []Option{WithMask("?x")},
+			field:   FieldMask,
+			value:   "?x",
+			rule:    ErrInvalidMask,
+		},
+		{
+			name:    "unknown_first_char_class",
+			options: []Option{WithFirstChar("runes")},
+			field:   FieldFirstChar,
+			value:   []string{"runes"},
+			rule:    ErrDisabledClass,
+		},
+		{
+			name:    "minimums_exceed_length",
+			options: []Option{WithLength(4), WithMinDigits(3), WithMinSymbols(3)},
+			field:   FieldLength,
+			value:   4,
+			rule:    ErrUnsatisfiable,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewGenerator(tt.options...)
+			if err == nil {
+				t.Fatal("expected error but got none")
+			}
+			if !errors.Is(err, tt.rule) {
+				t.Errorf("expected error to match %v, got %v", tt.rule, err)
+			}
+
+			var cfgErr *ConfigError
+			if !errors.As(err, &cfgErr) {
+				t.Fatalf("expected a *ConfigError, got %T", err)
+			}
+			if cfgErr.Field != tt.field {
+				t.Errorf("expected field %q, got %q", tt.field, cfgErr.Field)
+			}
+			if tt.value != nil && !equalValue(cfgErr.Value, tt.value) {
+				t.Errorf("expected value %v, got %v", tt.value, cfgErr.Value)
+			}
+			if cfgErr.Rule != tt.rule {
+				t.Errorf("expected rule %v, got %v", tt.rule, cfgErr.Rule)
+			}
+		})
+	}
+}
+
+func TestConfigErrorsReportsEveryViolation(t *testing.T) {
+	_, err := NewGenerator(
+		WithLength(-1),
+		WithMinUppercase(-1),
+		WithoutDigits(),
+		WithMinDigits(2),
+		WithMaxSymbols(-3),
+	)
+	if err == nil {
+		t.Fatal("expected error but got none")
+	}
+
+	var fields []string
+	for _, e := range ConfigErrors(err) {
+		fields = append(fields, e.Field)
+	}
+	want := []string{FieldLength, FieldMinUppercase, FieldMaxSymbols, FieldMinDigits}
+	slices.Sort(fields)
+	slices.Sort(want)
+	if !slices.Equal(fields, want) {
+		t.Errorf("expected violations of %v, got %v", want, fields)
+	}
+
+	if !errors.Is(err, ErrOutOfRange) || !errors.Is(err, ErrDisabledClass) {
+		t.Errorf("expected error to match every rule, got %v", err)
+	}
+}
+
+func TestConfigErrorsNil(t *testing.T) {
+	if errs := ConfigErrors(nil); errs != nil {
+		t.Errorf("expected no errors, got %v", errs)
+	}
+	if errs := ConfigErrors(errors.New("other")); errs != nil {
+		t.Errorf("expected no errors, got %v", errs)
+	}
+}
+
+func equalValue(a, b any) bool {
+	as, aok := a.([]string)
+	bs, bok := b.([]string)
+	if aok && bok {
+		return slices.Equal(as, bs)
+	}
+
+	return a == b
+}
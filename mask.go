@@ -0,0 +1,106 @@
+package passgen
+
+import (
+	"fmt"
+	"io"
+	"math/big"
+	"slices"
+)
+
+// parseMask resolves a hashcat-style mask against the enabled classes. Each
+// position becomes the set of characters it is drawn from:
+//
+//	?u ?l ?d ?s  uppercase, lowercase, digits, symbols
+//	?a           every enabled class
+//	?{name}      the custom charset added with WithCharset(name, ...)
+//	??           a literal '?'
+//
+// Any other character stands for itself.
+func parseMask(mask string, classes []charClass) ([][]rune, error) {
+	byName := make(map[string][]rune, len(classes))
+	var all []rune
+	for _, class := range classes {
+		byName[class.name] = class.chars
+		all = append(all, class.chars...)
+	}
+
+	builtin := map[rune]string{
+		'u': ClassUppercase,
+		'l': ClassLowercase,
+		'd': ClassDigits,
+		's': ClassSymbols,
+	}
+
+	var positions [][]rune
+	runes := []rune(mask)
+
+	for i := 0; i < len(runes); i++ {
+		if runes[i] != '?' {
+			positions = append(positions, []rune{runes[i]})
+			continue
+		}
+
+		if i+1 == len(runes) {
+			return nil, fmt.Errorf("mask ends with an incomplete placeholder")
+		}
+		i++
+
+		var name string
+		switch c := runes[i]; c {
+		case '?':
+			positions = append(positions, []rune{'?'})
+			continue
+		case 'a':
+			positions = append(positions, all)
+			continue
+		case '{':
+			end := slices.Index(runes[i:], '}')
+			if end < 0 {
+				return nil, fmt.Errorf("mask placeholder at position %d is missing a closing brace", i-1)
+			}
+			name = string(runes[i+1 : i+end])
+			i += end
+		default:
+			builtinName, ok := builtin[c]
+			if !ok {
+				return nil, fmt.Errorf("unknown mask placeholder \"?%c\" at position %d", c, i-1)
+			}
+			name = builtinName
+		}
+
+		chars, ok := byName[name]
+		if !ok {
+			return nil, fmt.Errorf("mask refers to disabled or unknown class %q", name)
+		}
+		if len(chars) == 0 {
+			return nil, fmt.Errorf("mask refers to class %q, which is empty after excluding ambiguous characters", name)
+		}
+
+		positions = append(positions, chars)
+	}
+
+	if len(positions) == 0 {
+		return nil, fmt.Errorf("mask must not be empty")
+	}
+
+	return positions, nil
+}
+
+func generateMasked(r io.Reader, pass []rune, mask [][]rune) error {
+	for i, chars := range mask {
+		if err := generatePassEntry(r, pass[i:i+1], chars); err != nil {
+			return fmt.Errorf("failed to generate password entry: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func countMasked(mask [][]rune) *big.Int {
+	total := big.NewInt(1)
+	for _, chars := range mask {
+		total.Mul(total, big.NewInt(int64(len(chars))))
+	}
+
+	return total
+}
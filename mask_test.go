@@ -0,0 +1,213 @@
+package passgen
+
+import (
+	"errors"
+	"math/big"
+	"strings"
+	"testing"
+)
+
+func TestWithMask(t *testing.T) {
+	tests := []struct {
+		name      string
+		options   []Option
+		positions []Charset
+	}{
+		{
+			name:      "built-in placeholders",
+			options:   []Option{WithMask("?u?l?l?d?s")},
+			positions: []Charset{Uppercase, Lowercase, Lowercase, Digits, Symbols},
+		},
+		{
+			name:    "literals and escaped question mark",
+			options: []Option{WithMask("id-?d??")},
+			positions: []Charset{
+				CharsetFromString("i"), CharsetFromString("d"), CharsetFromString("-"),
+				Digits, CharsetFromString("?"),
+			},
+		},
+		{
+			name:      "any enabled class",
+			options:   []Option{WithMask("?a?a"), WithoutSymbols()},
+			positions: []Charset{Uppercase.Union(Lowercase, Digits), Uppercase.Union(Lowercase, Digits)},
+		},
+		{
+			name:      "custom charset",
+			options:   []Option{WithMask("?{hex}?{hex}"), WithCharset("hex", CharsetFromString("abcdef"), 0), WithoutLowercase()},
+			positions: []Charset{CharsetFromString("abcdef"), CharsetFromString("abcdef")},
+		},
+		{
+			name:      "without ambiguous",
+			options:   []Option{WithMask("?d?d?d"), WithoutAmbiguous()},
+			positions: []Charset{Digits.Difference(Ambiguous), Digits.Difference(Ambiguous), Digits.Difference(Ambiguous)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gen, err := NewGenerator(tt.options...)
+			if err != nil {
+				t.Fatalf("failed to create generator: %v", err)
+			}
+
+			for range 200 {
+				password, err := gen.Generate()
+				if err != nil {
+					t.Fatalf("failed to generate password: %v", err)
+				}
+
+				runes := []rune(password)
+				if len(runes) != len(tt.positions) {
+					t.Fatalf("expected length %d, got %d: %q", len(tt.positions), len(runes), password)
+				}
+				for i, r := range runes {
+					if !tt.positions[i].Contains(r) {
+						t.Errorf("character %q at position %d of %q is not in %q", r, i, password, tt.positions[i])
+					}
+				}
+				if err := gen.Validate(password); err != nil {
+					t.Errorf("password %q does not satisfy the policy: %v", password, err)
+				}
+			}
+
+			expected := big.NewInt(1)
+			for _, p := range tt.positions {
+				expected.Mul(expected, big.NewInt(int64(p.Len())))
+			}
+			if got := gen.Keyspace(); got.Cmp(expected) != 0 {
+				t.Errorf("expected keyspace %v, got %v", expected, got)
+			}
+		})
+	}
+}
+
+func TestNewGeneratorMask(t *testing.T) {
+	tests := []struct {
+		name     string
+		options  []Option
+		errorMsg string
+	}{
+		{
+			name:     "incomplete placeholder",
+			options:  []Option{WithMask("?d?")},
+			errorMsg: "mask ends with an incomplete placeholder",
+		},
+		{
+			name:     "unknown placeholder",
+			options:  []Option{WithMask("ab?x")},
+			errorMsg: `unknown mask placeholder "?x" at position 2`,
+		},
+		{
+			name:     "missing closing brace",
+			options:  []Option{WithMask("?{hex")},
+			errorMsg: "mask placeholder at position 0 is missing a closing brace",
+		},
+		{
+			name:     "disabled class",
+			options:  []Option{WithMask("?d?s"), WithoutSymbols()},
+			errorMsg: `mask refers to disabled or unknown class "symbols"`,
+		},
+		{
+			name:     "unknown custom charset",
+			options:  []Option{WithMask("?{hex}")},
+			errorMsg: `mask refers to disabled or unknown class "hex"`,
+		},
+		{
+			name:     "class emptied by exclusion",
+			options:  []Option{WithMask("?d"), WithoutAmbiguous(), WithAmbiguousChars(Digits)},
+			errorMsg: `mask refers to class "digits", which is empty after excluding ambiguous characters`,
+		},
+		{
+			name:     "combined with minimum requirements",
+			options:  []Option{WithMask("?d?d"), WithMinDigits(1)},
+			errorMsg: "minimum requirements cannot be combined with a mask, got 1",
+		},
+		{
+			name:     "combined with minimum entropy",
+			options:  []Option{WithMask("?d?d"), WithMinEntropy(40)},
+			errorMsg: "minimum entropy cannot be combined with a mask",
+		},
+		{
+			name:     "combined with pronounceable",
+			options:  []Option{WithMask("?l?l"), WithPronounceable()},
+			errorMsg: "pronounceable passwords cannot be combined with a mask",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewGenerator(tt.options...)
+			if err == nil {
+				t.Fatalf("expected error but got none")
+			}
+			if !strings.Contains(err.Error(), tt.errorMsg) {
+				t.Errorf("expected error message to contain %q, got %q", tt.errorMsg, err.Error())
+			}
+		})
+	}
+}
+
+func TestValidateMask(t *testing.T) {
+	gen, err := NewGenerator(WithMask("?u?l-?d?d"))
+	if err != nil {
+		t.Fatalf("failed to create generator: %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		password   string
+		violations []Violation
+	}{
+		{
+			name:     "matching password",
+			password: "Ab-42",
+		},
+		{
+			name:     "wrong classes",
+			password: "ab_42",
+			violations: []Violation{
+				{Rule: RuleMask, Position: 0},
+				{Rule: RuleMask, Position: 2},
+			},
+		},
+		{
+			name:     "too short",
+			password: "Ab-4",
+			violations: []Violation{
+				{Rule: RuleMinLength, Want: 5, Got: 4},
+			},
+		},
+		{
+			name:     "too long",
+			password: "Ab-42x",
+			violations: []Violation{
+				{Rule: RuleMaxLength, Want: 5, Got: 6},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := gen.Validate(tt.password)
+			if len(tt.violations) == 0 {
+				if err != nil {
+					t.Errorf("expected no error, got %v", err)
+				}
+				return
+			}
+
+			var policyErr *PolicyError
+			if !errors.As(err, &policyErr) {
+				t.Fatalf("expected *PolicyError, got %v", err)
+			}
+			if len(policyErr.Violations) != len(tt.violations) {
+				t.Fatalf("expected violations %+v, got %+v", tt.violations, policyErr.Violations)
+			}
+			for i, v := range tt.violations {
+				if policyErr.Violations[i] != v {
+					t.Errorf("expected violation %+v, got %+v", v, policyErr.Violations[i])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,5 @@
+//go:build !race
+
+package passgen
+
+const raceEnabled = false
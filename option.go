@@ -1,5 +1,10 @@
 package passgen
 
+import (
+	"crypto/rand"
+	"io"
+)
+
 type Option func(*config)
 
 func WithLength(n int) Option {
@@ -8,6 +13,14 @@ func WithLength(n int) Option {
 	}
 }
 
+// WithMinEntropy derives the password length as the shortest one whose
+// keyspace reaches bits of entropy. It takes precedence over WithLength.
+func WithMinEntropy(bits float64) Option {
+	return func(c *config) {
+		c.minEntropy = bits
+	}
+}
+
 func WithUppercase() Option {
 	return func(c *config) {
 		c.useUppercase = true
@@ -88,3 +101,163 @@ func WithMinRequirements(upper, lower, digits, symbols int) Option {
 		c.minSymbols = symbols
 	}
 }
+
+// WithMaxUppercase caps the number of uppercase letters. Zero lifts the cap.
+func WithMaxUppercase(n int) Option {
+	return func(c *config) {
+		c.maxUppercase = n
+	}
+}
+
+// WithMaxLowercase caps the number of lowercase letters. Zero lifts the cap.
+func WithMaxLowercase(n int) Option {
+	return func(c *config) {
+		c.maxLowercase = n
+	}
+}
+
+// WithMaxDigits caps the number of digits. Zero lifts the cap.
+func WithMaxDigits(n int) Option {
+	return func(c *config) {
+		c.maxDigits = n
+	}
+}
+
+// WithMaxSymbols caps the number of symbols. Zero lifts the cap.
+func WithMaxSymbols(n int) Option {
+	return func(c *config) {
+		c.maxSymbols = n
+	}
+}
+
+// WithClassWeights weights the characters drawn after the minimums are met:
+// each character of a class is as likely as its weight relative to the
+// others, and characters of custom charsets have weight 1. Equal weights
+// give the default uniform fill; WithClassWeights(1, 8, 1, 0.5) favours
+// lowercase letters. Entropy accounts for the weighting.
+func WithClassWeights(upper, lower, digit, symbol float64) Option {
+	return func(c *config) {
+		c.weights = []float64{upper, lower, digit, symbol}
+	}
+}
+
+// WithCharset adds a named character class to the pool. At least min
+// characters of the password are drawn from it. Adding a charset with an
+// existing name replaces it.
+func WithCharset(name string, cs Charset, min int) Option {
+	return func(c *config) {
+		class := charClass{name: name, chars: cs.Runes(), min: min}
+
+		for i := range c.charsets {
+			if c.charsets[i].name == name {
+				c.charsets[i] = class
+				return
+			}
+		}
+
+		c.charsets = append(c.charsets, class)
+	}
+}
+
+func WithoutAmbiguous() Option {
+	return func(c *config) {
+		c.excludeAmbiguous = true
+	}
+}
+
+func WithAmbiguous() Option {
+	return func(c *config) {
+		c.excludeAmbiguous = false
+	}
+}
+
+// WithAmbiguousChars replaces the look-alike table used by WithoutAmbiguous.
+func WithAmbiguousChars(cs Charset) Option {
+	return func(c *config) {
+		c.ambiguous = cs
+	}
+}
+
+// WithPronounceable generates letters that alternate between consonants and
+// vowels. Only the minimum requirements of digits, symbols and custom
+// charsets are inserted, at random positions; with both cases enabled exactly
+// the minimum number of letters is capitalized.
+func WithPronounceable() Option {
+	return func(c *config) {
+		c.pronounceable = true
+	}
+}
+
+// WithMask fixes the class of every position with a hashcat-style mask such
+// as "?u?l?l?l?d?d": ?u, ?l, ?d and ?s draw from the built-in classes, ?a from
+// every enabled class, ?{name} from a custom charset and ?? is a literal '?'.
+// Other characters are copied as-is. The mask determines the length and
+// cannot be combined with minimum requirements.
+func WithMask(mask string) Option {
+	return func(c *config) {
+		c.mask = mask
+	}
+}
+
+// WithFirstChar requires the first character to come from one of the named
+// classes: ClassUppercase, ClassLowercase, ClassDigits, ClassSymbols or the
+// name of a custom charset. Calling it without names lifts the constraint.
+func WithFirstChar(classes ...string) Option {
+	return func(c *config) {
+		c.firstChar = classes
+	}
+}
+
+// WithLastChar is like WithFirstChar for the last character.
+func WithLastChar(classes ...string) Option {
+	return func(c *config) {
+		c.lastChar = classes
+	}
+}
+
+// WithMaxConsecutive forbids runs of more than n identical characters, such as
+// "aaa" for n = 2. Zero lifts the limit.
+func WithMaxConsecutive(n int) Option {
+	return func(c *config) {
+		c.maxConsecutive = n
+	}
+}
+
+// WithMaxSequential forbids runs of more than n characters with consecutive
+// code points in either direction, such as "abc" or "321" for n = 2. Zero
+// lifts the limit.
+func WithMaxSequential(n int) Option {
+	return func(c *config) {
+		c.maxSequential = n
+	}
+}
+
+// WithNoRepeats makes every character of the password unique. The length
+// cannot exceed the number of available characters.
+func WithNoRepeats() Option {
+	return func(c *config) {
+		c.noRepeats = true
+	}
+}
+
+// WithBreachFilter rejects and redraws generated passwords the filter
+// contains, and makes Validate report passwords it contains. Nil removes the
+// filter. The filter must be safe for concurrent use if the generator is
+// shared between goroutines.
+func WithBreachFilter(f BreachFilter) Option {
+	return func(c *config) {
+		c.breach = f
+	}
+}
+
+// WithRandReader replaces crypto/rand as the source of randomness; nil
+// restores crypto/rand. The reader must be safe for concurrent use if the
+// generator is shared between goroutines.
+func WithRandReader(r io.Reader) Option {
+	return func(c *config) {
+		if r == nil {
+			r = rand.Reader
+		}
+		c.rand = r
+	}
+}
@@ -1,10 +1,13 @@
 package passgen
 
 import (
-	"crypto/rand"
 	"encoding/binary"
 	"fmt"
-	"strings"
+	"io"
+	"math/big"
+	"math/bits"
+	"sync"
+	"unicode/utf8"
 )
 
 var (
@@ -49,8 +52,29 @@ var (
 )
 
 type Generator struct {
-	cfg     *config
-	charset []rune
+	cfg       *config
+	classes   []charClass
+	charset   []rune
+	syllables *syllables
+	mask      [][]rune
+	anchored  *anchored
+	sampler   *sampler
+	weighting *weighting
+	// width is the largest UTF-8 encoded size of a password character.
+	width   int
+	scratch sync.Pool
+	// repetition builds the exact repetition sampler the first time redrawing
+	// fails to meet the repetition rules.
+	repetition func() *repetitionSampler
+	// neighbours reports whether passwords meet WithMaxConsecutive(1) more
+	// often when drawn without two equal neighbours and checked against the
+	// classes than the other way round.
+	neighbours func() bool
+	keyspace   func() *big.Int
+	// log2Keyspace is log2 of keyspace, computed without the exact count
+	// where that is expensive.
+	log2Keyspace func() float64
+	entropy      func() float64
 }
 
 func NewGenerator(opts ...Option) (*Generator, error) {
@@ -64,24 +88,77 @@ func NewGenerator(opts ...Option) (*Generator, error) {
 		return nil, fmt.Errorf("failed to validate generator: %w", err)
 	}
 
+	classes := cfg.classes()
+
 	charset := make([]rune, 0, charsLength)
-	if cfg.useUppercase {
-		charset = append(charset, uppers...)
+	for _, class := range classes {
+		charset = append(charset, class.chars...)
 	}
-	if cfg.useLowercase {
-		charset = append(charset, lowers...)
+
+	var s *syllables
+	if cfg.pronounceable {
+		s = newSyllables(cfg, classes)
 	}
-	if cfg.useDigits {
-		charset = append(charset, digits...)
+
+	var mask [][]rune
+	if cfg.mask != "" {
+		// The mask has already been checked by validate.
+		mask, _ = parseMask(cfg.mask, classes)
 	}
-	if cfg.useSymbols {
-		charset = append(charset, symbols...)
+
+	var anchored *anchored
+	if a, _ := cfg.anchors(classes); a != nil {
+		anchored = newAnchored(a, classes, cfg.length)
 	}
 
-	return &Generator{
-		cfg:     cfg,
-		charset: charset,
-	}, nil
+	var w *weighting
+	if cfg.weights != nil {
+		w = newWeighting(classes, cfg.classWeights(classes))
+	}
+
+	width := utf8Width(charset)
+	for _, chars := range mask {
+		width = max(width, utf8Width(chars))
+	}
+
+	g := &Generator{
+		cfg:       cfg,
+		classes:   classes,
+		charset:   charset,
+		syllables: s,
+		mask:      mask,
+		anchored:  anchored,
+		sampler:   newSampler(classes, cfg.length, cfg.noRepeats),
+		weighting: w,
+		width:     width,
+		repetition: sync.OnceValue(func() *repetitionSampler {
+			return newRepetitionSampler(classes, cfg.allowed(classes, cfg.length), cfg.length, cfg.maxConsecutive, cfg.maxSequential)
+		}),
+		neighbours: sync.OnceValue(func() bool {
+			if cfg.maxConsecutive != 1 || cfg.maxSequential != 0 {
+				return false
+			}
+			draw, neighbours, ok := cfg.repetitionShares(classes)
+			return ok && neighbours > draw
+		}),
+		keyspace: sync.OnceValue(func() *big.Int {
+			return cfg.counter(classes)(cfg.length)
+		}),
+		log2Keyspace: sync.OnceValue(func() float64 {
+			return cfg.log2Counter(classes)(cfg.length)
+		}),
+	}
+	g.entropy = g.log2Keyspace
+	if cfg.weights != nil {
+		g.entropy = sync.OnceValue(func() float64 {
+			return cfg.entropy(classes)(cfg.length)
+		})
+	}
+	g.scratch.New = func() any {
+		return &scratch{pass: make([]rune, cfg.length), src: newSource(nil, sourceSize(cfg.length))}
+	}
+
+	return g, nil
 }
 
 func Generate(opts ...Option) (string, error) {
@@ -93,94 +170,314 @@ func Generate(opts ...Option) (string, error) {
 	return gen.Generate()
 }
 
+// Charset returns every character the generator's passwords may contain.
+func (g *Generator) Charset() Charset {
+	c := NewCharset(g.charset...)
+	if g.mask != nil {
+		c = NewCharset()
+		for _, chars := range g.mask {
+			c = c.Union(NewCharset(chars...))
+		}
+	}
+
+	return c
+}
+
 func (g *Generator) Generate() (string, error) {
-	var rawPass strings.Builder
-	cfg := g.cfg
+	s := g.getScratch()
+	defer g.putScratch(s)
 
-	if cfg.minUppercase > 0 {
-		entry, err := generatePassEntry(uppers, cfg.minUppercase)
-		if err != nil {
-			return "", fmt.Errorf("failed to generate password entry: %w", err)
-		}
+	if err := g.generate(s.src, s.pass); err != nil {
+		return "", err
+	}
 
-		rawPass.WriteString(entry)
+	return string(s.pass), nil
+}
+
+// GenerateBytes is like Generate but returns the password as UTF-8 encoded
+// bytes, which, unlike a string, the caller can zero once done with it.
+func (g *Generator) GenerateBytes() ([]byte, error) {
+	buf := make([]byte, g.cfg.length*g.width)
+
+	n, err := g.GenerateInto(buf)
+	if err != nil {
+		return nil, err
 	}
 
-	if cfg.minLowercase > 0 {
-		entry, err := generatePassEntry(lowers, cfg.minLowercase)
-		if err != nil {
-			return "", fmt.Errorf("failed to generate password entry: %w", err)
-		}
+	return buf[:n], nil
+}
+
+// GenerateInto writes a new password into dst as UTF-8 and returns the
+// number of bytes written. dst must hold the configured length times the
+// widest character of the pool, which is the length itself for ASCII
+// charsets. It does not allocate for plain character-class passwords; first
+// and last character constraints, pronounceable passwords, breach filters and
+// requirements that random draws rarely meet need working memory.
+func (g *Generator) GenerateInto(dst []byte) (int, error) {
+	if need := g.cfg.length * g.width; len(dst) < need {
+		return 0, fmt.Errorf("buffer too small: need %d bytes, got %d", need, len(dst))
+	}
+
+	s := g.getScratch()
+	defer g.putScratch(s)
+
+	if err := g.generate(s.src, s.pass); err != nil {
+		return 0, err
+	}
+
+	n := 0
+	for _, c := range s.pass {
+		n += utf8.EncodeRune(dst[n:], c)
+	}
+
+	return n, nil
+}
+
+// scratch holds the buffers a single password is generated in. Generators
+// keep them in a pool so the hot path does not allocate.
+type scratch struct {
+	pass []rune
+	src  *source
+}
+
+func (g *Generator) getScratch() *scratch {
+	s := g.scratch.Get().(*scratch)
+	s.src.reset(g.cfg.rand)
 
-		rawPass.WriteString(entry)
+	return s
+}
+
+// putScratch wipes the password and the random bytes it was drawn from before
+// releasing the scratch.
+func (g *Generator) putScratch(s *scratch) {
+	clear(s.pass)
+	s.src.reset(nil)
+	g.scratch.Put(s)
+}
+
+// sourceSize returns the buffer size of the source a password of the given
+// length is drawn from: about three bytes per character cover the character
+// and its shuffle swap in a single read for typical charsets.
+func sourceSize(length int) int {
+	return min(max(3*length, 64), 4096)
+}
+
+// generate fills pass, which must have the configured length, with a new
+// password drawn from r. Passwords the breach filter contains are redrawn.
+func (g *Generator) generate(r io.Reader, pass []rune) error {
+	if g.cfg.breach == nil {
+		return g.drawRepetition(r, pass)
 	}
 
-	if cfg.minDigits > 0 {
-		entry, err := generatePassEntry(digits, cfg.minDigits)
+	for range maxBreachAttempts {
+		if err := g.drawRepetition(r, pass); err != nil {
+			return err
+		}
+
+		found, err := g.breached(pass)
 		if err != nil {
-			return "", fmt.Errorf("failed to generate password entry: %w", err)
+			return err
+		}
+		if !found {
+			return nil
 		}
+	}
 
-		rawPass.WriteString(entry)
+	return fmt.Errorf("failed to generate a password outside the breach corpus after %d attempts", maxBreachAttempts)
+}
+
+// drawRepetition draws pass and redraws it while it breaks a repetition
+// rule. For WithMaxConsecutive(1) it may instead draw pass without two equal
+// neighbours and redraw it while it breaks a class requirement. Once
+// redrawing fails, pass is drawn from the exact repetition sampler. Either way
+// every password meeting the rules is equally likely.
+func (g *Generator) drawRepetition(r io.Reader, pass []rune) error {
+	if g.cfg.maxConsecutive == 0 && g.cfg.maxSequential == 0 {
+		return g.draw(r, pass)
 	}
 
-	if cfg.minSymbols > 0 {
-		entry, err := generatePassEntry(symbols, cfg.minSymbols)
-		if err != nil {
-			return "", fmt.Errorf("failed to generate password entry: %w", err)
+	draw, check := g.draw, g.checkRepetition
+	if g.neighbours() {
+		draw = func(r io.Reader, pass []rune) error {
+			return drawNeighbours(r, pass, g.charset)
+		}
+		check = g.checkClasses
+	}
+
+	for i := range maxRepetitionRedraws {
+		if i == maxRepetitionAttempts {
+			if s := g.repetition(); s != nil {
+				if err := s.draw(r, pass); err != nil {
+					return fmt.Errorf("failed to satisfy the repetition rules: %w", err)
+				}
+				return nil
+			}
 		}
 
-		rawPass.WriteString(entry)
+		if err := draw(r, pass); err != nil {
+			return err
+		}
+		if len(check(pass)) == 0 {
+			return nil
+		}
 	}
 
-	remaining := cfg.length - rawPass.Len()
+	return fmt.Errorf("failed to satisfy the repetition rules after %d attempts", maxRepetitionRedraws)
+}
 
-	if remaining > 0 {
-		entry, err := generatePassEntry(g.charset, remaining)
-		if err != nil {
-			return "", fmt.Errorf("failed to generate password entry: %w", err)
+func (g *Generator) draw(r io.Reader, pass []rune) error {
+	if g.syllables != nil {
+		return g.syllables.generate(r, pass)
+	}
+	if g.mask != nil {
+		return generateMasked(r, pass, g.mask)
+	}
+	if g.anchored != nil {
+		return g.generateAnchored(r, pass)
+	}
+
+	return g.fill(r, pass, g.sampler)
+}
+
+// fill draws pass uniformly from the strings that meet the minimums and
+// maximums of the sampler's classes. With class weights, it instead meets
+// the minimums, draws the remaining characters with their weights and
+// shuffles the result.
+func (g *Generator) fill(r io.Reader, pass []rune, s *sampler) error {
+	if g.weighting == nil {
+		if err := s.draw(r, pass); err != nil {
+			return fmt.Errorf("failed to generate password entry: %w", err)
 		}
 
-		rawPass.WriteString(entry)
+		return nil
 	}
 
-	pass, err := shuffleString(rawPass.String())
+	if err := drawWeighted(r, pass, s.classes, g.weighting); err != nil {
+		return fmt.Errorf("failed to generate password entry: %w", err)
+	}
+
+	if err := shuffleRunes(r, pass); err != nil {
+		return fmt.Errorf("failed to shuffle password: %w", err)
+	}
+
+	return nil
+}
+
+// drawWeighted is drawClasses with weighted fill characters.
+func drawWeighted(r io.Reader, dst []rune, classes []charClass, w *weighting) error {
+	filled, err := drawMinimums(r, dst, classes)
 	if err != nil {
-		return "", fmt.Errorf("failed to shuffle password: %w", err)
+		return err
 	}
 
-	return pass, nil
+	return w.draw(r, dst[filled:])
 }
 
-func generatePassEntry(charset []rune, count int) (string, error) {
-	var sb strings.Builder
-	buf := make([]byte, 8)
+// drawClasses fills dst with the minimum of every class followed by
+// characters drawn from pool.
+func drawClasses(r io.Reader, dst []rune, classes []charClass, pool []rune) error {
+	filled, err := drawMinimums(r, dst, classes)
+	if err != nil {
+		return err
+	}
+
+	return generatePassEntry(r, dst[filled:], pool)
+}
+
+// drawMinimums fills the start of dst with the minimum of every class and
+// returns the number of characters drawn.
+func drawMinimums(r io.Reader, dst []rune, classes []charClass) (int, error) {
+	filled := 0
+
+	for _, class := range classes {
+		if class.min == 0 {
+			continue
+		}
 
-	for range count {
-		if _, err := rand.Read(buf); err != nil {
-			return "", fmt.Errorf("failed to read random bytes: %w", err)
+		if err := generatePassEntry(r, dst[filled:filled+class.min], class.chars); err != nil {
+			return 0, err
 		}
 
-		idx := binary.LittleEndian.Uint64(buf) % uint64(len(charset))
-		sb.WriteRune(charset[idx])
+		filled += class.min
 	}
 
-	return sb.String(), nil
+	return filled, nil
 }
 
-func shuffleString(s string) (string, error) {
-	runes := []rune(s)
-	buf := make([]byte, 8)
+// generatePassEntry fills dst with characters drawn from charset.
+func generatePassEntry(r io.Reader, dst []rune, charset []rune) error {
+	for i := range dst {
+		idx, err := randomIndex(r, len(charset))
+		if err != nil {
+			return err
+		}
+
+		dst[i] = charset[idx]
+	}
 
+	return nil
+}
+
+func shuffleRunes(r io.Reader, runes []rune) error {
 	// Fisher–Yates shuffle
 	for i := len(runes) - 1; i > 0; i-- {
-		if _, err := rand.Read(buf); err != nil {
-			return "", fmt.Errorf("failed to read random bytes: %w", err)
+		j, err := randomIndex(r, i+1)
+		if err != nil {
+			return err
 		}
 
-		j := int(binary.LittleEndian.Uint64(buf) % uint64(i+1))
 		runes[i], runes[j] = runes[j], runes[i]
 	}
 
-	return string(runes), nil
+	return nil
+}
+
+// randomIndex returns a uniformly distributed integer in [0, n).
+func randomIndex(r io.Reader, n int) (int, error) {
+	if s, ok := r.(*source); ok {
+		return s.index(n)
+	}
+
+	return uniformIndex(func() (uint64, error) { return randomUint64(r) }, n)
+}
+
+func randomUint64(r io.Reader) (uint64, error) {
+	if s, ok := r.(*source); ok {
+		return s.uint64()
+	}
+
+	var buf [8]byte
+	if _, err := io.ReadFull(r, buf[:]); err != nil {
+		return 0, fmt.Errorf("failed to read random bytes: %w", err)
+	}
+
+	return binary.LittleEndian.Uint64(buf[:]), nil
+}
+
+// uniformIndex maps random 64-bit values from next to [0, n) without modulo
+// bias using Lemire's multiply-and-reject method: the high word of v*n is the
+// candidate, and the low word tells whether v fell into the short final
+// bucket that would make some results more likely than others.
+//
+// D. Lemire, "Fast Random Integer Generation in an Interval", ACM TOMACS 29(1), 2019.
+func uniformIndex(next func() (uint64, error), n int) (int, error) {
+	bound := uint64(n)
+
+	v, err := next()
+	if err != nil {
+		return 0, err
+	}
+
+	hi, lo := bits.Mul64(v, bound)
+	if lo < bound {
+		threshold := -bound % bound
+		for lo < threshold {
+			if v, err = next(); err != nil {
+				return 0, err
+			}
+			hi, lo = bits.Mul64(v, bound)
+		}
+	}
+
+	return int(hi), nil
 }
@@ -1,6 +1,9 @@
 package passgen
 
 import (
+	"bytes"
+	"errors"
+	"io"
 	"math"
 	"regexp"
 	"slices"
@@ -114,6 +117,33 @@ func TestNewGenerator(t *testing.T) {
 			options:     []Option{WithLength(20), WithMinRequirements(2, 2, 2, 2)},
 			expectError: false,
 		},
+		{
+			name:        "custom charset",
+			options:     []Option{WithoutSymbols(), WithCharset("safe", CharsetFromString("!#%"), 2)},
+			expectError: false,
+		},
+		{
+			name:        "custom charset overlapping builtin",
+			options:     []Option{WithCharset("vowels", CharsetFromString("aeiou"), 1)},
+			expectError: true,
+			errorMsg:    `charset "vowels" overlaps with "lowercase"`,
+		},
+		{
+			name:        "only custom charset",
+			options:     []Option{WithoutUppercase(), WithoutLowercase(), WithoutDigits(), WithoutSymbols(), WithCharset("hex", CharsetFromString("0123456789abcdef"), 0)},
+			expectError: false,
+		},
+		{
+			name:        "without ambiguous",
+			options:     []Option{WithoutAmbiguous(), WithMinRequirements(2, 2, 2, 2)},
+			expectError: false,
+		},
+		{
+			name:        "without ambiguous empties required class",
+			options:     []Option{WithoutAmbiguous(), WithAmbiguousChars(Digits), WithMinDigits(1)},
+			expectError: true,
+			errorMsg:    `charset "digits" is empty after excluding ambiguous characters but minimum requirement is 1`,
+		},
 	}
 
 	for _, tt := range tests {
@@ -353,6 +383,181 @@ func TestGenerate(t *testing.T) {
 	}
 }
 
+func TestGenerateWithCharset(t *testing.T) {
+	tests := []struct {
+		name           string
+		options        []Option
+		expectedLength int
+		allowed        Charset
+		minimums       map[string]int
+	}{
+		{
+			name:           "custom symbols",
+			options:        []Option{WithLength(20), WithoutSymbols(), WithCharset("safe", CharsetFromString("!#%"), 3)},
+			expectedLength: 20,
+			allowed:        Uppercase.Union(Lowercase, Digits, CharsetFromString("!#%")),
+			minimums:       map[string]int{"!#%": 3},
+		},
+		{
+			name: "only custom charsets",
+			options: []Option{
+				WithLength(12), WithoutUppercase(), WithoutLowercase(), WithoutDigits(), WithoutSymbols(),
+				WithCharset("hex", CharsetFromString("0123456789abcdef"), 4),
+				WithCharset("dash", CharsetFromString("-"), 2),
+			},
+			expectedLength: 12,
+			allowed:        CharsetFromString("0123456789abcdef-"),
+			minimums:       map[string]int{"0123456789abcdef": 4, "-": 2},
+		},
+		{
+			name: "non ascii charset",
+			options: []Option{
+				WithLength(10), WithoutUppercase(), WithoutDigits(), WithoutSymbols(),
+				WithCharset("cyrillic", CharsetFromRange('а', 'я'), 5),
+			},
+			expectedLength: 10,
+			allowed:        Lowercase.Union(CharsetFromRange('а', 'я')),
+			minimums:       map[string]int{"абвгдежзийклмнопрстуфхцчшщъыьэюя": 5},
+		},
+		{
+			name: "last charset with same name wins",
+			options: []Option{
+				WithLength(8), WithoutUppercase(), WithoutLowercase(), WithoutDigits(), WithoutSymbols(),
+				WithCharset("set", CharsetFromString("abc"), 1),
+				WithCharset("set", CharsetFromString("xyz"), 1),
+			},
+			expectedLength: 8,
+			allowed:        CharsetFromString("xyz"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gen, err := NewGenerator(tt.options...)
+			if err != nil {
+				t.Fatalf("failed to create generator: %v", err)
+			}
+
+			for range 100 {
+				password, err := gen.Generate()
+				if err != nil {
+					t.Fatalf("failed to generate password: %v", err)
+				}
+
+				runes := []rune(password)
+				if len(runes) != tt.expectedLength {
+					t.Errorf("expected length %d, got %d", tt.expectedLength, len(runes))
+				}
+
+				for _, r := range runes {
+					if !tt.allowed.Contains(r) {
+						t.Errorf("password %q contains unexpected character %q", password, r)
+					}
+				}
+
+				for chars, minimum := range tt.minimums {
+					cs := CharsetFromString(chars)
+					count := 0
+					for _, r := range runes {
+						if cs.Contains(r) {
+							count++
+						}
+					}
+					if count < minimum {
+						t.Errorf("expected at least %d characters from %q, got %d in password: %s", minimum, chars, count, password)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestGenerateWithoutAmbiguous(t *testing.T) {
+	tests := []struct {
+		name     string
+		options  []Option
+		excluded Charset
+	}{
+		{
+			name:     "default table",
+			options:  []Option{WithLength(64), WithoutAmbiguous(), WithMinRequirements(4, 4, 4, 4)},
+			excluded: Ambiguous,
+		},
+		{
+			name:     "custom table",
+			options:  []Option{WithLength(64), WithAmbiguousChars(CharsetFromString("aeiou")), WithoutAmbiguous()},
+			excluded: CharsetFromString("aeiou"),
+		},
+		{
+			name: "custom charset",
+			options: []Option{
+				WithLength(32), WithoutUppercase(), WithoutLowercase(), WithoutSymbols(), WithoutAmbiguous(),
+				WithCharset("quotes", CharsetFromString("'\"`~"), 4),
+			},
+			excluded: Ambiguous,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gen, err := NewGenerator(tt.options...)
+			if err != nil {
+				t.Fatalf("failed to create generator: %v", err)
+			}
+
+			for range 100 {
+				password, err := gen.Generate()
+				if err != nil {
+					t.Fatalf("failed to generate password: %v", err)
+				}
+
+				for _, r := range password {
+					if tt.excluded.Contains(r) {
+						t.Errorf("password %q contains excluded character %q", password, r)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestGeneratorCharset(t *testing.T) {
+	tests := []struct {
+		name     string
+		options  []Option
+		expected string
+	}{
+		{
+			name:     "digits",
+			options:  []Option{WithoutUppercase(), WithoutLowercase(), WithoutSymbols()},
+			expected: "0123456789",
+		},
+		{
+			name:     "custom charset without ambiguous",
+			options:  []Option{WithoutUppercase(), WithoutLowercase(), WithoutSymbols(), WithoutAmbiguous(), WithCharset("greek", CharsetFromString("βα"), 0)},
+			expected: "2346789αβ",
+		},
+		{
+			name:     "mask with a literal",
+			options:  []Option{WithMask("?d-?d")},
+			expected: "-0123456789",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gen, err := NewGenerator(tt.options...)
+			if err != nil {
+				t.Fatalf("failed to create generator: %v", err)
+			}
+
+			if got := gen.Charset().String(); got != tt.expected {
+				t.Errorf("expected charset %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
+
 func TestGeneratorReuse(t *testing.T) {
 	tests := []struct {
 		name           string
@@ -484,42 +689,55 @@ func TestConcurrentGeneration(t *testing.T) {
 	}
 }
 
-func BenchmarkGenerate(b *testing.B) {
-	benchmarks := []struct {
-		name    string
-		options []Option
-	}{
-		{
-			name:    "default",
-			options: nil,
-		},
-		{
-			name:    "short_password",
-			options: []Option{WithLength(8)},
-		},
-		{
-			name:    "long_password",
-			options: []Option{WithLength(64)},
-		},
-		{
-			name:    "no_symbols",
-			options: []Option{WithLength(16), WithoutSymbols()},
-		},
-		{
-			name:    "digits_only",
-			options: []Option{WithLength(16), WithoutUppercase(), WithoutLowercase(), WithoutSymbols()},
-		},
-		{
-			name:    "with_min_requirements",
-			options: []Option{WithLength(20), WithMinRequirements(3, 3, 3, 3)},
-		},
-		{
-			name:    "complex_requirements",
-			options: []Option{WithLength(32), WithMinUppercase(5), WithMinLowercase(5), WithMinDigits(5), WithMinSymbols(5)},
-		},
-	}
+var generateBenchmarks = []struct {
+	name    string
+	options []Option
+}{
+	{
+		name:    "default",
+		options: nil,
+	},
+	{
+		name:    "short_password",
+		options: []Option{WithLength(8)},
+	},
+	{
+		name:    "long_password",
+		options: []Option{WithLength(64)},
+	},
+	{
+		name:    "no_symbols",
+		options: []Option{WithLength(16), WithoutSymbols()},
+	},
+	{
+		name:    "digits_only",
+		options: []Option{WithLength(16), WithoutUppercase(), WithoutLowercase(), WithoutSymbols()},
+	},
+	{
+		name:    "with_min_requirements",
+		options: []Option{WithLength(20), WithMinRequirements(3, 3, 3, 3)},
+	},
+	{
+		name:    "complex_requirements",
+		options: []Option{WithLength(32), WithMinUppercase(5), WithMinLowercase(5), WithMinDigits(5), WithMinSymbols(5)},
+	},
+	{
+		name:    "very_long_password",
+		options: []Option{WithLength(1024)},
+	},
+	{
+		name:    "heavy_minimums",
+		options: []Option{WithLength(2000), WithMinRequirements(500, 500, 500, 500)},
+	},
+	{
+		name:    "half_digits",
+		options: []Option{WithLength(10000), WithMinDigits(5000)},
+	},
+}
 
-	for _, bm := range benchmarks {
+// BenchmarkGenerate includes building the generator on every call.
+func BenchmarkGenerate(b *testing.B) {
+	for _, bm := range generateBenchmarks {
 		b.Run(bm.name, func(b *testing.B) {
 			b.ResetTimer()
 			for i := 0; i < b.N; i++ {
@@ -532,6 +750,40 @@ func BenchmarkGenerate(b *testing.B) {
 	}
 }
 
+// BenchmarkGeneratorGenerate reuses one generator, as long-running services do.
+func BenchmarkGeneratorGenerate(b *testing.B) {
+	for _, bm := range generateBenchmarks {
+		b.Run(bm.name, func(b *testing.B) {
+			gen, err := NewGenerator(bm.options...)
+			if err != nil {
+				b.Fatalf("failed to create generator: %v", err)
+			}
+
+			b.ReportAllocs()
+			for b.Loop() {
+				if _, err := gen.Generate(); err != nil {
+					b.Fatalf("failed to generate password: %v", err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkGenerateInto(b *testing.B) {
+	gen, err := NewGenerator(WithLength(20), WithMinRequirements(3, 3, 3, 3))
+	if err != nil {
+		b.Fatalf("failed to create generator: %v", err)
+	}
+
+	buf := make([]byte, 20)
+	b.ReportAllocs()
+	for b.Loop() {
+		if _, err := gen.GenerateInto(buf); err != nil {
+			b.Fatalf("failed to generate password: %v", err)
+		}
+	}
+}
+
 func TestStatisticalUniformity(t *testing.T) {
 	tests := []struct {
 		name           string
@@ -646,3 +898,396 @@ func TestStatisticalUniformity(t *testing.T) {
 		})
 	}
 }
+
+func TestUniformIndex(t *testing.T) {
+	tests := []struct {
+		name          string
+		n             int
+		values        []uint64
+		expected      int
+		expectedReads int
+	}{
+		{
+			name:          "power of two never rejects",
+			n:             8,
+			values:        []uint64{0},
+			expected:      0,
+			expectedReads: 1,
+		},
+		{
+			name:          "top of range",
+			n:             26,
+			values:        []uint64{math.MaxUint64},
+			expected:      25,
+			expectedReads: 1,
+		},
+		{
+			// 2^64 mod 3 = 1, so exactly one value (0) lands in the short bucket.
+			name:          "rejects biased value for n=3",
+			n:             3,
+			values:        []uint64{0, 1 << 63},
+			expected:      1,
+			expectedReads: 2,
+		},
+		{
+			// 2^64 mod 26 = 16: values whose low product word is below 16 are rejected.
+			name:          "rejects biased values for n=26",
+			n:             26,
+			values:        []uint64{0, 709490156681136601, 1<<63 + 1},
+			expected:      13,
+			expectedReads: 3,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reads := 0
+			next := func() (uint64, error) {
+				v := tt.values[reads]
+				reads++
+				return v, nil
+			}
+
+			got, err := uniformIndex(next, tt.n)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.expected {
+				t.Errorf("expected %d, got %d", tt.expected, got)
+			}
+			if reads != tt.expectedReads {
+				t.Errorf("expected %d reads, got %d", tt.expectedReads, reads)
+			}
+		})
+	}
+}
+
+// chiSquareZ is the standard normal quantile the chi-square tests compare
+// against. 5.2 keeps the false positive rate of each test around 1e-7.
+const chiSquareZ = 5.2
+
+// chiSquareCritical approximates the upper critical value of the chi-square
+// distribution with df degrees of freedom for the standard normal quantile z
+// (Wilson–Hilferty).
+func chiSquareCritical(df int, z float64) float64 {
+	k := float64(df)
+	return k * math.Pow(1-2/(9*k)+z*math.Sqrt(2/(9*k)), 3)
+}
+
+func TestPerPositionUniformity(t *testing.T) {
+
+	tests := []struct {
+		name    string
+		options []Option
+		length  int
+		chars   []rune
+	}{
+		{
+			name:    "uppercase",
+			options: []Option{WithLength(8), WithoutLowercase(), WithoutDigits(), WithoutSymbols()},
+			length:  8,
+			chars:   uppers,
+		},
+		{
+			name:    "symbols",
+			options: []Option{WithLength(8), WithoutUppercase(), WithoutLowercase(), WithoutDigits()},
+			length:  8,
+			chars:   symbols,
+		},
+		{
+			name: "three characters",
+			options: []Option{
+				WithLength(6), WithoutUppercase(), WithoutLowercase(), WithoutDigits(), WithoutSymbols(),
+				WithCharset("abc", CharsetFromString("abc"), 0),
+			},
+			length: 6,
+			chars:  []rune("abc"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gen, err := NewGenerator(tt.options...)
+			if err != nil {
+				t.Fatalf("failed to create generator: %v", err)
+			}
+
+			const numPasswords = 20000
+			counts := make([]map[rune]int, tt.length)
+			for i := range counts {
+				counts[i] = make(map[rune]int)
+			}
+
+			for range numPasswords {
+				password, err := gen.Generate()
+				if err != nil {
+					t.Fatalf("failed to generate password: %v", err)
+				}
+				for i, r := range []rune(password) {
+					counts[i][r]++
+				}
+			}
+
+			expected := float64(numPasswords) / float64(len(tt.chars))
+			critical := chiSquareCritical(len(tt.chars)-1, chiSquareZ)
+
+			for pos, count := range counts {
+				chi2 := 0.0
+				for _, r := range tt.chars {
+					d := float64(count[r]) - expected
+					chi2 += d * d / expected
+				}
+				if chi2 > critical {
+					t.Errorf("position %d: chi-square %.2f exceeds critical value %.2f", pos, chi2, critical)
+				}
+			}
+		})
+	}
+}
+
+type counterReader struct {
+	next byte
+}
+
+func (r *counterReader) Read(p []byte) (int, error) {
+	for i := range p {
+		p[i] = r.next
+		r.next += 37
+	}
+
+	return len(p), nil
+}
+
+type errReader struct {
+	err error
+}
+
+func (r errReader) Read(p []byte) (int, error) {
+	return 0, r.err
+}
+
+func TestWithRandReader(t *testing.T) {
+	tests := []struct {
+		name        string
+		options     []Option
+		reader      io.Reader
+		expected    string
+		expectError bool
+		errorMsg    string
+	}{
+		{
+			name:     "deterministic default",
+			reader:   &counterReader{},
+			expected: "A-o(yuD|0SSG>#qn",
+		},
+		{
+			name:     "deterministic with min requirements",
+			options:  []Option{WithLength(12), WithMinRequirements(2, 2, 2, 2)},
+			reader:   &counterReader{},
+			expected: "YI7_10bAV,Ve",
+		},
+		{
+			name:     "deterministic digits only",
+			options:  []Option{WithLength(6), WithoutUppercase(), WithoutLowercase(), WithoutSymbols()},
+			reader:   &counterReader{},
+			expected: "005246",
+		},
+		{
+			name:        "reader error",
+			reader:      errReader{err: errors.New("device unplugged")},
+			expectError: true,
+			errorMsg:    "failed to read random bytes: device unplugged",
+		},
+		{
+			name:        "short read",
+			reader:      io.LimitReader(&counterReader{}, 5),
+			expectError: true,
+			errorMsg:    "failed to read random bytes: unexpected EOF",
+		},
+		{
+			name:        "empty reader",
+			reader:      bytes.NewReader(nil),
+			expectError: true,
+			errorMsg:    "failed to read random bytes: EOF",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gen, err := NewGenerator(append(tt.options, WithRandReader(tt.reader))...)
+			if err != nil {
+				t.Fatalf("failed to create generator: %v", err)
+			}
+
+			password, err := gen.Generate()
+
+			if tt.expectError {
+				if err == nil {
+					t.Errorf("expected error but got none")
+					return
+				}
+				if !strings.Contains(err.Error(), tt.errorMsg) {
+					t.Errorf("expected error message to contain %q, got %q", tt.errorMsg, err.Error())
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if password != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, password)
+			}
+		})
+	}
+}
+
+func TestWithRandReaderNilRestoresDefault(t *testing.T) {
+	gen, err := NewGenerator(WithRandReader(errReader{err: errors.New("broken")}), WithRandReader(nil))
+	if err != nil {
+		t.Fatalf("failed to create generator: %v", err)
+	}
+
+	if _, err := gen.Generate(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestGenerateInto(t *testing.T) {
+	tests := []struct {
+		name    string
+		options []Option
+		size    int
+		length  int
+	}{
+		{
+			name:   "default",
+			size:   16,
+			length: 16,
+		},
+		{
+			name:    "larger buffer",
+			options: []Option{WithLength(8), WithMinRequirements(1, 1, 1, 1)},
+			size:    64,
+			length:  8,
+		},
+		{
+			name:    "multi-byte charset",
+			options: []Option{WithLength(6), WithCharset("greek", CharsetFromString("αβγδ"), 2)},
+			size:    12,
+			length:  6,
+		},
+		{
+			name:    "mask",
+			options: []Option{WithMask("?u?l-€?d")},
+			size:    15,
+			length:  5,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gen, err := NewGenerator(tt.options...)
+			if err != nil {
+				t.Fatalf("failed to create generator: %v", err)
+			}
+
+			buf := make([]byte, tt.size)
+			for range 100 {
+				n, err := gen.GenerateInto(buf)
+				if err != nil {
+					t.Fatalf("failed to generate password: %v", err)
+				}
+
+				password := string(buf[:n])
+				if got := len([]rune(password)); got != tt.length {
+					t.Errorf("expected length %d, got %d: %q", tt.length, got, password)
+				}
+				if err := gen.Validate(password); err != nil {
+					t.Errorf("password %q does not satisfy the policy: %v", password, err)
+				}
+			}
+		})
+	}
+}
+
+func TestGenerateIntoBufferTooSmall(t *testing.T) {
+	gen, err := NewGenerator(WithLength(6), WithCharset("greek", CharsetFromString("αβγδ"), 0))
+	if err != nil {
+		t.Fatalf("failed to create generator: %v", err)
+	}
+
+	expected := "buffer too small: need 12 bytes, got 11"
+	if _, err := gen.GenerateInto(make([]byte, 11)); err == nil || err.Error() != expected {
+		t.Errorf("expected %q, got %v", expected, err)
+	}
+}
+
+func TestUTF8Width(t *testing.T) {
+	tests := []struct {
+		name     string
+		charset  []rune
+		expected int
+	}{
+		{name: "empty", charset: nil, expected: 1},
+		{name: "ascii", charset: []rune("abc"), expected: 1},
+		{name: "mixed", charset: []rune("aé€"), expected: 3},
+		{name: "emoji", charset: []rune("a😀"), expected: 4},
+		// utf8.EncodeRune writes U+FFFD, three bytes, for invalid runes.
+		{name: "surrogate", charset: []rune{'a', 0xD800}, expected: 3},
+		{name: "beyond unicode", charset: []rune{'a', 0x110000}, expected: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := utf8Width(tt.charset); got != tt.expected {
+				t.Errorf("expected width %d, got %d", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestGenerateIntoAllocs(t *testing.T) {
+	if raceEnabled {
+		t.Skip("skipping allocation test with the race detector")
+	}
+
+	gen, err := NewGenerator(WithLength(20), WithMinRequirements(2, 2, 2, 2))
+	if err != nil {
+		t.Fatalf("failed to create generator: %v", err)
+	}
+
+	buf := make([]byte, 20)
+	allocs := testing.AllocsPerRun(100, func() {
+		if _, err := gen.GenerateInto(buf); err != nil {
+			t.Fatalf("failed to generate password: %v", err)
+		}
+	})
+	if allocs != 0 {
+		t.Errorf("expected no allocations, got %v", allocs)
+	}
+}
+
+func TestGenerateBytes(t *testing.T) {
+	gen, err := NewSeededGenerator([32]byte{}, WithLength(12))
+	if err != nil {
+		t.Fatalf("failed to create generator: %v", err)
+	}
+	same, err := NewSeededGenerator([32]byte{}, WithLength(12))
+	if err != nil {
+		t.Fatalf("failed to create generator: %v", err)
+	}
+
+	password, err := gen.GenerateBytes()
+	if err != nil {
+		t.Fatalf("failed to generate password: %v", err)
+	}
+	expected, err := same.Generate()
+	if err != nil {
+		t.Fatalf("failed to generate password: %v", err)
+	}
+
+	if string(password) != expected {
+		t.Errorf("expected %q, got %q", expected, password)
+	}
+}
@@ -0,0 +1,380 @@
+package passgen
+
+import (
+	"errors"
+	"io"
+	"math"
+	"slices"
+	"strings"
+	"testing"
+	"unicode"
+)
+
+func TestWordlists(t *testing.T) {
+	tests := []struct {
+		name     string
+		words    []string
+		expected int
+		first    string
+		last     string
+	}{
+		{
+			name:     "eff_large",
+			words:    EFFLargeWordlist(),
+			expected: 7776,
+			first:    "abacus",
+			last:     "zoom",
+		},
+		{
+			name:     "eff_short",
+			words:    EFFShortWordlist(),
+			expected: 1296,
+			first:    "aardvark",
+			last:     "zucchini",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if len(tt.words) != tt.expected {
+				t.Fatalf("expected %d words, got %d", tt.expected, len(tt.words))
+			}
+			if tt.words[0] != tt.first {
+				t.Errorf("expected first word %q, got %q", tt.first, tt.words[0])
+			}
+			if tt.words[len(tt.words)-1] != tt.last {
+				t.Errorf("expected last word %q, got %q", tt.last, tt.words[len(tt.words)-1])
+			}
+
+			cfg := defaultPassphraseConfig()
+			cfg.wordlist = tt.words
+			if err := cfg.validate(); err != nil {
+				t.Errorf("wordlist is not valid: %v", err)
+			}
+		})
+	}
+}
+
+func TestNewPassphrase(t *testing.T) {
+	tests := []struct {
+		name        string
+		options     []PassphraseOption
+		expectError bool
+		errorMsg    string
+	}{
+		{
+			name:        "default configuration",
+			options:     nil,
+			expectError: false,
+		},
+		{
+			name:        "zero words",
+			options:     []PassphraseOption{WithWordCount(0)},
+			expectError: true,
+			errorMsg:    "word count must be greater than 0, got 0",
+		},
+		{
+			name:        "too many words",
+			options:     []PassphraseOption{WithWordCount(1001)},
+			expectError: true,
+			errorMsg:    "word count must not exceed 1000, got 1001",
+		},
+		{
+			name:        "unknown capitalization",
+			options:     []PassphraseOption{WithCapitalization(Capitalization(42))},
+			expectError: true,
+			errorMsg:    "unknown capitalization style 42",
+		},
+		{
+			name:        "wordlist too short",
+			options:     []PassphraseOption{WithWordlist([]string{"only"})},
+			expectError: true,
+			errorMsg:    "wordlist must contain at least 2 words, got 1",
+		},
+		{
+			name:        "wordlist with duplicates",
+			options:     []PassphraseOption{WithWordlist([]string{"one", "two", "one"})},
+			expectError: true,
+			errorMsg:    `wordlist contains duplicate word "one"`,
+		},
+		{
+			name:        "wordlist with empty word",
+			options:     []PassphraseOption{WithWordlist([]string{"one", ""})},
+			expectError: true,
+			errorMsg:    "wordlist must not contain empty words",
+		},
+		{
+			name:        "negative digits",
+			options:     []PassphraseOption{WithInsertedDigits(-1)},
+			expectError: true,
+			errorMsg:    "inserted digits count cannot be negative, got -1",
+		},
+		{
+			name:        "negative symbols",
+			options:     []PassphraseOption{WithInsertedSymbols(-2)},
+			expectError: true,
+			errorMsg:    "inserted symbols count cannot be negative, got -2",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := NewPassphrase(tt.options...)
+
+			if tt.expectError {
+				if err == nil {
+					t.Errorf("expected error but got none")
+					return
+				}
+				if tt.errorMsg != "" && !strings.Contains(err.Error(), tt.errorMsg) {
+					t.Errorf("expected error message to contain %q, got %q", tt.errorMsg, err.Error())
+				}
+			} else {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+					return
+				}
+				if p == nil {
+					t.Error("expected non-nil passphrase generator")
+				}
+			}
+		})
+	}
+}
+
+func TestGeneratePassphrase(t *testing.T) {
+	wordlist := EFFLargeWordlist()
+
+	tests := []struct {
+		name           string
+		options        []PassphraseOption
+		separator      string
+		expectedWords  int
+		expectedDigits int
+		expectSymbols  int
+		check          func(word string) bool
+	}{
+		{
+			name:          "default",
+			options:       nil,
+			separator:     " ",
+			expectedWords: 6,
+			check:         func(word string) bool { return slices.Contains(wordlist, word) },
+		},
+		{
+			name:          "custom separator and count",
+			options:       []PassphraseOption{WithWordCount(4), WithSeparator("_")},
+			separator:     "_",
+			expectedWords: 4,
+			check:         func(word string) bool { return slices.Contains(wordlist, word) },
+		},
+		{
+			name:          "capitalize first",
+			options:       []PassphraseOption{WithSeparator("."), WithCapitalization(CapitalizeFirst)},
+			separator:     ".",
+			expectedWords: 6,
+			check:         func(word string) bool { return unicode.IsUpper([]rune(word)[0]) },
+		},
+		{
+			name:          "capitalize all",
+			options:       []PassphraseOption{WithSeparator("."), WithCapitalization(CapitalizeAll)},
+			separator:     ".",
+			expectedWords: 6,
+			check:         func(word string) bool { return word == strings.ToUpper(word) },
+		},
+		{
+			name:          "capitalize random",
+			options:       []PassphraseOption{WithSeparator("."), WithCapitalization(CapitalizeRandom)},
+			separator:     ".",
+			expectedWords: 6,
+			check:         func(word string) bool { return slices.Contains(wordlist, strings.ToLower(word[:1])+word[1:]) },
+		},
+		{
+			name:          "custom wordlist",
+			options:       []PassphraseOption{WithWordlist([]string{"alpha", "beta", "gamma"}), WithWordCount(5), WithSeparator("-")},
+			separator:     "-",
+			expectedWords: 5,
+			check:         func(word string) bool { return slices.Contains([]string{"alpha", "beta", "gamma"}, word) },
+		},
+		{
+			name:           "inserted digits and symbols",
+			options:        []PassphraseOption{WithSeparator(" "), WithInsertedDigits(2), WithInsertedSymbols(1)},
+			separator:      " ",
+			expectedWords:  6,
+			expectedDigits: 2,
+			expectSymbols:  1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := NewPassphrase(tt.options...)
+			if err != nil {
+				t.Fatalf("failed to create passphrase generator: %v", err)
+			}
+
+			for range 100 {
+				passphrase, err := p.Generate()
+				if err != nil {
+					t.Fatalf("failed to generate passphrase: %v", err)
+				}
+
+				words := strings.Split(passphrase, tt.separator)
+				if len(words) != tt.expectedWords {
+					t.Fatalf("expected %d words, got %d in passphrase: %s", tt.expectedWords, len(words), passphrase)
+				}
+
+				// Inserted characters are appended to words, some of which,
+				// such as "t-shirt", contain symbols themselves.
+				digitCount, symbolCount := 0, 0
+				for _, word := range words {
+					for _, r := range insertedSuffix(word, wordlist) {
+						if slices.Contains(digits, r) {
+							digitCount++
+						}
+						if slices.Contains(symbols, r) {
+							symbolCount++
+						}
+					}
+				}
+				if digitCount != tt.expectedDigits {
+					t.Errorf("expected %d digits, got %d in passphrase: %s", tt.expectedDigits, digitCount, passphrase)
+				}
+				if tt.expectSymbols > 0 && symbolCount != tt.expectSymbols {
+					t.Errorf("expected %d symbols, got %d in passphrase: %s", tt.expectSymbols, symbolCount, passphrase)
+				}
+
+				if tt.check == nil {
+					continue
+				}
+				for _, word := range words {
+					if !tt.check(word) {
+						t.Errorf("unexpected word %q in passphrase: %s", word, passphrase)
+					}
+				}
+			}
+		})
+	}
+}
+
+// insertedSuffix returns the digits and symbols appended to a word of the
+// wordlist, ignoring capitalization.
+func insertedSuffix(word string, wordlist []string) string {
+	for i := len(word); i >= 0; i-- {
+		suffix := word[i:]
+		if strings.IndexFunc(suffix, func(r rune) bool { return !slices.Contains(digits, r) && !slices.Contains(symbols, r) }) >= 0 {
+			break
+		}
+		if slices.Contains(wordlist, strings.ToLower(word[:i])) {
+			return suffix
+		}
+	}
+
+	return word
+}
+
+func TestWithPassphraseRandReader(t *testing.T) {
+	tests := []struct {
+		name        string
+		options     []PassphraseOption
+		reader      io.Reader
+		expected    string
+		expectError bool
+		errorMsg    string
+	}{
+		{
+			name:     "deterministic default",
+			reader:   &counterReader{},
+			expected: "contently thirty corner visibly affecting demise",
+		},
+		{
+			name:     "deterministic with capitals and inserted characters",
+			options:  []PassphraseOption{WithWordCount(4), WithSeparator("-"), WithCapitalization(CapitalizeRandom), WithInsertedDigits(1), WithInsertedSymbols(1)},
+			reader:   &counterReader{},
+			expected: "Contently3-Varmint!-styling-Slum",
+		},
+		{
+			name:        "reader error",
+			reader:      errReader{err: errors.New("device unplugged")},
+			expectError: true,
+			errorMsg:    "failed to read random bytes: device unplugged",
+		},
+		{
+			name:        "short read",
+			reader:      io.LimitReader(&counterReader{}, 5),
+			expectError: true,
+			errorMsg:    "failed to read random bytes: unexpected EOF",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := NewPassphrase(append(tt.options, WithPassphraseRandReader(tt.reader))...)
+			if err != nil {
+				t.Fatalf("failed to create passphrase generator: %v", err)
+			}
+
+			passphrase, err := p.Generate()
+
+			if tt.expectError {
+				if err == nil {
+					t.Errorf("expected error but got none")
+					return
+				}
+				if !strings.Contains(err.Error(), tt.errorMsg) {
+					t.Errorf("expected error message to contain %q, got %q", tt.errorMsg, err.Error())
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if passphrase != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, passphrase)
+			}
+		})
+	}
+}
+
+func TestPassphraseEntropy(t *testing.T) {
+	tests := []struct {
+		name     string
+		options  []PassphraseOption
+		expected float64
+	}{
+		{
+			name:     "default",
+			options:  nil,
+			expected: 6 * math.Log2(7776),
+		},
+		{
+			name:     "short wordlist",
+			options:  []PassphraseOption{WithWordlist(EFFShortWordlist()), WithWordCount(4)},
+			expected: 4 * math.Log2(1296),
+		},
+		{
+			name:     "random capitalization",
+			options:  []PassphraseOption{WithWordCount(5), WithCapitalization(CapitalizeRandom)},
+			expected: 5*math.Log2(7776) + 5,
+		},
+		{
+			name:     "inserted characters",
+			options:  []PassphraseOption{WithWordCount(4), WithInsertedDigits(2), WithInsertedSymbols(1)},
+			expected: 4*math.Log2(7776) + 2*math.Log2(10) + math.Log2(26),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := NewPassphrase(tt.options...)
+			if err != nil {
+				t.Fatalf("failed to create passphrase generator: %v", err)
+			}
+
+			if got := p.Entropy(); math.Abs(got-tt.expected) > 1e-9 {
+				t.Errorf("expected entropy %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,190 @@
+package passgen
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+type Rule string
+
+const (
+	RuleMinLength      Rule = "min_length"
+	RuleMaxLength      Rule = "max_length"
+	RuleAllowedChars   Rule = "allowed_chars"
+	RuleMinCount       Rule = "min_count"
+	RuleMaxCount       Rule = "max_count"
+	RuleMask           Rule = "mask"
+	RuleFirstChar      Rule = "first_char"
+	RuleLastChar       Rule = "last_char"
+	RuleMaxConsecutive Rule = "max_consecutive"
+	RuleMaxSequential  Rule = "max_sequential"
+	RuleNoRepeats      Rule = "no_repeats"
+	RuleBreached       Rule = "breached"
+)
+
+// Violation describes a single rule a password breaks.
+type Violation struct {
+	Rule Rule
+	// Class is the character class the rule applies to, if any.
+	Class string
+	Want  int
+	Got   int
+	// Chars lists the offending characters for RuleAllowedChars, RuleFirstChar,
+	// RuleLastChar and RuleNoRepeats, and the offending run for
+	// RuleMaxConsecutive and RuleMaxSequential.
+	Chars string
+	// Position is the zero-based index of the offending character for RuleMask.
+	Position int
+}
+
+func (v Violation) String() string {
+	switch v.Rule {
+	case RuleMinLength:
+		return fmt.Sprintf("password length must be at least %d, got %d", v.Want, v.Got)
+	case RuleMaxLength:
+		return fmt.Sprintf("password length must not exceed %d, got %d", v.Want, v.Got)
+	case RuleAllowedChars:
+		return fmt.Sprintf("password contains characters outside the allowed classes: %q", v.Chars)
+	case RuleMinCount:
+		return fmt.Sprintf("password must contain at least %d characters from %q, got %d", v.Want, v.Class, v.Got)
+	case RuleMaxCount:
+		return fmt.Sprintf("password must contain at most %d characters from %q, got %d", v.Want, v.Class, v.Got)
+	case RuleFirstChar:
+		return fmt.Sprintf("password must start with a character from %s, got %q", v.Class, v.Chars)
+	case RuleLastChar:
+		return fmt.Sprintf("password must end with a character from %s, got %q", v.Class, v.Chars)
+	case RuleMaxConsecutive:
+		return fmt.Sprintf("password must not repeat a character more than %d times in a row, got %q", v.Want, v.Chars)
+	case RuleMaxSequential:
+		return fmt.Sprintf("password must not contain more than %d sequential characters, got %q", v.Want, v.Chars)
+	case RuleNoRepeats:
+		return fmt.Sprintf("password must not repeat characters, got %q more than once", v.Chars)
+	case RuleMask:
+		return fmt.Sprintf("character at position %d does not match the mask", v.Position)
+	case RuleBreached:
+		return "password appears in a breach corpus"
+	}
+
+	return string(v.Rule)
+}
+
+// PolicyError reports every rule a password violates.
+type PolicyError struct {
+	Violations []Violation
+}
+
+func (e *PolicyError) Error() string {
+	msgs := make([]string, len(e.Violations))
+	for i, v := range e.Violations {
+		msgs[i] = v.String()
+	}
+
+	return "password violates policy: " + strings.Join(msgs, "; ")
+}
+
+// Validate checks a password against the generator's configuration. The
+// configured length is treated as a minimum so that longer passwords pass. It
+// returns a *PolicyError listing every violated rule, or nil. With a breach
+// filter, a password it contains violates RuleBreached, and a failed lookup
+// is returned as is.
+func (g *Generator) Validate(password string) error {
+	violations := g.check([]rune(password))
+	if g.cfg.breach != nil {
+		found, err := g.cfg.breach.Contains([]byte(password))
+		if err != nil {
+			return fmt.Errorf("failed to check breach filter: %w", err)
+		}
+		if found {
+			violations = append(violations, Violation{Rule: RuleBreached})
+		}
+	}
+	if len(violations) == 0 {
+		return nil
+	}
+
+	return &PolicyError{Violations: violations}
+}
+
+func (g *Generator) check(password []rune) []Violation {
+	var violations []Violation
+	if g.mask != nil {
+		violations = g.checkMask(password)
+	} else {
+		violations = g.checkClasses(password)
+	}
+
+	return append(violations, g.checkRepetition(password)...)
+}
+
+func (g *Generator) checkClasses(password []rune) []Violation {
+	var violations []Violation
+
+	if len(password) < g.cfg.length {
+		violations = append(violations, Violation{Rule: RuleMinLength, Want: g.cfg.length, Got: len(password)})
+	}
+	if len(password) > maxLength {
+		violations = append(violations, Violation{Rule: RuleMaxLength, Want: maxLength, Got: len(password)})
+	}
+
+	counts := make([]int, len(g.classes))
+	var disallowed []rune
+
+	for _, r := range password {
+		idx := g.classOf(r)
+		if idx < 0 {
+			disallowed = append(disallowed, r)
+			continue
+		}
+		counts[idx]++
+	}
+
+	if len(disallowed) > 0 {
+		violations = append(violations, Violation{Rule: RuleAllowedChars, Chars: NewCharset(disallowed...).String()})
+	}
+
+	for i, class := range g.classes {
+		if counts[i] < class.min {
+			violations = append(violations, Violation{Rule: RuleMinCount, Class: class.name, Want: class.min, Got: counts[i]})
+		}
+		if counts[i] > class.max {
+			violations = append(violations, Violation{Rule: RuleMaxCount, Class: class.name, Want: class.max, Got: counts[i]})
+		}
+	}
+
+	return append(violations, g.checkAnchors(password)...)
+}
+
+// classOf returns the index of the class containing r, or -1.
+func (g *Generator) classOf(r rune) int {
+	for i, class := range g.classes {
+		for _, c := range class.chars {
+			if c == r {
+				return i
+			}
+		}
+	}
+
+	return -1
+}
+
+// checkMask requires an exact match: the mask fixes both the length and the
+// class of every position.
+func (g *Generator) checkMask(password []rune) []Violation {
+	var violations []Violation
+
+	if len(password) < len(g.mask) {
+		violations = append(violations, Violation{Rule: RuleMinLength, Want: len(g.mask), Got: len(password)})
+	}
+	if len(password) > len(g.mask) {
+		violations = append(violations, Violation{Rule: RuleMaxLength, Want: len(g.mask), Got: len(password)})
+	}
+
+	for i, r := range password[:min(len(password), len(g.mask))] {
+		if !slices.Contains(g.mask[i], r) {
+			violations = append(violations, Violation{Rule: RuleMask, Position: i})
+		}
+	}
+
+	return violations
+}
@@ -0,0 +1,177 @@
+package passgen
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name       string
+		options    []Option
+		password   string
+		violations []Violation
+	}{
+		{
+			name:     "valid default",
+			options:  nil,
+			password: "Abcdefgh12345!@#",
+		},
+		{
+			name:     "longer than configured length",
+			options:  []Option{WithLength(8)},
+			password: "Abcdefgh12345!@#",
+		},
+		{
+			name:     "too short",
+			options:  nil,
+			password: "Abc1!",
+			violations: []Violation{
+				{Rule: RuleMinLength, Want: 16, Got: 5},
+			},
+		},
+		{
+			name:     "too long",
+			options:  []Option{WithLength(4)},
+			password: strings.Repeat("a", 10001),
+			violations: []Violation{
+				{Rule: RuleMaxLength, Want: 10000, Got: 10001},
+			},
+		},
+		{
+			name:     "disabled class",
+			options:  []Option{WithLength(8), WithoutSymbols()},
+			password: "abc!def?gh!",
+			violations: []Violation{
+				{Rule: RuleAllowedChars, Chars: "!?"},
+			},
+		},
+		{
+			name:     "characters outside every class",
+			options:  []Option{WithLength(4)},
+			password: "ab c~é",
+			violations: []Violation{
+				{Rule: RuleAllowedChars, Chars: " ~é"},
+			},
+		},
+		{
+			name:     "ambiguous characters",
+			options:  []Option{WithLength(4), WithoutAmbiguous()},
+			password: "O0abcd",
+			violations: []Violation{
+				{Rule: RuleAllowedChars, Chars: "0O"},
+			},
+		},
+		{
+			name:     "missing minimums",
+			options:  []Option{WithLength(8), WithMinRequirements(1, 2, 3, 1)},
+			password: "abcdefg1",
+			violations: []Violation{
+				{Rule: RuleMinCount, Class: "uppercase", Want: 1, Got: 0},
+				{Rule: RuleMinCount, Class: "digits", Want: 3, Got: 1},
+				{Rule: RuleMinCount, Class: "symbols", Want: 1, Got: 0},
+			},
+		},
+		{
+			name:     "custom charset minimum",
+			options:  []Option{WithLength(4), WithoutSymbols(), WithCharset("safe", CharsetFromString("!#"), 2)},
+			password: "abcd!",
+			violations: []Violation{
+				{Rule: RuleMinCount, Class: "safe", Want: 2, Got: 1},
+			},
+		},
+		{
+			name:     "every violation at once",
+			options:  []Option{WithLength(10), WithoutSymbols(), WithMinDigits(2)},
+			password: "ab!1",
+			violations: []Violation{
+				{Rule: RuleMinLength, Want: 10, Got: 4},
+				{Rule: RuleAllowedChars, Chars: "!"},
+				{Rule: RuleMinCount, Class: "digits", Want: 2, Got: 1},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gen, err := NewGenerator(tt.options...)
+			if err != nil {
+				t.Fatalf("failed to create generator: %v", err)
+			}
+
+			err = gen.Validate(tt.password)
+
+			if len(tt.violations) == 0 {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+
+			var policyErr *PolicyError
+			if !errors.As(err, &policyErr) {
+				t.Fatalf("expected *PolicyError, got %v", err)
+			}
+			if !reflect.DeepEqual(policyErr.Violations, tt.violations) {
+				t.Errorf("expected violations %+v, got %+v", tt.violations, policyErr.Violations)
+			}
+		})
+	}
+}
+
+func TestValidateGeneratedPasswords(t *testing.T) {
+	tests := []struct {
+		name    string
+		options []Option
+	}{
+		{
+			name:    "default",
+			options: nil,
+		},
+		{
+			name:    "min requirements",
+			options: []Option{WithLength(12), WithMinRequirements(2, 2, 2, 2)},
+		},
+		{
+			name:    "custom charset without ambiguous",
+			options: []Option{WithoutSymbols(), WithoutAmbiguous(), WithCharset("safe", CharsetFromString("!#%"), 2)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gen, err := NewGenerator(tt.options...)
+			if err != nil {
+				t.Fatalf("failed to create generator: %v", err)
+			}
+
+			for range 100 {
+				password, err := gen.Generate()
+				if err != nil {
+					t.Fatalf("failed to generate password: %v", err)
+				}
+				if err := gen.Validate(password); err != nil {
+					t.Errorf("generated password %q failed validation: %v", password, err)
+				}
+			}
+		})
+	}
+}
+
+func TestPolicyErrorMessage(t *testing.T) {
+	err := &PolicyError{Violations: []Violation{
+		{Rule: RuleMinLength, Want: 10, Got: 4},
+		{Rule: RuleAllowedChars, Chars: "!"},
+		{Rule: RuleMinCount, Class: "digits", Want: 2, Got: 1},
+	}}
+
+	expected := `password violates policy: password length must be at least 10, got 4; ` +
+		`password contains characters outside the allowed classes: "!"; ` +
+		`password must contain at least 2 characters from "digits", got 1`
+
+	if err.Error() != expected {
+		t.Errorf("expected %q, got %q", expected, err.Error())
+	}
+}
@@ -0,0 +1,362 @@
+package passgen
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"math/big"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// maxAnchorAttempts bounds how often a password drawn without regard to the
+// first and last character constraints is redrawn before the placement is
+// drawn from the exact counts instead.
+const maxAnchorAttempts = 1000
+
+// anchors constrains the first and last positions of a password to subsets
+// of the enabled classes, given as indices into the class list. With distinct
+// set no character may be used twice.
+type anchors struct {
+	first    []int
+	last     []int
+	distinct bool
+}
+
+// slot is a constrained position and the classes allowed there.
+type slot struct {
+	pos     int
+	classes []int
+}
+
+// placement fixes the class of every slot. Its weight is the number of ways
+// to fill the slots from those classes, and rest holds the minimums the
+// remaining positions still have to meet.
+type placement struct {
+	classes []int
+	weight  int
+	rest    []charClass
+}
+
+// anchors resolves the first and last character constraints against the
+// enabled classes. It returns nil when neither position is constrained.
+func (c *config) anchors(classes []charClass) (*anchors, *ConfigError) {
+	if len(c.firstChar) == 0 && len(c.lastChar) == 0 {
+		return nil, nil
+	}
+
+	first, err := classIndices(classes, c.firstChar)
+	if err != nil {
+		return nil, newConfigError(FieldFirstChar, c.firstChar, ErrDisabledClass, "invalid first character constraint: %v", err)
+	}
+	last, err := classIndices(classes, c.lastChar)
+	if err != nil {
+		return nil, newConfigError(FieldLastChar, c.lastChar, ErrDisabledClass, "invalid last character constraint: %v", err)
+	}
+
+	return &anchors{first: first, last: last, distinct: c.noRepeats}, nil
+}
+
+func classIndices(classes []charClass, names []string) ([]int, error) {
+	var indices []int
+	for _, name := range names {
+		idx := slices.IndexFunc(classes, func(class charClass) bool { return class.name == name })
+		if idx < 0 {
+			return nil, fmt.Errorf("class %q is disabled or unknown", name)
+		}
+		if !slices.Contains(indices, idx) {
+			indices = append(indices, idx)
+		}
+	}
+
+	return indices, nil
+}
+
+// slots returns the constrained positions of a password of the given length.
+// A single character has to satisfy both constraints at once.
+func (a *anchors) slots(length int) []slot {
+	switch {
+	case a.first == nil:
+		return []slot{{pos: length - 1, classes: a.last}}
+	case a.last == nil:
+		return []slot{{pos: 0, classes: a.first}}
+	case length == 1:
+		var both []int
+		for _, idx := range a.first {
+			if slices.Contains(a.last, idx) {
+				both = append(both, idx)
+			}
+		}
+		return []slot{{pos: 0, classes: both}}
+	}
+
+	return []slot{{pos: 0, classes: a.first}, {pos: length - 1, classes: a.last}}
+}
+
+// allowed returns the characters each position of a password of the given
+// length is restricted to by a mask or by the first and last character
+// constraints, with nil for unrestricted positions.
+func (c *config) allowed(classes []charClass, length int) [][]rune {
+	if c.mask != "" {
+		mask, _ := parseMask(c.mask, classes)
+		return mask
+	}
+
+	a, _ := c.anchors(classes)
+	if a == nil {
+		return nil
+	}
+
+	allowed := make([][]rune, length)
+	for _, s := range a.slots(length) {
+		chars := []rune{}
+		for _, idx := range s.classes {
+			chars = append(chars, classes[idx].chars...)
+		}
+		allowed[s.pos] = chars
+	}
+
+	return allowed
+}
+
+// placements lists every way to assign a class to each slot that leaves a
+// satisfiable remainder for the other positions.
+func (a *anchors) placements(classes []charClass, length int) []placement {
+	slots := a.slots(length)
+	free := length - len(slots)
+
+	var result []placement
+	var walk func(i int, picked []int)
+	walk = func(i int, picked []int) {
+		if i < len(slots) {
+			for _, idx := range slots[i].classes {
+				walk(i+1, append(picked, idx))
+			}
+			return
+		}
+
+		weight := 1
+		rest := slices.Clone(classes)
+		for _, idx := range picked {
+			if rest[idx].max == 0 {
+				return
+			}
+			if rest[idx].max != noLimit {
+				rest[idx].max--
+			}
+
+			weight *= len(rest[idx].chars)
+			rest[idx].min = max(rest[idx].min-1, 0)
+			if a.distinct && len(rest[idx].chars) > 0 {
+				// Only the size matters here; generateAnchored removes the
+				// characters actually drawn.
+				rest[idx].chars = rest[idx].chars[1:]
+			}
+		}
+
+		need, capacity := 0, 0
+		for _, class := range rest {
+			available := len(class.chars) * free
+			if a.distinct {
+				available = len(class.chars)
+			}
+			if class.min > min(class.max, available) {
+				return
+			}
+
+			need += class.min
+			capacity = min(capacity+min(class.max, available), free)
+		}
+		if weight == 0 || need > free || capacity < free {
+			return
+		}
+
+		result = append(result, placement{
+			classes: slices.Clone(picked),
+			weight:  weight,
+			rest:    rest,
+		})
+	}
+	walk(0, nil)
+
+	return result
+}
+
+// count returns the number of passwords of the given length that satisfy the
+// constraints and every minimum: for each placement, the ways to fill the
+// slots times the ways to fill the remaining positions.
+func (a *anchors) count(classes []charClass, length int) *big.Int {
+	free := length - len(a.slots(length))
+
+	total := new(big.Int)
+	for _, p := range a.placements(classes, length) {
+		term := countClasses(p.rest, free, a.distinct)
+		total.Add(total, term.Mul(term, big.NewInt(int64(p.weight))))
+	}
+
+	return total
+}
+
+// log2Count returns log2 of count, with the remaining positions counted in
+// floating point.
+func (a *anchors) log2Count(classes []charClass, length int) float64 {
+	free := length - len(a.slots(length))
+
+	var terms []float64
+	largest := math.Inf(-1)
+	for _, p := range a.placements(classes, length) {
+		term := math.Log2(float64(p.weight)) + log2Classes(p.rest, free, a.distinct)
+		terms = append(terms, term)
+		largest = max(largest, term)
+	}
+	if math.IsInf(largest, -1) {
+		return largest
+	}
+
+	sum := 0.0
+	for _, term := range terms {
+		sum += math.Exp2(term - largest)
+	}
+
+	return largest + math.Log2(sum)
+}
+
+// anchored generates passwords with constrained first and last characters.
+// It draws whole passwords meeting the minimums and maximums and redraws them
+// until the first and last characters are allowed, which keeps every
+// password equally likely. Once that keeps failing, or with class weights,
+// the placement is drawn in proportion to the number of passwords it leads
+// to, its slots are filled, and the positions in between are drawn uniformly
+// from those meeting the remaining minimums and maximums.
+type anchored struct {
+	*anchors
+	slots      []slot
+	placements []placement
+	samplers   []*sampler
+	// weights counts the passwords of every placement the first time they
+	// are needed, which can take long for long passwords.
+	weights func() placementWeights
+}
+
+// placementWeights holds the number of passwords of every placement and
+// their sum.
+type placementWeights struct {
+	weights []*big.Int
+	total   *big.Int
+}
+
+func newAnchored(a *anchors, classes []charClass, length int) *anchored {
+	slots := a.slots(length)
+	free := length - len(slots)
+
+	an := &anchored{anchors: a, slots: slots, placements: a.placements(classes, length)}
+	for _, p := range an.placements {
+		an.samplers = append(an.samplers, newSampler(p.rest, free, a.distinct))
+	}
+	an.weights = sync.OnceValue(func() placementWeights {
+		pw := placementWeights{total: new(big.Int)}
+		for _, p := range an.placements {
+			w := countClasses(p.rest, free, a.distinct)
+			w.Mul(w, big.NewInt(int64(p.weight)))
+
+			pw.weights = append(pw.weights, w)
+			pw.total.Add(pw.total, w)
+		}
+		return pw
+	})
+
+	return an
+}
+
+func (g *Generator) generateAnchored(r io.Reader, pass []rune) error {
+	if g.weighting == nil {
+		for range maxAnchorAttempts {
+			if err := g.fill(r, pass, g.sampler); err != nil {
+				return err
+			}
+			if len(g.checkAnchors(pass)) == 0 {
+				return nil
+			}
+		}
+	}
+
+	return g.generatePlacement(r, pass)
+}
+
+// generatePlacement draws the placement of the slots in proportion to the
+// number of passwords it leads to and fills the password around it.
+func (g *Generator) generatePlacement(r io.Reader, pass []rune) error {
+	a := g.anchored
+
+	pw := a.weights()
+	i, err := pick(r, pw.total, func(each func(k int, w *big.Int) bool) {
+		for k, w := range pw.weights {
+			if !each(k, w) {
+				return
+			}
+		}
+	})
+	if err != nil {
+		return fmt.Errorf("failed to choose placement: %w", err)
+	}
+	p, inner := a.placements[i], a.samplers[i]
+
+	var used []rune
+	for i, s := range a.slots {
+		chars := g.classes[p.classes[i]].chars
+		if a.distinct {
+			chars = excludeChars(chars, NewCharset(used...))
+		}
+
+		if err := generatePassEntry(r, pass[s.pos:s.pos+1], chars); err != nil {
+			return fmt.Errorf("failed to generate password entry: %w", err)
+		}
+		used = append(used, pass[s.pos])
+	}
+
+	if a.distinct {
+		rest := slices.Clone(p.rest)
+		for i := range rest {
+			rest[i].chars = excludeChars(g.classes[i].chars, NewCharset(used...))
+		}
+		inner = inner.withClasses(rest)
+	}
+
+	// Slots sit at the ends, so the free positions are contiguous.
+	start, end := 0, len(pass)
+	for _, s := range a.slots {
+		if s.pos == 0 {
+			start = 1
+		} else {
+			end = s.pos
+		}
+	}
+
+	return g.fill(r, pass[start:end], inner)
+}
+
+// checkAnchors reports a first or last character outside its allowed classes.
+func (g *Generator) checkAnchors(password []rune) []Violation {
+	if g.anchored == nil || len(password) == 0 {
+		return nil
+	}
+
+	var violations []Violation
+	check := func(rule Rule, allowed []int, r rune) {
+		if allowed == nil || slices.Contains(allowed, g.classOf(r)) {
+			return
+		}
+
+		names := make([]string, len(allowed))
+		for i, idx := range allowed {
+			names[i] = g.classes[idx].name
+		}
+		violations = append(violations, Violation{Rule: rule, Class: strings.Join(names, ", "), Chars: string(r)})
+	}
+
+	check(RuleFirstChar, g.anchored.first, password[0])
+	check(RuleLastChar, g.anchored.last, password[len(password)-1])
+
+	return violations
+}
@@ -0,0 +1,287 @@
+package passgen
+
+import (
+	"errors"
+	"math/big"
+	"strings"
+	"testing"
+)
+
+func TestWithFirstLastChar(t *testing.T) {
+	tests := []struct {
+		name    string
+		options []Option
+		length  int
+		first   Charset
+		last    Charset
+	}{
+		{
+			name:    "letter first",
+			options: []Option{WithLength(12), WithFirstChar(ClassUppercase, ClassLowercase)},
+			length:  12,
+			first:   Uppercase.Union(Lowercase),
+		},
+		{
+			name:    "digit last",
+			options: []Option{WithLength(8), WithLastChar(ClassDigits)},
+			length:  8,
+			last:    Digits,
+		},
+		{
+			name: "both with tight minimums",
+			options: []Option{
+				WithLength(4), WithMinRequirements(1, 1, 1, 1),
+				WithFirstChar(ClassLowercase), WithLastChar(ClassSymbols),
+			},
+			length: 4,
+			first:  Lowercase,
+			last:   Symbols,
+		},
+		{
+			name: "custom charset",
+			options: []Option{
+				WithLength(6), WithoutSymbols(), WithCharset("safe", CharsetFromString("!#%"), 1),
+				WithFirstChar("safe"), WithLastChar("safe"),
+			},
+			length: 6,
+			first:  CharsetFromString("!#%"),
+			last:   CharsetFromString("!#%"),
+		},
+		{
+			name:    "single character",
+			options: []Option{WithLength(1), WithFirstChar(ClassUppercase, ClassDigits), WithLastChar(ClassDigits, ClassSymbols)},
+			length:  1,
+			first:   Digits,
+			last:    Digits,
+		},
+		{
+			name:    "without ambiguous",
+			options: []Option{WithLength(10), WithoutAmbiguous(), WithFirstChar(ClassUppercase), WithMinUppercase(1)},
+			length:  10,
+			first:   Uppercase.Difference(Ambiguous),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gen, err := NewGenerator(tt.options...)
+			if err != nil {
+				t.Fatalf("failed to create generator: %v", err)
+			}
+
+			for range 200 {
+				password, err := gen.Generate()
+				if err != nil {
+					t.Fatalf("failed to generate password: %v", err)
+				}
+
+				runes := []rune(password)
+				if len(runes) != tt.length {
+					t.Fatalf("expected length %d, got %d: %q", tt.length, len(runes), password)
+				}
+				if tt.first.Len() > 0 && !tt.first.Contains(runes[0]) {
+					t.Errorf("first character of %q is not in %q", password, tt.first)
+				}
+				if tt.last.Len() > 0 && !tt.last.Contains(runes[len(runes)-1]) {
+					t.Errorf("last character of %q is not in %q", password, tt.last)
+				}
+				if err := gen.Validate(password); err != nil {
+					t.Errorf("password %q does not satisfy the policy: %v", password, err)
+				}
+			}
+		})
+	}
+}
+
+func TestNewGeneratorFirstLastChar(t *testing.T) {
+	tests := []struct {
+		name     string
+		options  []Option
+		errorMsg string
+	}{
+		{
+			name:     "disabled class",
+			options:  []Option{WithoutSymbols(), WithFirstChar(ClassSymbols)},
+			errorMsg: `invalid first character constraint: class "symbols" is disabled or unknown`,
+		},
+		{
+			name:     "unknown class",
+			options:  []Option{WithLastChar("hex")},
+			errorMsg: `invalid last character constraint: class "hex" is disabled or unknown`,
+		},
+		{
+			name:     "no shared class for a single character",
+			options:  []Option{WithLength(1), WithFirstChar(ClassUppercase), WithLastChar(ClassDigits)},
+			errorMsg: "no password of length 1 satisfies the first and last character constraints and the minimum requirements",
+		},
+		{
+			name:     "minimums leave no room",
+			options:  []Option{WithLength(2), WithMinRequirements(0, 0, 1, 1), WithFirstChar(ClassUppercase)},
+			errorMsg: "no password of length 2 satisfies the first and last character constraints and the minimum requirements",
+		},
+		{
+			name:     "class emptied by exclusion",
+			options:  []Option{WithoutAmbiguous(), WithAmbiguousChars(Digits), WithFirstChar(ClassDigits)},
+			errorMsg: "no password of length 16 satisfies the first and last character constraints and the minimum requirements",
+		},
+		{
+			name:     "combined with a mask",
+			options:  []Option{WithMask("?d?d"), WithFirstChar(ClassDigits)},
+			errorMsg: "first and last character constraints cannot be combined with a mask",
+		},
+		{
+			name:     "combined with pronounceable",
+			options:  []Option{WithPronounceable(), WithLastChar(ClassDigits)},
+			errorMsg: "first and last character constraints cannot be combined with pronounceable passwords",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewGenerator(tt.options...)
+			if err == nil {
+				t.Fatalf("expected error but got none")
+			}
+			if !strings.Contains(err.Error(), tt.errorMsg) {
+				t.Errorf("expected error message to contain %q, got %q", tt.errorMsg, err.Error())
+			}
+		})
+	}
+}
+
+func TestFirstLastCharKeyspace(t *testing.T) {
+	small := []Option{WithoutUppercase(), WithoutLowercase(), WithoutSymbols(), WithCharset("ab", CharsetFromString("ab"), 0)}
+
+	tests := []struct {
+		name    string
+		options []Option
+	}{
+		{
+			name:    "first only",
+			options: []Option{WithLength(3), WithFirstChar("ab")},
+		},
+		{
+			name:    "last only with minimum",
+			options: []Option{WithLength(3), WithMinDigits(2), WithLastChar("ab")},
+		},
+		{
+			name:    "both with minimums",
+			options: []Option{WithLength(4), WithMinDigits(1), WithCharset("ab", CharsetFromString("ab"), 2), WithFirstChar("ab", ClassDigits), WithLastChar("ab")},
+		},
+		{
+			name:    "single character",
+			options: []Option{WithLength(1), WithFirstChar("ab", ClassDigits), WithLastChar("ab")},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gen, err := NewGenerator(append(small, tt.options...)...)
+			if err != nil {
+				t.Fatalf("failed to create generator: %v", err)
+			}
+
+			expected := bruteForceValid(gen)
+			if got := gen.Keyspace(); got.Cmp(big.NewInt(expected)) != 0 {
+				t.Errorf("expected keyspace %d, got %v", expected, got)
+			}
+		})
+	}
+}
+
+func TestFirstCharLongPassword(t *testing.T) {
+	gen, err := NewGenerator(WithLength(10000), WithFirstChar(ClassDigits), WithMinRequirements(100, 100, 100, 100), WithMaxSymbols(500))
+	if err != nil {
+		t.Fatalf("failed to create generator: %v", err)
+	}
+
+	password, err := gen.Generate()
+	if err != nil {
+		t.Fatalf("failed to generate password: %v", err)
+	}
+	if !strings.ContainsRune(string(digits), rune(password[0])) {
+		t.Errorf("expected a digit first, got %q", password[0])
+	}
+	if err := gen.Validate(password); err != nil {
+		t.Errorf("generated password fails validation: %v", err)
+	}
+
+	free, err := NewGenerator(WithLength(10000), WithMinRequirements(100, 100, 100, 100), WithMaxSymbols(500))
+	if err != nil {
+		t.Fatalf("failed to create generator: %v", err)
+	}
+
+	// Roughly one password in seven starts with a digit, which costs under 3 bits.
+	if diff := free.Entropy() - gen.Entropy(); diff < 2 || diff > 3 {
+		t.Errorf("expected the first character to cost 2 to 3 bits, got %v", diff)
+	}
+}
+
+func TestValidateFirstLastChar(t *testing.T) {
+	gen, err := NewGenerator(WithLength(4), WithFirstChar(ClassUppercase, ClassLowercase), WithLastChar(ClassDigits))
+	if err != nil {
+		t.Fatalf("failed to create generator: %v", err)
+	}
+
+	err = gen.Validate("1ab!")
+
+	var policyErr *PolicyError
+	if !errors.As(err, &policyErr) {
+		t.Fatalf("expected *PolicyError, got %v", err)
+	}
+
+	expected := []Violation{
+		{Rule: RuleFirstChar, Class: "uppercase, lowercase", Chars: "1"},
+		{Rule: RuleLastChar, Class: "digits", Chars: "!"},
+	}
+	if len(policyErr.Violations) != len(expected) {
+		t.Fatalf("expected violations %+v, got %+v", expected, policyErr.Violations)
+	}
+	for i, v := range expected {
+		if policyErr.Violations[i] != v {
+			t.Errorf("expected violation %+v, got %+v", v, policyErr.Violations[i])
+		}
+	}
+
+	expectedMsg := `password violates policy: password must start with a character from uppercase, lowercase, got "1"; password must end with a character from digits, got "!"`
+	if err.Error() != expectedMsg {
+		t.Errorf("expected message %q, got %q", expectedMsg, err.Error())
+	}
+}
+
+// bruteForceValid counts the strings of the configured length over the
+// generator's pool and mask literals that pass Validate.
+func bruteForceValid(g *Generator) int64 {
+	length := g.cfg.length
+
+	pool := NewCharset(g.charset...)
+	for _, chars := range g.mask {
+		pool = pool.Union(NewCharset(chars...))
+	}
+	charset := pool.Runes()
+
+	var count int64
+	idx := make([]int, length)
+	pass := make([]rune, length)
+	for {
+		for i, j := range idx {
+			pass[i] = charset[j]
+		}
+		if g.Validate(string(pass)) == nil {
+			count++
+		}
+
+		pos := 0
+		for pos < length {
+			idx[pos]++
+			if idx[pos] < len(charset) {
+				break
+			}
+			idx[pos] = 0
+			pos++
+		}
+		if pos == length {
+			return count
+		}
+	}
+}
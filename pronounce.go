@@ -0,0 +1,321 @@
+package passgen
+
+import (
+	"crypto/rand"
+	"fmt"
+	"io"
+	"math/big"
+	"unicode"
+)
+
+var (
+	vowels     = []rune("aeiou")
+	consonants = []rune("bdfghjklmnprstvwz")
+)
+
+// syllables generates pronounceable passwords: letters alternate between
+// consonants and vowels, starting with either, and the minimum requirements
+// of the non-letter classes are met by inserting their characters at random
+// positions.
+type syllables struct {
+	vowels     []rune
+	consonants []rune
+	// capitals letters are capitalized when both cases are enabled.
+	capitals int
+	upper    Charset
+	// capitalVowels and capitalConsonants are the letters whose capital is
+	// not excluded.
+	capitalVowels     []rune
+	capitalConsonants []rune
+	inserted          []charClass
+}
+
+func newSyllables(c *config, classes []charClass) *syllables {
+	toCase := unicode.ToLower
+	if !c.useLowercase {
+		toCase = unicode.ToUpper
+	}
+
+	s := &syllables{
+		vowels:     c.letters(vowels, toCase),
+		consonants: c.letters(consonants, toCase),
+	}
+
+	for _, class := range classes {
+		switch class.name {
+		case ClassUppercase:
+			s.upper = NewCharset(class.chars...)
+			if c.useLowercase {
+				s.capitals = class.min
+			}
+		case ClassLowercase:
+		default:
+			if class.min > 0 {
+				s.inserted = append(s.inserted, class)
+			}
+		}
+	}
+
+	s.capitalVowels = s.capitalizable(s.vowels)
+	s.capitalConsonants = s.capitalizable(s.consonants)
+
+	return s
+}
+
+func (s *syllables) capitalizable(letters []rune) []rune {
+	var result []rune
+	for _, l := range letters {
+		if s.canCapitalize(l) {
+			result = append(result, l)
+		}
+	}
+
+	return result
+}
+
+// letters converts the letters to the enabled case and drops excluded ones.
+func (c *config) letters(letters []rune, toCase func(rune) rune) []rune {
+	converted := make([]rune, len(letters))
+	for i, r := range letters {
+		converted[i] = toCase(r)
+	}
+
+	if c.excludeAmbiguous {
+		return excludeChars(converted, c.ambiguous)
+	}
+
+	return converted
+}
+
+func (s *syllables) canCapitalize(r rune) bool {
+	return s.capitals > 0 && s.upper.Contains(unicode.ToUpper(r))
+}
+
+func (s *syllables) insertedCount() int {
+	count := 0
+	for _, class := range s.inserted {
+		count += class.min
+	}
+
+	return count
+}
+
+func (s *syllables) generate(r io.Reader, pass []rune) error {
+	letters := make([]rune, len(pass)-s.insertedCount())
+	if err := s.fillLetters(r, letters); err != nil {
+		return fmt.Errorf("failed to generate letters: %w", err)
+	}
+
+	extras := make([]rune, 0, len(pass)-len(letters))
+	for _, class := range s.inserted {
+		entry := make([]rune, class.min)
+		if err := generatePassEntry(r, entry, class.chars); err != nil {
+			return fmt.Errorf("failed to generate password entry: %w", err)
+		}
+		extras = append(extras, entry...)
+	}
+	if err := shuffleRunes(r, extras); err != nil {
+		return fmt.Errorf("failed to shuffle password: %w", err)
+	}
+
+	positions := make([]int, len(pass))
+	for i := range positions {
+		positions[i] = i
+	}
+	if err := partialShuffle(r, positions, len(extras)); err != nil {
+		return fmt.Errorf("failed to place inserted characters: %w", err)
+	}
+
+	isExtra := make([]bool, len(pass))
+	for _, p := range positions[:len(extras)] {
+		isExtra[p] = true
+	}
+
+	nextLetter, nextExtra := 0, 0
+	for i := range pass {
+		if isExtra[i] {
+			pass[i] = extras[nextExtra]
+			nextExtra++
+		} else {
+			pass[i] = letters[nextLetter]
+			nextLetter++
+		}
+	}
+
+	return nil
+}
+
+// fillLetters draws the alternating letters with s.capitals of them
+// capitalized, uniformly among all such strings. It picks whether they start
+// with a consonant and how many capitals go to consonants with probability
+// proportional to the number of strings, then the capitalized positions and
+// the letters.
+func (s *syllables) fillLetters(r io.Reader, letters []rune) error {
+	type split struct {
+		start, consonants int
+	}
+	var splits []split
+	var weights []*big.Int
+	total := new(big.Int)
+
+	for start := range 2 {
+		nc, nv := s.letterCounts(len(letters), start)
+		for k := max(0, s.capitals-nv); k <= min(s.capitals, nc); k++ {
+			w := s.capitalWays(nc, k, s.consonants, s.capitalConsonants)
+			w.Mul(w, s.capitalWays(nv, s.capitals-k, s.vowels, s.capitalVowels))
+			if w.Sign() > 0 {
+				splits = append(splits, split{start, k})
+				weights = append(weights, w)
+				total.Add(total, w)
+			}
+		}
+
+		// Without letters both starting points produce the same empty string.
+		if len(letters) == 0 {
+			break
+		}
+	}
+	if total.Sign() == 0 {
+		return fmt.Errorf("no letters with %d capitals", s.capitals)
+	}
+
+	x, err := rand.Int(r, total)
+	if err != nil {
+		return fmt.Errorf("failed to read random bytes: %w", err)
+	}
+	var chosen split
+	for i, w := range weights {
+		if x.Sub(x, w).Sign() < 0 {
+			chosen = splits[i]
+			break
+		}
+	}
+
+	var consonantPositions, vowelPositions []int
+	for i := range letters {
+		if (i+chosen.start)%2 == 1 {
+			vowelPositions = append(vowelPositions, i)
+		} else {
+			consonantPositions = append(consonantPositions, i)
+		}
+	}
+
+	parts := []struct {
+		positions      []int
+		capitals       int
+		letters, upper []rune
+	}{
+		{consonantPositions, chosen.consonants, s.consonants, s.capitalConsonants},
+		{vowelPositions, s.capitals - chosen.consonants, s.vowels, s.capitalVowels},
+	}
+	for _, p := range parts {
+		if err := partialShuffle(r, p.positions, p.capitals); err != nil {
+			return err
+		}
+		for i, pos := range p.positions {
+			set := p.letters
+			if i < p.capitals {
+				set = p.upper
+			}
+			if err := generatePassEntry(r, letters[pos:pos+1], set); err != nil {
+				return err
+			}
+			if i < p.capitals {
+				letters[pos] = unicode.ToUpper(letters[pos])
+			}
+		}
+	}
+
+	return nil
+}
+
+// letterCounts returns the number of consonants and vowels among n letters
+// starting with a consonant (start 0) or a vowel (start 1).
+func (s *syllables) letterCounts(n, start int) (consonants, vowels int) {
+	consonants = (n + 1 - start) / 2
+	return consonants, n - consonants
+}
+
+// capitalWays returns the number of strings of n letters from set with k of
+// them capitalized, which must be from upper.
+func (s *syllables) capitalWays(n, k int, set, upper []rune) *big.Int {
+	w := new(big.Int).Binomial(int64(n), int64(k))
+	w.Mul(w, new(big.Int).Exp(big.NewInt(int64(len(upper))), big.NewInt(int64(k)), nil))
+	return w.Mul(w, new(big.Int).Exp(big.NewInt(int64(len(set))), big.NewInt(int64(n-k)), nil))
+}
+
+// partialShuffle moves k uniformly chosen elements of s to its front.
+func partialShuffle[T any](r io.Reader, s []T, k int) error {
+	for i := range k {
+		j, err := randomIndex(r, len(s)-i)
+		if err != nil {
+			return err
+		}
+
+		s[i], s[i+j] = s[i+j], s[i]
+	}
+
+	return nil
+}
+
+// count returns the number of distinct pronounceable passwords of the given
+// length. The alternating letters, the capitalized positions, the positions
+// of the inserted characters and the inserted characters themselves can all
+// be read back from the password, so the choices multiply.
+func (s *syllables) count(length int) *big.Int {
+	inserted := s.insertedCount()
+	letterCount := length - inserted
+	if letterCount < 0 {
+		return new(big.Int)
+	}
+
+	letters := new(big.Int)
+	for start := range 2 {
+		// ways[c] counts letter strings with c capitalized letters.
+		ways := make([]*big.Int, s.capitals+1)
+		for i := range ways {
+			ways[i] = new(big.Int)
+		}
+		ways[0].SetInt64(1)
+
+		for i := range letterCount {
+			set := s.consonants
+			if (i+start)%2 == 1 {
+				set = s.vowels
+			}
+
+			capitalizable := 0
+			for _, l := range set {
+				if s.canCapitalize(l) {
+					capitalizable++
+				}
+			}
+
+			for c := s.capitals; c >= 0; c-- {
+				ways[c].Mul(ways[c], big.NewInt(int64(len(set))))
+				if c > 0 {
+					ways[c].Add(ways[c], new(big.Int).Mul(ways[c-1], big.NewInt(int64(capitalizable))))
+				}
+			}
+		}
+
+		letters.Add(letters, ways[s.capitals])
+
+		// Without letters both starting points produce the same empty string.
+		if letterCount == 0 {
+			break
+		}
+	}
+
+	total := new(big.Int).Binomial(int64(length), int64(inserted))
+	total.Mul(total, letters)
+
+	placed := 0
+	for _, class := range s.inserted {
+		placed += class.min
+		total.Mul(total, new(big.Int).Binomial(int64(placed), int64(class.min)))
+		total.Mul(total, new(big.Int).Exp(big.NewInt(int64(len(class.chars))), big.NewInt(int64(class.min)), nil))
+	}
+
+	return total
+}
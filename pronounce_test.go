@@ -0,0 +1,306 @@
+package passgen
+
+import (
+	"math/big"
+	"strings"
+	"testing"
+	"unicode"
+)
+
+func TestPronounceable(t *testing.T) {
+	tests := []struct {
+		name         string
+		options      []Option
+		length       int
+		upperLetters int
+		digits       int
+		symbols      int
+		allUpper     bool
+	}{
+		{
+			name:    "letters only",
+			options: []Option{WithLength(10), WithPronounceable()},
+			length:  10,
+		},
+		{
+			name:         "with minimums",
+			options:      []Option{WithLength(12), WithPronounceable(), WithMinRequirements(2, 1, 2, 1)},
+			length:       12,
+			upperLetters: 2,
+			digits:       2,
+			symbols:      1,
+		},
+		{
+			name:     "uppercase letters",
+			options:  []Option{WithLength(8), WithPronounceable(), WithoutLowercase(), WithMinDigits(1)},
+			length:   8,
+			digits:   1,
+			allUpper: true,
+		},
+		{
+			name:         "without ambiguous",
+			options:      []Option{WithLength(16), WithPronounceable(), WithoutAmbiguous(), WithMinRequirements(4, 0, 2, 0)},
+			length:       16,
+			upperLetters: 4,
+			digits:       2,
+		},
+		{
+			name:    "only inserted characters",
+			options: []Option{WithLength(3), WithPronounceable(), WithMinDigits(3)},
+			length:  3,
+			digits:  3,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gen, err := NewGenerator(tt.options...)
+			if err != nil {
+				t.Fatalf("failed to create generator: %v", err)
+			}
+
+			for range 200 {
+				password, err := gen.Generate()
+				if err != nil {
+					t.Fatalf("failed to generate password: %v", err)
+				}
+				if err := gen.Validate(password); err != nil {
+					t.Errorf("password %q does not satisfy the policy: %v", password, err)
+				}
+
+				runes := []rune(password)
+				if len(runes) != tt.length {
+					t.Errorf("expected length %d, got %d: %q", tt.length, len(runes), password)
+				}
+
+				var letters []rune
+				upper, digitCount, symbolCount := 0, 0, 0
+				for _, r := range runes {
+					switch {
+					case unicode.IsLetter(r):
+						letters = append(letters, r)
+						if unicode.IsUpper(r) {
+							upper++
+						}
+					case Digits.Contains(r):
+						digitCount++
+					case Symbols.Contains(r):
+						symbolCount++
+					}
+				}
+
+				if tt.allUpper {
+					if upper != len(letters) {
+						t.Errorf("expected only uppercase letters in %q", password)
+					}
+				} else if upper != tt.upperLetters {
+					t.Errorf("expected %d uppercase letters, got %d in %q", tt.upperLetters, upper, password)
+				}
+				if digitCount != tt.digits {
+					t.Errorf("expected %d digits, got %d in %q", tt.digits, digitCount, password)
+				}
+				if symbolCount != tt.symbols {
+					t.Errorf("expected %d symbols, got %d in %q", tt.symbols, symbolCount, password)
+				}
+
+				for i := 1; i < len(letters); i++ {
+					if isVowel(letters[i]) == isVowel(letters[i-1]) {
+						t.Errorf("letters of %q do not alternate between vowels and consonants", password)
+						break
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestNewGeneratorPronounceable(t *testing.T) {
+	tests := []struct {
+		name     string
+		options  []Option
+		errorMsg string
+	}{
+		{
+			name:     "no letters",
+			options:  []Option{WithPronounceable(), WithoutUppercase(), WithoutLowercase()},
+			errorMsg: "pronounceable passwords require uppercase or lowercase letters",
+		},
+		{
+			name:     "vowels excluded",
+			options:  []Option{WithPronounceable(), WithoutAmbiguous(), WithAmbiguousChars(CharsetFromString("aeiou"))},
+			errorMsg: "no vowels or consonants left after excluding ambiguous characters",
+		},
+		{
+			// Only vowels can be capitalized and a two-letter password has one.
+			name: "too few capitalizable letters",
+			options: []Option{
+				WithLength(2), WithPronounceable(), WithMinUppercase(2),
+				WithoutAmbiguous(), WithAmbiguousChars(CharsetFromString("BDFGHJKLMNPRSTVWZ")),
+			},
+			errorMsg: "no pronounceable password of length 2 satisfies the minimum requirements",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewGenerator(tt.options...)
+			if err == nil {
+				t.Fatalf("expected error but got none")
+			}
+			if !strings.Contains(err.Error(), tt.errorMsg) {
+				t.Errorf("expected error message to contain %q, got %q", tt.errorMsg, err.Error())
+			}
+		})
+	}
+}
+
+func TestPronounceableKeyspace(t *testing.T) {
+	tests := []struct {
+		name    string
+		options []Option
+	}{
+		{
+			name:    "letters only",
+			options: []Option{WithLength(3), WithoutDigits(), WithoutSymbols()},
+		},
+		{
+			name:    "capitals and digits",
+			options: []Option{WithLength(3), WithoutSymbols(), WithMinUppercase(1), WithMinDigits(1)},
+		},
+		{
+			name: "without ambiguous",
+			options: []Option{
+				WithLength(3), WithoutSymbols(), WithMinUppercase(2),
+				WithoutAmbiguous(), WithAmbiguousChars(CharsetFromString("BDFGHJ0123456")),
+			},
+		},
+		{
+			name:    "inserted characters only",
+			options: []Option{WithLength(2), WithoutUppercase(), WithMinDigits(1), WithMinSymbols(1)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gen, err := NewGenerator(append(tt.options, WithPronounceable())...)
+			if err != nil {
+				t.Fatalf("failed to create generator: %v", err)
+			}
+
+			expected := bruteForcePronounceable(gen)
+			if got := gen.Keyspace(); got.Cmp(big.NewInt(expected)) != 0 {
+				t.Errorf("expected keyspace %d, got %v", expected, got)
+			}
+		})
+	}
+}
+
+func TestPronounceableUniform(t *testing.T) {
+	const samples = 20000
+
+	// Only s is left of the consonants, and S, I and O cannot be capitalized,
+	// so letter strings differ in how many of their letters can be.
+	gen, err := NewGenerator(
+		WithLength(3), WithPronounceable(), WithoutDigits(), WithoutSymbols(), WithMinUppercase(1),
+		WithoutAmbiguous(), WithAmbiguousChars(CharsetFromString("bdfghjklmnprtvwzSIO")),
+	)
+	if err != nil {
+		t.Fatalf("failed to create generator: %v", err)
+	}
+
+	observed := make(map[string]int)
+	for range samples {
+		password, err := gen.Generate()
+		if err != nil {
+			t.Fatalf("failed to generate password: %v", err)
+		}
+		observed[password]++
+	}
+
+	if n := int64(len(observed)); n != gen.Keyspace().Int64() {
+		t.Errorf("expected %v distinct passwords, got %d", gen.Keyspace(), n)
+	}
+	checkUniform(t, observed, gen.Keyspace().Int64(), samples)
+}
+
+func isVowel(r rune) bool {
+	return strings.ContainsRune("aeiou", unicode.ToLower(r))
+}
+
+// bruteForcePronounceable enumerates every string over the generator's pool
+// and counts the ones the pronounceable mode can produce.
+func bruteForcePronounceable(g *Generator) int64 {
+	s := g.syllables
+	length := g.cfg.length
+
+	insertedOf := make(map[rune]int)
+	for i, class := range s.inserted {
+		for _, r := range class.chars {
+			insertedOf[r] = i
+		}
+	}
+	vowelSet, consonantSet := NewCharset(s.vowels...), NewCharset(s.consonants...)
+
+	var count int64
+	idx := make([]int, length)
+	for {
+		extras := make([]int, len(s.inserted))
+		var letters []rune
+		valid := true
+
+		for _, i := range idx {
+			r := g.charset[i]
+			if class, ok := insertedOf[r]; ok {
+				extras[class]++
+				continue
+			}
+			if unicode.IsUpper(r) && s.capitals > 0 {
+				lower := unicode.ToLower(r)
+				if !s.canCapitalize(lower) {
+					valid = false
+				}
+				r = lower
+			}
+			if !vowelSet.Contains(r) && !consonantSet.Contains(r) {
+				valid = false
+			}
+			letters = append(letters, r)
+		}
+
+		upper := 0
+		for _, i := range idx {
+			if unicode.IsUpper(g.charset[i]) && s.capitals > 0 {
+				upper++
+			}
+		}
+		if upper != s.capitals {
+			valid = false
+		}
+		for i, class := range s.inserted {
+			if extras[i] != class.min {
+				valid = false
+			}
+		}
+		for i := 1; i < len(letters); i++ {
+			if vowelSet.Contains(letters[i]) == vowelSet.Contains(letters[i-1]) {
+				valid = false
+			}
+		}
+		if valid {
+			count++
+		}
+
+		pos := 0
+		for pos < length {
+			idx[pos]++
+			if idx[pos] < len(g.charset) {
+				break
+			}
+			idx[pos] = 0
+			pos++
+		}
+		if pos == length {
+			return count
+		}
+	}
+}
@@ -0,0 +1,837 @@
+package passgen
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"math/big"
+	"slices"
+)
+
+// maxRepetitionAttempts bounds how often a password breaking
+// WithMaxConsecutive or WithMaxSequential is redrawn before it is drawn from
+// the exact repetition sampler instead. Without that sampler it is redrawn up
+// to maxRepetitionRedraws times, which fails about once in e^50 draws for the
+// rarest rules validate accepts.
+const (
+	maxRepetitionAttempts = 1000
+	maxRepetitionRedraws  = 50 * repetitionAcceptance
+)
+
+// maxRepetitionTable bounds the bytes the exact repetition sampler may keep.
+// Rules whose sampler would need more must be met by enough random draws,
+// one in repetitionAcceptance at least, for redrawing to succeed. Counting
+// them may take up to maxRepetitionWork state updates of the dynamic
+// program; rules that need more are rejected.
+const (
+	maxRepetitionTable   = 64 << 20
+	maxRepetitionWork    = 1 << 24
+	repetitionAcceptance = 1000
+)
+
+// drawDistinct fills dst without repeated characters: the minimums are drawn
+// without replacement from their classes, and the remaining characters
+// without replacement from whatever is left of the pool.
+func drawDistinct(r io.Reader, dst []rune, classes []charClass) error {
+	filled := 0
+	var pool []rune
+
+	for _, class := range classes {
+		chars := slices.Clone(class.chars)
+		if err := partialShuffle(r, chars, class.min); err != nil {
+			return err
+		}
+
+		filled += copy(dst[filled:], chars[:class.min])
+		pool = append(pool, chars[class.min:]...)
+	}
+
+	if err := partialShuffle(r, pool, len(dst)-filled); err != nil {
+		return err
+	}
+	copy(dst[filled:], pool)
+
+	return nil
+}
+
+// countDistinct counts the passwords of the given length without repeated
+// characters that meet every minimum. Choosing k_i characters from class i
+// and arranging them gives length! * prod C(|class_i|, k_i), summed over all
+// k_i >= min_i adding up to length; ways[j] accumulates the sum of the
+// products for the classes processed so far with j characters in total.
+func countDistinct(classes []charClass, length int) *big.Int {
+	ways := []*big.Int{big.NewInt(1)}
+
+	for _, class := range classes {
+		next := make([]*big.Int, min(len(ways)+len(class.chars), length+1))
+		for i := range next {
+			next[i] = new(big.Int)
+		}
+
+		for j, w := range ways {
+			if w.Sign() == 0 {
+				continue
+			}
+
+			for k := class.min; k <= len(class.chars) && j+k <= length; k++ {
+				term := new(big.Int).Binomial(int64(len(class.chars)), int64(k))
+				next[j+k].Add(next[j+k], term.Mul(term, w))
+			}
+		}
+
+		ways = next
+	}
+
+	if len(ways) <= length {
+		return new(big.Int)
+	}
+
+	total := new(big.Int).MulRange(1, int64(length))
+	return total.Mul(total, ways[length])
+}
+
+// runKinds describes how the last characters of a password prefix end: in
+// no run, in a run of identical characters or in an ascending or descending
+// sequential run. At most one kind of run can be in progress, since a repeated
+// character ends a sequential run and vice versa. A zero limit disables the
+// corresponding rule and its runs are not tracked.
+type runKinds struct {
+	maxConsecutive int
+	maxSequential  int
+}
+
+// kinds are numbered: 0 for no run, then identical runs of length
+// 2..maxConsecutive, then ascending and descending runs of length
+// 2..maxSequential.
+func (k runKinds) count() int {
+	return 1 + max(k.maxConsecutive-1, 0) + 2*max(k.maxSequential-1, 0)
+}
+
+func (k runKinds) consecutive(length int) int {
+	return length - 1
+}
+
+func (k runKinds) ascending(length int) int {
+	return max(k.maxConsecutive-1, 0) + length - 1
+}
+
+func (k runKinds) descending(length int) int {
+	return max(k.maxConsecutive-1, 0) + max(k.maxSequential-1, 0) + length - 1
+}
+
+// extend returns the kind after appending a character that repeats the last
+// one (step 0) or follows it in ascending (step 1) or descending (step -1)
+// order, and false if that breaks a rule.
+func (k runKinds) extend(kind, step int) (int, bool) {
+	limit, first, kindOf := k.maxSequential, k.consecutive(2), k.consecutive
+	switch step {
+	case 0:
+		limit = k.maxConsecutive
+	case 1:
+		first, kindOf = k.ascending(2), k.ascending
+	case -1:
+		first, kindOf = k.descending(2), k.descending
+	}
+	if limit == 0 {
+		return 0, true
+	}
+
+	length := 2
+	if kind >= first && kind < first+limit-1 {
+		length = kind - first + 3
+	}
+	if length > limit {
+		return 0, false
+	}
+
+	return kindOf(length), true
+}
+
+// countRepetition counts the passwords of the given length that meet every
+// minimum and the repetition rules. allowed optionally restricts positions to
+// given characters, which may lie outside the classes as mask literals do.
+func countRepetition(classes []charClass, allowed [][]rune, length, maxConsecutive, maxSequential int) *big.Int {
+	rep := newRepetition(classes, allowed, length, maxConsecutive, maxSequential)
+	layers := rep.layers(false)
+
+	return rep.complete(layers[len(layers)-1])
+}
+
+// repetition is the dynamic program behind countRepetition and the exact
+// repetition sampler. It runs over prefixes whose state is the number of
+// characters seen from each class, capped at its minimum unless it has a
+// maximum the length can exceed, the last character and the kind of run it
+// ends. Most transitions start no run, so they are added in bulk and the few
+// that extend a run are corrected individually.
+type repetition struct {
+	classes []charClass
+	length  int
+	pool    []rune
+	classOf []int
+	index   map[rune]int
+	// below and above hold the pool index of the character preceding and
+	// following each one, or -1.
+	below, above []int
+	allowed      [][]rune
+	// unrestricted lists the pool characters of the classes, which fill
+	// every position allowed does not restrict.
+	unrestricted []int
+
+	// Counts are encoded in mixed radix. Digit i ranges up to the maximum of
+	// class i if the length can exceed it, and is capped at its minimum
+	// otherwise.
+	strides, caps []int
+	counts        int
+
+	kinds runKinds
+	k     int
+}
+
+func newRepetition(classes []charClass, allowed [][]rune, length, maxConsecutive, maxSequential int) *repetition {
+	rep := &repetition{classes: classes, length: length, allowed: allowed}
+
+	for i, class := range classes {
+		rep.pool = append(rep.pool, class.chars...)
+		for range class.chars {
+			rep.classOf = append(rep.classOf, i)
+		}
+	}
+	for _, chars := range allowed {
+		for _, r := range chars {
+			if !slices.Contains(rep.pool, r) {
+				rep.pool = append(rep.pool, r)
+				rep.classOf = append(rep.classOf, -1)
+			}
+		}
+	}
+
+	rep.index = make(map[rune]int, len(rep.pool))
+	for i, r := range rep.pool {
+		rep.index[r] = i
+	}
+	for _, r := range rep.pool {
+		rep.below = append(rep.below, rep.neighbour(r-1))
+		rep.above = append(rep.above, rep.neighbour(r+1))
+	}
+	for i, class := range rep.classOf {
+		if class >= 0 {
+			rep.unrestricted = append(rep.unrestricted, i)
+		}
+	}
+
+	rep.strides = make([]int, len(classes))
+	rep.caps = make([]int, len(classes))
+	rep.counts = 1
+	for i, class := range classes {
+		rep.caps[i] = class.min
+		if class.max < length {
+			rep.caps[i] = class.max
+		}
+		rep.strides[i] = rep.counts
+		rep.counts *= rep.caps[i] + 1
+	}
+
+	rep.kinds = runKinds{maxConsecutive: maxConsecutive, maxSequential: maxSequential}
+	rep.k = rep.kinds.count()
+
+	return rep
+}
+
+// size returns the number of states of a position.
+func (rep *repetition) size() int {
+	return rep.counts * len(rep.pool) * rep.k
+}
+
+func (rep *repetition) at(v, c, kind int) int {
+	return (v*len(rep.pool)+c)*rep.k + kind
+}
+
+func (rep *repetition) digit(v, class int) int {
+	return v / rep.strides[class] % (rep.caps[class] + 1)
+}
+
+// step returns the counts after a character of the class, or -1 if that
+// exceeds its maximum.
+func (rep *repetition) step(v, class int) int {
+	switch {
+	case class < 0 || rep.digit(v, class) < rep.caps[class]:
+		if class >= 0 {
+			v += rep.strides[class]
+		}
+		return v
+	case rep.classes[class].max < rep.length:
+		return -1
+	}
+	return v
+}
+
+// before returns the counts from which a character of the class leads to v.
+func (rep *repetition) before(v, class int) []int {
+	if class < 0 {
+		return []int{v}
+	}
+
+	var prev []int
+	if rep.digit(v, class) > 0 {
+		prev = append(prev, v-rep.strides[class])
+	}
+	if rep.digit(v, class) == rep.caps[class] && rep.classes[class].max >= rep.length {
+		prev = append(prev, v)
+	}
+	return prev
+}
+
+func (rep *repetition) meetsMinimums(v int) bool {
+	for i, class := range rep.classes {
+		if rep.digit(v, i) < class.min {
+			return false
+		}
+	}
+	return true
+}
+
+func (rep *repetition) neighbour(r rune) int {
+	if i, ok := rep.index[r]; ok {
+		return i
+	}
+	return -1
+}
+
+// follow returns the kind after appending character c to a prefix ending in
+// character f and the given kind, and false if that breaks a rule.
+func (rep *repetition) follow(f, c, kind int) (int, bool) {
+	switch rep.pool[c] - rep.pool[f] {
+	case 0:
+		return rep.kinds.extend(kind, 0)
+	case 1:
+		return rep.kinds.extend(kind, 1)
+	case -1:
+		return rep.kinds.extend(kind, -1)
+	}
+	return 0, true
+}
+
+func (rep *repetition) allowedAt(pos int) []int {
+	if pos >= len(rep.allowed) || rep.allowed[pos] == nil {
+		return rep.unrestricted
+	}
+
+	idx := make([]int, len(rep.allowed[pos]))
+	for i, r := range rep.allowed[pos] {
+		idx[i] = rep.index[r]
+	}
+	return idx
+}
+
+// layers returns the number of prefixes ending in every state, for every
+// prefix length if keep is set and for the full length only otherwise.
+func (rep *repetition) layers(keep bool) [][]big.Int {
+	size := rep.size()
+
+	cur := make([]big.Int, size)
+	for _, c := range rep.allowedAt(0) {
+		if v := rep.step(0, rep.classOf[c]); v >= 0 {
+			cur[rep.at(v, c, 0)].SetInt64(1)
+		}
+	}
+
+	layers := [][]big.Int{cur}
+	var next []big.Int
+	if !keep {
+		next = make([]big.Int, size)
+	}
+
+	total := new(big.Int)
+	perChar := make([]big.Int, len(rep.pool))
+	tmp := new(big.Int)
+
+	for pos := 1; pos < rep.length; pos++ {
+		if keep {
+			next = make([]big.Int, size)
+		} else {
+			for i := range next {
+				next[i].SetInt64(0)
+			}
+		}
+		targets := rep.allowedAt(pos)
+
+		for v := range rep.counts {
+			total.SetInt64(0)
+			for c := range rep.pool {
+				perChar[c].SetInt64(0)
+				for kind := range rep.k {
+					perChar[c].Add(&perChar[c], &cur[rep.at(v, c, kind)])
+				}
+				total.Add(total, &perChar[c])
+			}
+			if total.Sign() == 0 {
+				continue
+			}
+
+			for _, c := range targets {
+				nv := rep.step(v, rep.classOf[c])
+				if nv < 0 {
+					continue
+				}
+				// Repeating c, or reaching it from c-1 or c+1, may extend a run.
+				from := [3]int{c, rep.below[c], rep.above[c]}
+				steps := [3]int{0, 1, -1}
+
+				tmp.Set(total)
+				for _, f := range from {
+					if f >= 0 {
+						tmp.Sub(tmp, &perChar[f])
+					}
+				}
+				next[rep.at(nv, c, 0)].Add(&next[rep.at(nv, c, 0)], tmp)
+
+				for i, f := range from {
+					if f < 0 {
+						continue
+					}
+					for kind := range rep.k {
+						if cur[rep.at(v, f, kind)].Sign() == 0 {
+							continue
+						}
+						nk, ok := rep.kinds.extend(kind, steps[i])
+						if ok {
+							next[rep.at(nv, c, nk)].Add(&next[rep.at(nv, c, nk)], &cur[rep.at(v, f, kind)])
+						}
+					}
+				}
+			}
+		}
+
+		if keep {
+			layers = append(layers, next)
+		} else {
+			layers[0] = next
+			next = cur
+		}
+		cur = layers[len(layers)-1]
+	}
+
+	return layers
+}
+
+// complete returns the number of passwords among the prefixes of the last
+// layer, which are those meeting every minimum.
+func (rep *repetition) complete(last []big.Int) *big.Int {
+	result := new(big.Int)
+	for v := range rep.counts {
+		if !rep.meetsMinimums(v) {
+			continue
+		}
+		for c := range rep.pool {
+			for kind := range rep.k {
+				result.Add(result, &last[rep.at(v, c, kind)])
+			}
+		}
+	}
+
+	return result
+}
+
+// logCount returns the natural logarithm of the number of passwords, or -Inf
+// if there are none. It runs the dynamic program in floating point, scaling
+// every layer to sum to one, which is cheap enough for any table.
+func (rep *repetition) logCount() float64 {
+	size := rep.size()
+	cur, next := make([]float64, size), make([]float64, size)
+	for _, c := range rep.allowedAt(0) {
+		if v := rep.step(0, rep.classOf[c]); v >= 0 {
+			cur[rep.at(v, c, 0)] = 1
+		}
+	}
+
+	scale := 0.0
+	perChar := make([]float64, len(rep.pool))
+	for pos := 1; pos < rep.length; pos++ {
+		sum := 0.0
+		for _, x := range cur {
+			sum += x
+		}
+		if sum == 0 {
+			return math.Inf(-1)
+		}
+		scale += math.Log(sum)
+		for i := range cur {
+			cur[i] /= sum
+		}
+
+		clear(next)
+		targets := rep.allowedAt(pos)
+
+		for v := range rep.counts {
+			total := 0.0
+			for c := range rep.pool {
+				perChar[c] = 0
+				for kind := range rep.k {
+					perChar[c] += cur[rep.at(v, c, kind)]
+				}
+				total += perChar[c]
+			}
+			if total == 0 {
+				continue
+			}
+
+			for _, c := range targets {
+				nv := rep.step(v, rep.classOf[c])
+				if nv < 0 {
+					continue
+				}
+				from := [3]int{c, rep.below[c], rep.above[c]}
+				steps := [3]int{0, 1, -1}
+
+				start := total
+				for i, f := range from {
+					if f < 0 {
+						continue
+					}
+					start -= perChar[f]
+					for kind := range rep.k {
+						if nk, ok := rep.kinds.extend(kind, steps[i]); ok {
+							next[rep.at(nv, c, nk)] += cur[rep.at(v, f, kind)]
+						}
+					}
+				}
+				// Rounding must not make the count negative.
+				next[rep.at(nv, c, 0)] += max(start, 0)
+			}
+		}
+
+		cur, next = next, cur
+	}
+
+	result := 0.0
+	for v := range rep.counts {
+		if !rep.meetsMinimums(v) {
+			continue
+		}
+		for c := range rep.pool {
+			for kind := range rep.k {
+				result += cur[rep.at(v, c, kind)]
+			}
+		}
+	}
+
+	return scale + math.Log(result)
+}
+
+// logCountByClass is logCount for WithMaxConsecutive alone, without
+// positions restricted to given characters. Characters of a class are
+// interchangeable then, so the state holds the class of the last character
+// instead of the character itself, which keeps long passwords cheap.
+func (rep *repetition) logCountByClass() float64 {
+	n := len(rep.classes)
+	at := func(v, class, kind int) int {
+		return (v*n+class)*rep.k + kind
+	}
+
+	size := rep.counts * n * rep.k
+	cur, next := make([]float64, size), make([]float64, size)
+	for i, class := range rep.classes {
+		if v := rep.step(0, i); v >= 0 {
+			cur[at(v, i, 0)] = float64(len(class.chars))
+		}
+	}
+
+	steps := make([]int, rep.counts*n)
+	for v := range rep.counts {
+		for i := range n {
+			steps[v*n+i] = rep.step(v, i)
+		}
+	}
+	extended := make([]int, rep.k)
+	for kind := range rep.k {
+		extended[kind] = -1
+		if nk, ok := rep.kinds.extend(kind, 0); ok {
+			extended[kind] = nk
+		}
+	}
+
+	scale := 0.0
+	for pos := 1; pos < rep.length; pos++ {
+		sum := 0.0
+		for _, x := range cur {
+			sum += x
+		}
+		if sum == 0 {
+			return math.Inf(-1)
+		}
+		scale += math.Log(sum)
+		for i := range cur {
+			cur[i] /= sum
+			// Prefixes this rare cannot change the result noticeably, but
+			// would slow every later layer down to subnormal arithmetic.
+			if cur[i] < 0x1p-960 {
+				cur[i] = 0
+			}
+		}
+
+		clear(next)
+		for v := range rep.counts {
+			for last := range n {
+				for kind := range rep.k {
+					w := cur[at(v, last, kind)]
+					if w == 0 {
+						continue
+					}
+
+					for i, class := range rep.classes {
+						nv := steps[v*n+i]
+						if nv < 0 {
+							continue
+						}
+						// Any other character of the class starts a new run,
+						// while repeating the last one extends it.
+						ways := len(class.chars)
+						if i == last {
+							ways--
+							if nk := extended[kind]; nk >= 0 {
+								next[at(nv, i, nk)] += w
+							}
+						}
+						next[at(nv, i, 0)] += w * float64(ways)
+					}
+				}
+			}
+		}
+
+		cur, next = next, cur
+	}
+
+	result := 0.0
+	for v := range rep.counts {
+		if !rep.meetsMinimums(v) {
+			continue
+		}
+		for i := range n * rep.k {
+			result += cur[v*n*rep.k+i]
+		}
+	}
+
+	return scale + math.Log(result)
+}
+
+// work returns the number of state updates of the dynamic program.
+func (rep *repetition) work() float64 {
+	return float64(rep.length) * float64(rep.size())
+}
+
+// classWork returns the number of state updates of logCountByClass.
+func (rep *repetition) classWork() float64 {
+	return float64(rep.length) * float64(rep.counts*len(rep.classes)*rep.k)
+}
+
+// byClass reports whether logCountByClass applies to the rules.
+func (rep *repetition) byClass() bool {
+	return rep.kinds.maxSequential == 0 && rep.allowed == nil
+}
+
+// tableBytes estimates the memory a repetitionSampler keeps: a big number
+// below pool^length, plus its four-word header, for every state of every
+// position.
+func (rep *repetition) tableBytes() float64 {
+	words := math.Ceil(float64(rep.length)*math.Log2(float64(len(rep.pool)+1))/64) + 4
+	return rep.work() * words * 8
+}
+
+// repetitionSampler draws passwords that meet the repetition rules exactly
+// uniformly, for rules that random draws rarely meet. It keeps the layers of
+// the dynamic program and walks them backwards from the last position,
+// picking each state with probability proportional to the number of prefixes
+// ending in it.
+type repetitionSampler struct {
+	rep    *repetition
+	layers [][]big.Int
+	total  *big.Int
+}
+
+// newRepetitionSampler returns the sampler for the rules, or nil if its table
+// would exceed maxRepetitionTable.
+func newRepetitionSampler(classes []charClass, allowed [][]rune, length, maxConsecutive, maxSequential int) *repetitionSampler {
+	rep := newRepetition(classes, allowed, length, maxConsecutive, maxSequential)
+	if rep.tableBytes() > maxRepetitionTable {
+		return nil
+	}
+
+	layers := rep.layers(true)
+	return &repetitionSampler{rep: rep, layers: layers, total: rep.complete(layers[len(layers)-1])}
+}
+
+func (s *repetitionSampler) draw(r io.Reader, pass []rune) error {
+	rep := s.rep
+	if s.total.Sign() == 0 {
+		return errors.New("no password meets the repetition rules")
+	}
+
+	x, err := rand.Int(r, s.total)
+	if err != nil {
+		return fmt.Errorf("failed to read random bytes: %w", err)
+	}
+
+	// Pick the last state among those meeting every minimum.
+	var v, c, kind int
+	last := s.layers[rep.length-1]
+pick:
+	for v = range rep.counts {
+		if !rep.meetsMinimums(v) {
+			continue
+		}
+		for c = range rep.pool {
+			for kind = range rep.k {
+				if x.Sub(x, &last[rep.at(v, c, kind)]).Sign() < 0 {
+					break pick
+				}
+			}
+		}
+	}
+
+	for pos := rep.length - 1; pos > 0; pos-- {
+		pass[pos] = rep.pool[c]
+
+		x, err := rand.Int(r, &s.layers[pos][rep.at(v, c, kind)])
+		if err != nil {
+			return fmt.Errorf("failed to read random bytes: %w", err)
+		}
+
+		prev := s.layers[pos-1]
+	predecessor:
+		for _, pv := range rep.before(v, rep.classOf[c]) {
+			for f := range rep.pool {
+				for pk := range rep.k {
+					w := &prev[rep.at(pv, f, pk)]
+					if w.Sign() == 0 {
+						continue
+					}
+					if nk, ok := rep.follow(f, c, pk); !ok || nk != kind {
+						continue
+					}
+					if x.Sub(x, w).Sign() < 0 {
+						v, c, kind = pv, f, pk
+						break predecessor
+					}
+				}
+			}
+		}
+	}
+	pass[0] = rep.pool[c]
+
+	return nil
+}
+
+// repetitionShares returns the natural logarithms of the shares of the
+// passwords meeting the repetition rules among those draw produces and among
+// those drawNeighbours produces, which is -Inf where drawNeighbours does not
+// apply. ok is false if counting them would take more than
+// maxRepetitionWork state updates.
+func (c *config) repetitionShares(classes []charClass) (draw, neighbours float64, ok bool) {
+	allowed := c.allowed(classes, c.length)
+	rep := newRepetition(classes, allowed, c.length, c.maxConsecutive, c.maxSequential)
+	neighbours = math.Inf(-1)
+
+	if !rep.byClass() {
+		if rep.work() > maxRepetitionWork {
+			return 0, 0, false
+		}
+		draw = rep.logCount() - newRepetition(classes, allowed, c.length, 0, 0).logCount()
+		return draw, neighbours, true
+	}
+
+	if rep.classWork() > maxRepetitionWork {
+		return 0, 0, false
+	}
+	valid := rep.logCountByClass()
+	draw = valid - log2Classes(classes, c.length, false)*math.Ln2
+
+	if pool := len(rep.pool); c.maxConsecutive == 1 && c.weights == nil && pool > 1 {
+		neighbours = valid - math.Log(float64(pool)) - float64(c.length-1)*math.Log(float64(pool-1))
+	}
+
+	return draw, neighbours, true
+}
+
+// drawNeighbours fills pass uniformly from the strings over pool without two
+// equal neighbours: the first character is drawn from the whole pool and
+// every other one from the pool without its predecessor.
+func drawNeighbours(r io.Reader, pass []rune, pool []rune) error {
+	prev := -1
+	for i := range pass {
+		n := len(pool)
+		if prev >= 0 {
+			n--
+		}
+
+		idx, err := randomIndex(r, n)
+		if err != nil {
+			return err
+		}
+		if prev >= 0 && idx >= prev {
+			idx++
+		}
+
+		pass[i] = pool[idx]
+		prev = idx
+	}
+
+	return nil
+}
+
+// checkRepetition reports the longest run breaking each repetition rule and
+// the characters used more than once when repeats are forbidden.
+func (g *Generator) checkRepetition(password []rune) []Violation {
+	var violations []Violation
+
+	if n := g.cfg.maxConsecutive; n > 0 {
+		if run := longestRun(password, func(a, b rune) bool { return a == b }); len(run) > n {
+			violations = append(violations, Violation{Rule: RuleMaxConsecutive, Want: n, Got: len(run), Chars: string(run)})
+		}
+	}
+
+	if n := g.cfg.maxSequential; n > 0 {
+		up := longestRun(password, func(a, b rune) bool { return b == a+1 })
+		down := longestRun(password, func(a, b rune) bool { return b == a-1 })
+		if len(down) > len(up) {
+			up = down
+		}
+		if len(up) > n {
+			violations = append(violations, Violation{Rule: RuleMaxSequential, Want: n, Got: len(up), Chars: string(up)})
+		}
+	}
+
+	if g.cfg.noRepeats {
+		seen := make(map[rune]bool, len(password))
+		var repeated []rune
+		for _, r := range password {
+			if seen[r] {
+				repeated = append(repeated, r)
+			}
+			seen[r] = true
+		}
+		if len(repeated) > 0 {
+			violations = append(violations, Violation{Rule: RuleNoRepeats, Chars: NewCharset(repeated...).String()})
+		}
+	}
+
+	return violations
+}
+
+// longestRun returns the first longest run of password in which every
+// character follows its predecessor.
+func longestRun(password []rune, follows func(prev, next rune) bool) []rune {
+	var best []rune
+	start := 0
+	for i := range password {
+		if i > 0 && !follows(password[i-1], password[i]) {
+			start = i
+		}
+		if i+1-start > len(best) {
+			best = password[start : i+1]
+		}
+	}
+
+	return best
+}
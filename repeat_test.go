@@ -0,0 +1,347 @@
+package passgen
+
+import (
+	"crypto/rand"
+	"errors"
+	"math"
+	"math/big"
+	"strings"
+	"testing"
+)
+
+func TestRepetitionRules(t *testing.T) {
+	tests := []struct {
+		name    string
+		options []Option
+		length  int
+	}{
+		{
+			name:    "max consecutive",
+			options: []Option{WithLength(40), WithoutUppercase(), WithoutLowercase(), WithoutSymbols(), WithMaxConsecutive(1)},
+			length:  40,
+		},
+		{
+			name:    "max sequential",
+			options: []Option{WithLength(20), WithoutUppercase(), WithoutLowercase(), WithoutSymbols(), WithMaxSequential(2)},
+			length:  20,
+		},
+		{
+			name:    "max consecutive rarely met",
+			options: []Option{WithLength(100), WithoutUppercase(), WithoutLowercase(), WithoutSymbols(), WithMaxConsecutive(1)},
+			length:  100,
+		},
+		{
+			name:    "max consecutive with minimums on a long password",
+			options: []Option{WithLength(1000), WithMaxConsecutive(1), WithMinRequirements(5, 5, 5, 5)},
+			length:  1000,
+		},
+		{
+			name:    "max consecutive with a minimum above the expected count",
+			options: []Option{WithLength(500), WithMaxConsecutive(1), WithMinDigits(100)},
+			length:  500,
+		},
+		{
+			name:    "max sequential rarely met",
+			options: []Option{WithLength(40), WithoutUppercase(), WithoutLowercase(), WithoutSymbols(), WithMaxSequential(1)},
+			length:  40,
+		},
+		{
+			name:    "no repeats",
+			options: []Option{WithLength(10), WithoutUppercase(), WithoutLowercase(), WithoutSymbols(), WithNoRepeats()},
+			length:  10,
+		},
+		{
+			name:    "no repeats with minimums",
+			options: []Option{WithLength(30), WithMinRequirements(5, 5, 5, 5), WithNoRepeats()},
+			length:  30,
+		},
+		{
+			name: "no repeats with first character",
+			options: []Option{
+				WithLength(12), WithoutUppercase(), WithoutSymbols(), WithMinDigits(9),
+				WithNoRepeats(), WithFirstChar(ClassDigits), WithLastChar(ClassDigits),
+			},
+			length: 12,
+		},
+		{
+			name:    "all rules with a mask",
+			options: []Option{WithMask("?d?d?d?d?d?d"), WithMaxConsecutive(1), WithMaxSequential(1)},
+			length:  6,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gen, err := NewGenerator(tt.options...)
+			if err != nil {
+				t.Fatalf("failed to create generator: %v", err)
+			}
+
+			for range 200 {
+				password, err := gen.Generate()
+				if err != nil {
+					t.Fatalf("failed to generate password: %v", err)
+				}
+				if n := len([]rune(password)); n != tt.length {
+					t.Errorf("expected length %d, got %d: %q", tt.length, n, password)
+				}
+				if err := gen.Validate(password); err != nil {
+					t.Errorf("password %q does not satisfy the policy: %v", password, err)
+				}
+			}
+		})
+	}
+}
+
+func TestRepetitionKeyspace(t *testing.T) {
+	small := []Option{WithoutUppercase(), WithoutLowercase(), WithoutSymbols(), WithCharset("abc", CharsetFromString("abc"), 0)}
+
+	tests := []struct {
+		name    string
+		options []Option
+	}{
+		{
+			name:    "max consecutive",
+			options: []Option{WithLength(4), WithMaxConsecutive(2)},
+		},
+		{
+			name:    "max sequential",
+			options: []Option{WithLength(4), WithMaxSequential(2)},
+		},
+		{
+			name:    "both rules with minimums",
+			options: []Option{WithLength(4), WithMaxConsecutive(1), WithMaxSequential(2), WithMinDigits(2), WithCharset("abc", CharsetFromString("abc"), 1)},
+		},
+		{
+			name:    "with first and last character",
+			options: []Option{WithLength(4), WithMaxSequential(1), WithFirstChar("abc"), WithLastChar(ClassDigits)},
+		},
+		{
+			name:    "with a mask",
+			options: []Option{WithMask("?d?d-?{abc}?{abc}"), WithMaxConsecutive(1), WithMaxSequential(2)},
+		},
+		{
+			name:    "no repeats",
+			options: []Option{WithLength(4), WithNoRepeats(), WithMinDigits(1), WithCharset("abc", CharsetFromString("abc"), 2)},
+		},
+		{
+			name:    "no repeats with first and last character",
+			options: []Option{WithLength(4), WithNoRepeats(), WithCharset("abc", CharsetFromString("abc"), 2), WithFirstChar("abc"), WithLastChar("abc")},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gen, err := NewGenerator(append(small, tt.options...)...)
+			if err != nil {
+				t.Fatalf("failed to create generator: %v", err)
+			}
+
+			expected := bruteForceValid(gen)
+			if got := gen.Keyspace(); got.Cmp(big.NewInt(expected)) != 0 {
+				t.Errorf("expected keyspace %d, got %v", expected, got)
+			}
+		})
+	}
+}
+
+func TestRepetitionSamplerUniform(t *testing.T) {
+	const samples = 20000
+
+	gen, err := NewGenerator(
+		WithLength(5), WithoutUppercase(), WithoutLowercase(), WithoutSymbols(),
+		WithoutAmbiguous(), WithAmbiguousChars(CharsetFromString("0156789")),
+		WithCharset("abc", CharsetFromString("abc"), 1), WithFirstChar("abc"),
+		WithMaxConsecutive(1), WithMaxSequential(2),
+	)
+	if err != nil {
+		t.Fatalf("failed to create generator: %v", err)
+	}
+
+	cfg := gen.cfg
+	s := newRepetitionSampler(gen.classes, cfg.allowed(gen.classes, cfg.length), cfg.length, cfg.maxConsecutive, cfg.maxSequential)
+	if s == nil {
+		t.Fatal("expected a repetition sampler")
+	}
+
+	observed := make(map[string]int)
+	pass := make([]rune, cfg.length)
+	for range samples {
+		if err := s.draw(rand.Reader, pass); err != nil {
+			t.Fatalf("failed to draw password: %v", err)
+		}
+		if err := gen.Validate(string(pass)); err != nil {
+			t.Fatalf("password %q does not satisfy the policy: %v", string(pass), err)
+		}
+		observed[string(pass)]++
+	}
+
+	checkUniform(t, observed, gen.Keyspace().Int64(), samples)
+}
+
+func TestNeighboursUniform(t *testing.T) {
+	gen, err := NewGenerator(WithLength(4), WithoutUppercase(), WithoutLowercase(), WithoutSymbols(), WithoutDigits(),
+		WithCharset("abcd", CharsetFromString("abcd"), 0), WithMaxConsecutive(1))
+	if err != nil {
+		t.Fatalf("failed to create generator: %v", err)
+	}
+
+	keyspace := gen.Keyspace()
+	samples := 40 * int(keyspace.Int64())
+	observed := make(map[string]int)
+	pass := make([]rune, 4)
+	for range samples {
+		if err := drawNeighbours(rand.Reader, pass, gen.charset); err != nil {
+			t.Fatalf("failed to draw password: %v", err)
+		}
+		if err := gen.Validate(string(pass)); err != nil {
+			t.Fatalf("password %q does not satisfy the policy: %v", string(pass), err)
+		}
+		observed[string(pass)]++
+	}
+
+	checkUniform(t, observed, keyspace.Int64(), samples)
+}
+
+func TestLongMaxConsecutive(t *testing.T) {
+	gen, err := NewGenerator(WithLength(10000), WithMaxConsecutive(1), WithMinDigits(100))
+	if err != nil {
+		t.Fatalf("failed to create generator: %v", err)
+	}
+
+	password, err := gen.Generate()
+	if err != nil {
+		t.Fatalf("failed to generate password: %v", err)
+	}
+	if err := gen.Validate(password); err != nil {
+		t.Errorf("generated password fails validation: %v", err)
+	}
+
+	// Nearly every string without two equal neighbours has 100 digits.
+	expected := math.Log2(88) + 9999*math.Log2(87)
+	if got := gen.Entropy(); math.Abs(got-expected) > 1e-6 {
+		t.Errorf("expected entropy %v, got %v", expected, got)
+	}
+}
+
+func TestNewGeneratorRepetitionRules(t *testing.T) {
+	tests := []struct {
+		name     string
+		options  []Option
+		errorMsg string
+	}{
+		{
+			name:     "negative max consecutive",
+			options:  []Option{WithMaxConsecutive(-1)},
+			errorMsg: "maximum consecutive identical characters cannot be negative, got -1",
+		},
+		{
+			name:     "negative max sequential",
+			options:  []Option{WithMaxSequential(-2)},
+			errorMsg: "maximum sequential characters cannot be negative, got -2",
+		},
+		{
+			name:     "single character pool",
+			options:  []Option{WithLength(3), WithoutUppercase(), WithoutLowercase(), WithoutSymbols(), WithoutAmbiguous(), WithAmbiguousChars(CharsetFromString("012345678")), WithMaxConsecutive(2)},
+			errorMsg: "a single available character cannot fill 3 positions with at most 2 in a row",
+		},
+		{
+			name:     "repetition rules too rarely met",
+			options:  []Option{WithLength(10000), WithoutUppercase(), WithoutLowercase(), WithoutSymbols(), WithMaxSequential(2)},
+			errorMsg: "passwords of length 10000 meets the repetition rules, too few to draw one at random",
+		},
+		{
+			name:     "no repeats longer than the pool",
+			options:  []Option{WithLength(11), WithoutUppercase(), WithoutLowercase(), WithoutSymbols(), WithNoRepeats()},
+			errorMsg: "password length 11 exceeds the 10 available characters without repeats",
+		},
+		{
+			name:     "no repeats with a minimum larger than its class",
+			options:  []Option{WithLength(12), WithMinDigits(11), WithNoRepeats()},
+			errorMsg: `charset "digits" has 10 characters but minimum requirement is 11 without repeats`,
+		},
+		{
+			name:     "no repeats with max sequential",
+			options:  []Option{WithNoRepeats(), WithMaxSequential(2)},
+			errorMsg: "unique characters cannot be combined with a maximum sequential run",
+		},
+		{
+			name:     "no repeats with a mask",
+			options:  []Option{WithNoRepeats(), WithMask("?d?d")},
+			errorMsg: "unique characters cannot be combined with a mask",
+		},
+		{
+			name:     "repetition rules with pronounceable",
+			options:  []Option{WithPronounceable(), WithMaxConsecutive(2)},
+			errorMsg: "repetition rules cannot be combined with pronounceable passwords",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewGenerator(tt.options...)
+			if err == nil {
+				t.Fatalf("expected error but got none")
+			}
+			if !strings.Contains(err.Error(), tt.errorMsg) {
+				t.Errorf("expected error message to contain %q, got %q", tt.errorMsg, err.Error())
+			}
+		})
+	}
+}
+
+func TestGenerateUnsatisfiableRepetitionRules(t *testing.T) {
+	// Two adjacent letters are either identical or sequential.
+	gen, err := NewGenerator(
+		WithLength(8), WithoutUppercase(), WithoutDigits(), WithoutSymbols(),
+		WithoutAmbiguous(), WithAmbiguousChars(CharsetFromString("cdefghijklmnopqrstuvwxyz")),
+		WithMaxConsecutive(1), WithMaxSequential(1),
+	)
+	if err != nil {
+		t.Fatalf("failed to create generator: %v", err)
+	}
+
+	if _, err := gen.Generate(); err == nil || !strings.Contains(err.Error(), "no password meets the repetition rules") {
+		t.Errorf("expected repetition failure, got %v", err)
+	}
+	if gen.Keyspace().Sign() != 0 {
+		t.Errorf("expected an empty keyspace, got %v", gen.Keyspace())
+	}
+}
+
+func TestValidateRepetitionRules(t *testing.T) {
+	gen, err := NewGenerator(WithLength(8), WithMaxConsecutive(2), WithMaxSequential(3))
+	if err != nil {
+		t.Fatalf("failed to create generator: %v", err)
+	}
+
+	err = gen.Validate("aaab4321")
+
+	var policyErr *PolicyError
+	if !errors.As(err, &policyErr) {
+		t.Fatalf("expected *PolicyError, got %v", err)
+	}
+
+	expected := []Violation{
+		{Rule: RuleMaxConsecutive, Want: 2, Got: 3, Chars: "aaa"},
+		{Rule: RuleMaxSequential, Want: 3, Got: 4, Chars: "4321"},
+	}
+	if len(policyErr.Violations) != len(expected) {
+		t.Fatalf("expected violations %+v, got %+v", expected, policyErr.Violations)
+	}
+	for i, v := range expected {
+		if policyErr.Violations[i] != v {
+			t.Errorf("expected violation %+v, got %+v", v, policyErr.Violations[i])
+		}
+	}
+
+	gen, err = NewGenerator(WithLength(4), WithNoRepeats())
+	if err != nil {
+		t.Fatalf("failed to create generator: %v", err)
+	}
+
+	expectedMsg := `password violates policy: password must not repeat characters, got "ab" more than once`
+	if err := gen.Validate("abcaxb"); err == nil || err.Error() != expectedMsg {
+		t.Errorf("expected %q, got %v", expectedMsg, err)
+	}
+}
@@ -0,0 +1,131 @@
+package passgen
+
+import (
+	"sync"
+	"testing"
+)
+
+func testSeed(s string) [32]byte {
+	var seed [32]byte
+	copy(seed[:], s)
+	return seed
+}
+
+func TestNewSeededGenerator(t *testing.T) {
+	tests := []struct {
+		name     string
+		options  []Option
+		expected []string
+	}{
+		{
+			name:     "default",
+			expected: []string{"nK!-&p.cP9{JO!>+", "t;9XUmF,5gl9&z.h", "k.*]XW.ZXI}K0bl3"},
+		},
+		{
+			name:     "with min requirements",
+			options:  []Option{WithLength(12), WithMinRequirements(2, 2, 2, 2)},
+			expected: []string{"Ay1g*6KPQ.we"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gen, err := NewSeededGenerator(testSeed("passgen test seed"), tt.options...)
+			if err != nil {
+				t.Fatalf("failed to create generator: %v", err)
+			}
+
+			for _, expected := range tt.expected {
+				password, err := gen.Generate()
+				if err != nil {
+					t.Fatalf("failed to generate password: %v", err)
+				}
+				if password != expected {
+					t.Errorf("expected %q, got %q", expected, password)
+				}
+			}
+		})
+	}
+}
+
+func TestNewSeededGeneratorSeeds(t *testing.T) {
+	first, err := NewSeededGenerator(testSeed("first"))
+	if err != nil {
+		t.Fatalf("failed to create generator: %v", err)
+	}
+	again, err := NewSeededGenerator(testSeed("first"))
+	if err != nil {
+		t.Fatalf("failed to create generator: %v", err)
+	}
+	second, err := NewSeededGenerator(testSeed("second"))
+	if err != nil {
+		t.Fatalf("failed to create generator: %v", err)
+	}
+
+	var batches [3][]string
+	for i, gen := range []*Generator{first, again, second} {
+		if batches[i], err = gen.GenerateN(20); err != nil {
+			t.Fatalf("failed to generate passwords: %v", err)
+		}
+	}
+
+	a, b, c := batches[0], batches[1], batches[2]
+	for i := range a {
+		if a[i] != b[i] {
+			t.Errorf("expected the same seed to give %q at %d, got %q", a[i], i, b[i])
+		}
+		if a[i] == c[i] {
+			t.Errorf("expected different seeds to differ at %d, both gave %q", i, a[i])
+		}
+	}
+}
+
+func TestNewSeededGeneratorGenerateN(t *testing.T) {
+	single, err := NewSeededGenerator(testSeed("batch"))
+	if err != nil {
+		t.Fatalf("failed to create generator: %v", err)
+	}
+	batch, err := NewSeededGenerator(testSeed("batch"))
+	if err != nil {
+		t.Fatalf("failed to create generator: %v", err)
+	}
+
+	passwords, err := batch.GenerateN(5)
+	if err != nil {
+		t.Fatalf("failed to generate passwords: %v", err)
+	}
+	for i, got := range passwords {
+		expected, err := single.Generate()
+		if err != nil {
+			t.Fatalf("failed to generate password: %v", err)
+		}
+		if got != expected {
+			t.Errorf("expected password %d of the batch to be %q, got %q", i, expected, got)
+		}
+	}
+}
+
+func TestNewSeededGeneratorConcurrent(t *testing.T) {
+	gen, err := NewSeededGenerator(testSeed("concurrent"))
+	if err != nil {
+		t.Fatalf("failed to create generator: %v", err)
+	}
+
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Go(func() {
+			for range 100 {
+				if _, err := gen.Generate(); err != nil {
+					t.Errorf("failed to generate password: %v", err)
+				}
+			}
+		})
+	}
+	wg.Wait()
+}
+
+func TestNewSeededGeneratorInvalidOptions(t *testing.T) {
+	if _, err := NewSeededGenerator(testSeed("invalid"), WithLength(0)); err == nil {
+		t.Error("expected error but got none")
+	}
+}
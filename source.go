@@ -0,0 +1,135 @@
+package passgen
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math/bits"
+)
+
+// source buffers random bytes and hands out indices bit by bit, so a password
+// costs a read or two from the underlying reader instead of eight bytes per
+// character and per shuffle swap.
+//
+// Bytes left in the buffer are discarded with the source rather than carried
+// over to the next password, so a deterministic reader gives the same
+// passwords however the generator is called.
+type source struct {
+	r   io.Reader
+	buf []byte
+	// buf[pos:end] holds the bytes not consumed yet.
+	pos, end int
+	// started records that the reader has delivered bytes, which turns its
+	// end into io.ErrUnexpectedEOF.
+	started bool
+
+	// The low n bits of word are random bits not consumed yet.
+	word uint64
+	n    uint
+}
+
+func newSource(r io.Reader, size int) *source {
+	return &source{r: r, buf: make([]byte, size)}
+}
+
+// reset points the source at r and forgets every buffered byte and bit.
+func (s *source) reset(r io.Reader) {
+	clear(s.buf)
+	*s = source{r: r, buf: s.buf}
+}
+
+func (s *source) Read(p []byte) (int, error) {
+	if s.pos == s.end {
+		if err := s.fill(); err != nil {
+			return 0, err
+		}
+	}
+
+	n := copy(p, s.buf[s.pos:s.end])
+	s.pos += n
+
+	return n, nil
+}
+
+func (s *source) fill() error {
+	n, err := io.ReadAtLeast(s.r, s.buf, 1)
+	if err != nil {
+		if err == io.EOF && s.started {
+			err = io.ErrUnexpectedEOF
+		}
+		return fmt.Errorf("failed to read random bytes: %w", err)
+	}
+
+	s.pos, s.end = 0, n
+	s.started = true
+
+	return nil
+}
+
+func (s *source) byte() (byte, error) {
+	if s.pos == s.end {
+		if err := s.fill(); err != nil {
+			return 0, err
+		}
+	}
+
+	b := s.buf[s.pos]
+	s.pos++
+
+	return b, nil
+}
+
+func (s *source) uint64() (uint64, error) {
+	var v [8]byte
+	for i := range v {
+		b, err := s.byte()
+		if err != nil {
+			return 0, err
+		}
+		v[i] = b
+	}
+
+	return binary.LittleEndian.Uint64(v[:]), nil
+}
+
+// bits returns the next k random bits, 0 < k <= 56.
+func (s *source) bits(k uint) (uint64, error) {
+	for s.n < k {
+		b, err := s.byte()
+		if err != nil {
+			return 0, err
+		}
+		s.word |= uint64(b) << s.n
+		s.n += 8
+	}
+
+	v := s.word & (1<<k - 1)
+	s.word >>= k
+	s.n -= k
+
+	return v, nil
+}
+
+// index returns a uniformly distributed integer in [0, n). It draws just
+// enough bits to cover n and rejects values past it, which happens less than
+// half the time, so an index costs under 2*log2(n) + 2 bits on average.
+func (s *source) index(n int) (int, error) {
+	if n == 1 {
+		return 0, nil
+	}
+
+	k := uint(bits.Len(uint(n - 1)))
+	if k > 56 {
+		return uniformIndex(s.uint64, n)
+	}
+
+	for {
+		v, err := s.bits(k)
+		if err != nil {
+			return 0, err
+		}
+		if v < uint64(n) {
+			return int(v), nil
+		}
+	}
+}
@@ -0,0 +1,139 @@
+package passgen
+
+import (
+	"bytes"
+	"crypto/rand"
+	"errors"
+	"io"
+	"runtime"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestSourceIndexDistribution(t *testing.T) {
+	const samples = 50000
+
+	for _, n := range []int{2, 10, 94, 100, 1000} {
+		s := newSource(rand.Reader, 64)
+
+		observed := make([]int, n)
+		for range samples {
+			i, err := s.index(n)
+			if err != nil {
+				t.Fatalf("failed to draw index: %v", err)
+			}
+			observed[i]++
+		}
+
+		expected := float64(samples) / float64(n)
+		chi2 := 0.0
+		for _, o := range observed {
+			chi2 += (float64(o) - expected) * (float64(o) - expected) / expected
+		}
+		if critical := chiSquareCritical(n-1, chiSquareZ); chi2 > critical {
+			t.Errorf("indices in [0, %d) deviate from uniform: chi2 = %.2f > %.2f", n, chi2, critical)
+		}
+	}
+}
+
+func TestSourceBitsPerIndex(t *testing.T) {
+	const draws = 10000
+
+	counter := &countingReader{r: rand.Reader}
+	s := newSource(counter, 64)
+	for range draws {
+		if _, err := s.index(94); err != nil {
+			t.Fatalf("failed to draw index: %v", err)
+		}
+	}
+
+	// 7 bits per attempt and 128/94 attempts on average.
+	if bits := float64(counter.n*8) / draws; bits > 10.5 {
+		t.Errorf("expected about 9.5 bits per index, used %.2f", bits)
+	}
+}
+
+func TestSourceReadErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		reader   io.Reader
+		errorMsg string
+	}{
+		{
+			name:     "empty reader",
+			reader:   bytes.NewReader(nil),
+			errorMsg: "failed to read random bytes: EOF",
+		},
+		{
+			name:     "reader ends mid-draw",
+			reader:   bytes.NewReader([]byte{1, 2, 3}),
+			errorMsg: "failed to read random bytes: unexpected EOF",
+		},
+		{
+			name:     "reader error",
+			reader:   errReader{err: errors.New("device unplugged")},
+			errorMsg: "failed to read random bytes: device unplugged",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newSource(tt.reader, 64)
+			_, err := s.uint64()
+			if err == nil {
+				t.Fatalf("expected error but got none")
+			}
+			if !strings.Contains(err.Error(), tt.errorMsg) {
+				t.Errorf("expected error message to contain %q, got %q", tt.errorMsg, err.Error())
+			}
+		})
+	}
+}
+
+func TestGenerateCallPatterns(t *testing.T) {
+	gen, err := NewSeededGenerator([32]byte{7}, WithLength(8))
+	if err != nil {
+		t.Fatalf("failed to create generator: %v", err)
+	}
+	other, err := NewSeededGenerator([32]byte{7}, WithLength(8))
+	if err != nil {
+		t.Fatalf("failed to create generator: %v", err)
+	}
+
+	// Leftover bytes are dropped with every password, so neither the method
+	// used nor the pool losing its buffers to a collection changes the
+	// sequence.
+	var first, second []string
+	for i := range 3 {
+		password, err := gen.Generate()
+		if err != nil {
+			t.Fatalf("failed to generate password: %v", err)
+		}
+		first = append(first, password)
+
+		if i == 1 {
+			runtime.GC()
+		}
+		bytes, err := other.GenerateBytes()
+		if err != nil {
+			t.Fatalf("failed to generate password: %v", err)
+		}
+		second = append(second, string(bytes))
+	}
+
+	if !slices.Equal(first, second) {
+		t.Errorf("expected %q, got %q", first, second)
+	}
+}
+
+type countingReader struct {
+	r io.Reader
+	n int
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += n
+	return n, err
+}
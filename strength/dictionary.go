@@ -0,0 +1,88 @@
+package strength
+
+import (
+	"bufio"
+	_ "embed"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/haadi-coder/passgen"
+)
+
+// passwordList holds common passwords, most common first.
+//
+//go:embed passwords.txt
+var passwordList string
+
+// nameList and surnameList hold common first names and surnames, most common
+// first.
+var (
+	//go:embed names.txt
+	nameList string
+	//go:embed surnames.txt
+	surnameList string
+)
+
+// dictionary ranks lowercase words by the number of guesses needed to find
+// them.
+type dictionary struct {
+	name  string
+	ranks map[string]int
+	// longest is the length in runes of the longest word.
+	longest int
+}
+
+func newDictionary(name string) *dictionary {
+	return &dictionary{name: name, ranks: make(map[string]int)}
+}
+
+func (d *dictionary) add(word string, rank int) {
+	word = strings.ToLower(word)
+	if word == "" {
+		return
+	}
+	if _, ok := d.ranks[word]; ok {
+		return
+	}
+
+	d.ranks[word] = rank
+	d.longest = max(d.longest, utf8.RuneCountInString(word))
+}
+
+// rankedDictionary returns the dictionary of a list with one word per line,
+// most common first.
+func rankedDictionary(name, list string) *dictionary {
+	d := newDictionary(name)
+	scanner := bufio.NewScanner(strings.NewReader(list))
+	for rank := 1; scanner.Scan(); rank++ {
+		d.add(strings.TrimSpace(scanner.Text()), rank)
+	}
+
+	return d
+}
+
+var defaultDictionaries = sync.OnceValue(func() []*dictionary {
+	passwords := rankedDictionary(DictionaryPasswords, passwordList)
+	names := rankedDictionary(DictionaryNames, nameList)
+	surnames := rankedDictionary(DictionarySurnames, surnameList)
+
+	// The EFF words are not ordered by frequency, so an attacker needs as
+	// many guesses for any of them as there are words.
+	english := newDictionary(DictionaryEnglish)
+	words := passgen.EFFLargeWordlist()
+	for _, word := range words {
+		english.add(word, len(words))
+	}
+
+	return []*dictionary{passwords, names, surnames, english}
+})
+
+func newUserInputsDictionary(inputs []string) *dictionary {
+	d := newDictionary(DictionaryUserInputs)
+	for i, input := range inputs {
+		d.add(input, i+1)
+	}
+
+	return d
+}
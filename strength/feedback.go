@@ -0,0 +1,125 @@
+package strength
+
+import (
+	"slices"
+	"unicode"
+	"unicode/utf8"
+)
+
+// feedback explains a weak password by its longest match. Passwords scoring
+// 3 or more get none.
+func feedback(score int, sequence []Match) Feedback {
+	if len(sequence) == 0 {
+		return Feedback{Suggestions: []string{
+			"Use a few words, avoid common phrases",
+			"No need for symbols, digits, or uppercase letters",
+		}}
+	}
+	if score > 2 {
+		return Feedback{}
+	}
+
+	longest := sequence[0]
+	for _, m := range sequence[1:] {
+		if utf8.RuneCountInString(m.Token) > utf8.RuneCountInString(longest.Token) {
+			longest = m
+		}
+	}
+
+	fb := matchFeedback(longest, len(sequence) == 1)
+	fb.Suggestions = append([]string{"Add another word or two. Uncommon words are better."}, fb.Suggestions...)
+
+	return fb
+}
+
+func matchFeedback(m Match, sole bool) Feedback {
+	switch m.Pattern {
+	case PatternDictionary:
+		return dictionaryFeedback(m, sole)
+	case PatternSpatial:
+		warning := "Short keyboard patterns are easy to guess"
+		if m.Turns == 1 {
+			warning = "Straight rows of keys are easy to guess"
+		}
+		return Feedback{
+			Warning:     warning,
+			Suggestions: []string{"Use a longer keyboard pattern with more turns"},
+		}
+	case PatternRepeat:
+		warning := `Repeats like "abcabcabc" are only slightly harder to guess than "abc"`
+		if utf8.RuneCountInString(m.BaseToken) == 1 {
+			warning = `Repeats like "aaa" are easy to guess`
+		}
+		return Feedback{
+			Warning:     warning,
+			Suggestions: []string{"Avoid repeated words and characters"},
+		}
+	case PatternSequence:
+		return Feedback{
+			Warning:     "Sequences like abc or 6543 are easy to guess",
+			Suggestions: []string{"Avoid sequences"},
+		}
+	case PatternYear:
+		return Feedback{
+			Warning:     "Recent years are easy to guess",
+			Suggestions: []string{"Avoid recent years", "Avoid years that are associated with you"},
+		}
+	case PatternDate:
+		return Feedback{
+			Warning:     "Dates are often easy to guess",
+			Suggestions: []string{"Avoid dates and years that are associated with you"},
+		}
+	default:
+		return Feedback{}
+	}
+}
+
+func dictionaryFeedback(m Match, sole bool) Feedback {
+	var fb Feedback
+
+	switch m.Dictionary {
+	case DictionaryPasswords:
+		switch {
+		case sole && !m.L33t && !m.Reversed && m.Rank <= 10:
+			fb.Warning = "This is a top-10 common password"
+		case sole && !m.L33t && !m.Reversed && m.Rank <= 100:
+			fb.Warning = "This is a top-100 common password"
+		case sole && !m.L33t && !m.Reversed:
+			fb.Warning = "This is a very common password"
+		default:
+			fb.Warning = "This is similar to a commonly used password"
+		}
+	case DictionaryNames, DictionarySurnames:
+		if sole {
+			fb.Warning = "Names and surnames by themselves are easy to guess"
+		} else {
+			fb.Warning = "Common names and surnames are easy to guess"
+		}
+	case DictionaryEnglish:
+		if sole {
+			fb.Warning = "A word by itself is easy to guess"
+		}
+	case DictionaryUserInputs:
+		fb.Warning = "Avoid words and names that are associated with you"
+	}
+
+	token := []rune(m.Token)
+	switch {
+	case unicode.IsUpper(token[0]) && !isUpper(token):
+		fb.Suggestions = append(fb.Suggestions, "Capitalization doesn't help very much")
+	case isUpper(token) && string(toLower(token)) != m.Token:
+		fb.Suggestions = append(fb.Suggestions, "All-uppercase is almost as easy to guess as all-lowercase")
+	}
+	if m.Reversed && len(token) >= 4 {
+		fb.Suggestions = append(fb.Suggestions, "Reversed words aren't much harder to guess")
+	}
+	if m.L33t {
+		fb.Suggestions = append(fb.Suggestions, "Predictable substitutions like '@' instead of 'a' don't help very much")
+	}
+
+	return fb
+}
+
+func isUpper(s []rune) bool {
+	return !slices.ContainsFunc(s, unicode.IsLower)
+}
@@ -0,0 +1,118 @@
+package strength
+
+import (
+	"strings"
+	"sync"
+)
+
+// Keyboard names reported in Match.Graph.
+const (
+	GraphQwerty = "qwerty"
+	GraphKeypad = "keypad"
+)
+
+// qwertyLayout lists the keys of every row, unshifted character first. Each
+// row starts half a key to the right of the one above.
+var qwertyLayout = []string{
+	"`~ 1! 2@ 3# 4$ 5% 6^ 7& 8* 9( 0) -_ =+",
+	"qQ wW eE rR tT yY uU iI oO pP [{ ]} \\|",
+	"aA sS dD fF gG hH jJ kK lL ;: '\"",
+	"zZ xX cC vV bB nN mM ,< .> /?",
+}
+
+// keypadLayout lists the keys of a numeric keypad, with a space where a
+// column has no key. Rows are aligned.
+var keypadLayout = []string{
+	" /*-",
+	"789+",
+	"456",
+	"123",
+	" 0.",
+}
+
+// graph links every key of a keyboard to its neighbours.
+type graph struct {
+	name string
+	// adjacent lists, for every character, the keys next to its key in a
+	// fixed order of directions, nil where there is none. Every key lists
+	// its unshifted character first.
+	adjacent map[rune][][]rune
+	// shifted holds the characters typed with shift.
+	shifted map[rune]bool
+	// averageDegree is the average number of neighbours of a key.
+	averageDegree float64
+}
+
+type position struct {
+	x, y int
+}
+
+var graphs = sync.OnceValue(func() []*graph {
+	qwerty := make(map[position][]rune)
+	for y, row := range qwertyLayout {
+		// The first key of every row below the top one sits between the
+		// first two keys of the row above.
+		offset := min(y, 1)
+		for x, key := range strings.Fields(row) {
+			qwerty[position{x + offset, y}] = []rune(key)
+		}
+	}
+
+	keypad := make(map[position][]rune)
+	for y, row := range keypadLayout {
+		for x, key := range row {
+			if key != ' ' {
+				keypad[position{x, y}] = []rune{key}
+			}
+		}
+	}
+
+	return []*graph{
+		newGraph(GraphQwerty, qwerty, func(p position) []position {
+			return []position{
+				{p.x - 1, p.y}, {p.x, p.y - 1}, {p.x + 1, p.y - 1},
+				{p.x + 1, p.y}, {p.x, p.y + 1}, {p.x - 1, p.y + 1},
+			}
+		}),
+		newGraph(GraphKeypad, keypad, func(p position) []position {
+			return []position{
+				{p.x - 1, p.y}, {p.x - 1, p.y - 1}, {p.x, p.y - 1}, {p.x + 1, p.y - 1},
+				{p.x + 1, p.y}, {p.x + 1, p.y + 1}, {p.x, p.y + 1}, {p.x - 1, p.y + 1},
+			}
+		}),
+	}
+})
+
+func newGraph(name string, keys map[position][]rune, neighbours func(position) []position) *graph {
+	g := &graph{name: name, adjacent: make(map[rune][][]rune), shifted: make(map[rune]bool)}
+
+	degrees := 0
+	for pos, key := range keys {
+		var adjacent [][]rune
+		for _, n := range neighbours(pos) {
+			adjacent = append(adjacent, keys[n])
+			if keys[n] != nil {
+				degrees++
+			}
+		}
+		for _, r := range key {
+			g.adjacent[r] = adjacent
+		}
+		if len(key) == 2 {
+			g.shifted[key[1]] = true
+		}
+	}
+	g.averageDegree = float64(degrees) / float64(len(keys))
+
+	return g
+}
+
+func graphByName(name string) *graph {
+	for _, g := range graphs() {
+		if g.name == name {
+			return g
+		}
+	}
+
+	return nil
+}
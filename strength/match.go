@@ -0,0 +1,703 @@
+package strength
+
+import (
+	"cmp"
+	"maps"
+	"math"
+	"slices"
+	"strconv"
+	"unicode"
+)
+
+// omnimatch returns every match of every pattern in password, ordered by
+// position.
+func omnimatch(password []rune, dicts []*dictionary) []Match {
+	var matches []Match
+	matches = append(matches, dictionaryMatches(password, dicts)...)
+	matches = append(matches, reverseDictionaryMatches(password, dicts)...)
+	matches = append(matches, l33tMatches(password, dicts)...)
+	matches = append(matches, spatialMatches(password)...)
+	matches = append(matches, repeatMatches(password, dicts)...)
+	matches = append(matches, sequenceMatches(password)...)
+	matches = append(matches, yearMatches(password)...)
+	matches = append(matches, dateMatches(password)...)
+
+	slices.SortStableFunc(matches, func(a, b Match) int {
+		return cmp.Or(cmp.Compare(a.I, b.I), cmp.Compare(a.J, b.J))
+	})
+
+	return matches
+}
+
+// dictionaryMatches returns every substring of password that is a word of one
+// of dicts, ignoring case.
+func dictionaryMatches(password []rune, dicts []*dictionary) []Match {
+	lower := toLower(password)
+
+	var matches []Match
+	for _, d := range dicts {
+		for i := range lower {
+			for j := i; j < len(lower) && j-i < d.longest; j++ {
+				word := string(lower[i : j+1])
+				rank, ok := d.ranks[word]
+				if !ok {
+					continue
+				}
+
+				matches = append(matches, Match{
+					Pattern:     PatternDictionary,
+					I:           i,
+					J:           j,
+					Token:       string(password[i : j+1]),
+					Dictionary:  d.name,
+					MatchedWord: word,
+					Rank:        rank,
+				})
+			}
+		}
+	}
+
+	return matches
+}
+
+// reverseDictionaryMatches returns the dictionary words spelled backwards in
+// password.
+func reverseDictionaryMatches(password []rune, dicts []*dictionary) []Match {
+	reversed := slices.Clone(password)
+	slices.Reverse(reversed)
+
+	var matches []Match
+	for _, m := range dictionaryMatches(reversed, dicts) {
+		i, j := len(password)-1-m.J, len(password)-1-m.I
+		// Palindromes already match forwards.
+		if string(toLower(password[i:j+1])) == m.MatchedWord {
+			continue
+		}
+
+		m.I, m.J = i, j
+		m.Token = string(password[i : j+1])
+		m.Reversed = true
+		matches = append(matches, m)
+	}
+
+	return matches
+}
+
+// l33tTable lists the characters commonly substituted for each letter.
+var l33tTable = map[rune][]rune{
+	'a': []rune("4@"),
+	'b': []rune("8"),
+	'c': []rune("({[<"),
+	'e': []rune("3"),
+	'g': []rune("69"),
+	'i': []rune("1!|"),
+	'l': []rune("1|7"),
+	'o': []rune("0"),
+	's': []rune("$5"),
+	't': []rune("+7"),
+	'x': []rune("%"),
+	'z': []rune("2"),
+}
+
+// l33tMatches returns the dictionary words spelled with substitutions in
+// password. A character that stands for several letters, like 1 for i or l,
+// is tried as each of them.
+func l33tMatches(password []rune, dicts []*dictionary) []Match {
+	lower := toLower(password)
+
+	type key struct {
+		i, j       int
+		dictionary string
+		word       string
+	}
+	seen := make(map[key]bool)
+	var matches []Match
+	for _, sub := range l33tSubs(lower) {
+		translated := make([]rune, len(lower))
+		for i, r := range lower {
+			translated[i] = r
+			if letter, ok := sub[r]; ok {
+				translated[i] = letter
+			}
+		}
+
+		for _, m := range dictionaryMatches(translated, dicts) {
+			token := password[m.I : m.J+1]
+			// Single characters are better explained as brute force.
+			if len(token) <= 1 {
+				continue
+			}
+
+			used := make(map[rune]rune)
+			for _, r := range lower[m.I : m.J+1] {
+				if letter, ok := sub[r]; ok {
+					used[r] = letter
+				}
+			}
+			if len(used) == 0 {
+				continue
+			}
+
+			k := key{m.I, m.J, m.Dictionary, m.MatchedWord}
+			if seen[k] {
+				continue
+			}
+			seen[k] = true
+
+			m.Token = string(token)
+			m.L33t = true
+			m.Sub = used
+			matches = append(matches, m)
+		}
+	}
+
+	return matches
+}
+
+// l33tSubs returns every way to read the substituted characters of password
+// as letters.
+func l33tSubs(password []rune) []map[rune]rune {
+	letters := make(map[rune][]rune)
+	for _, letter := range slices.Sorted(maps.Keys(l33tTable)) {
+		for _, sub := range l33tTable[letter] {
+			if slices.Contains(password, sub) {
+				letters[sub] = append(letters[sub], letter)
+			}
+		}
+	}
+	if len(letters) == 0 {
+		return nil
+	}
+
+	subs := []map[rune]rune{{}}
+	for _, sub := range slices.Sorted(maps.Keys(letters)) {
+		var next []map[rune]rune
+		for _, s := range subs {
+			for _, letter := range letters[sub] {
+				m := maps.Clone(s)
+				m[sub] = letter
+				next = append(next, m)
+			}
+		}
+		subs = next
+	}
+
+	return subs
+}
+
+// spatialMatches returns the keyboard walks of at least three characters in
+// password on every graph.
+func spatialMatches(password []rune) []Match {
+	var matches []Match
+	for _, g := range graphs() {
+		matches = append(matches, graphMatches(password, g)...)
+	}
+
+	return matches
+}
+
+func graphMatches(password []rune, g *graph) []Match {
+	var matches []Match
+
+	for i := 0; i < len(password)-1; {
+		j := i + 1
+		lastDirection := -1
+		turns := 0
+		shifted := 0
+		if g.shifted[password[i]] {
+			shifted = 1
+		}
+
+		for {
+			found := false
+			if j < len(password) {
+				for direction, key := range g.adjacent[password[j-1]] {
+					pos := slices.Index(key, password[j])
+					if pos < 0 {
+						continue
+					}
+
+					found = true
+					if pos == 1 {
+						shifted++
+					}
+					if direction != lastDirection {
+						turns++
+						lastDirection = direction
+					}
+					break
+				}
+			}
+
+			if found {
+				j++
+				continue
+			}
+
+			if j-i > 2 {
+				matches = append(matches, Match{
+					Pattern:      PatternSpatial,
+					I:            i,
+					J:            j - 1,
+					Token:        string(password[i:j]),
+					Graph:        g.name,
+					Turns:        turns,
+					ShiftedCount: shifted,
+				})
+			}
+			i = j
+			break
+		}
+	}
+
+	return matches
+}
+
+// repeatMatches returns the runs of a repeated substring in password, like
+// "aaa" or "abcabc". Every run uses the base that covers the most characters,
+// reduced to its shortest repeating unit.
+func repeatMatches(password []rune, dicts []*dictionary) []Match {
+	var matches []Match
+
+	for i := 0; i < len(password); {
+		span := 0
+		for size := 1; 2*size <= len(password)-i; size++ {
+			reps := 1
+			for end := i + (reps+1)*size; end <= len(password) && slices.Equal(password[i:i+size], password[end-size:end]); end += size {
+				reps++
+			}
+			if reps >= 2 && reps*size > span {
+				span = reps * size
+			}
+		}
+
+		if span == 0 {
+			i++
+			continue
+		}
+
+		run := password[i : i+span]
+		base := run[:period(run)]
+		baseGuesses, _ := mostGuessableSequence(base, omnimatch(base, dicts), false)
+		matches = append(matches, Match{
+			Pattern:     PatternRepeat,
+			I:           i,
+			J:           i + span - 1,
+			Token:       string(run),
+			BaseToken:   string(base),
+			BaseGuesses: baseGuesses,
+			RepeatCount: span / len(base),
+		})
+		i += span
+	}
+
+	return matches
+}
+
+// period returns the length of the shortest substring that s repeats.
+func period(s []rune) int {
+	for size := 1; size < len(s); size++ {
+		if len(s)%size != 0 {
+			continue
+		}
+		repeats := true
+		for k := size; k < len(s) && repeats; k += size {
+			repeats = slices.Equal(s[:size], s[k:k+size])
+		}
+		if repeats {
+			return size
+		}
+	}
+
+	return len(s)
+}
+
+// maxSequenceDelta is the largest step between consecutive characters of a
+// sequence, as in "aceg" or "9630".
+const maxSequenceDelta = 5
+
+// sequenceMatches returns the runs of characters with a constant step in
+// password, like "abcd", "7531" or "ZYX".
+func sequenceMatches(password []rune) []Match {
+	if len(password) < 2 {
+		return nil
+	}
+
+	var matches []Match
+	add := func(i, j, delta int) {
+		if j-i <= 1 && abs(delta) != 1 {
+			return
+		}
+		if delta == 0 || abs(delta) > maxSequenceDelta {
+			return
+		}
+
+		token := password[i : j+1]
+		name, space := "unicode", 26
+		switch {
+		case all(token, func(r rune) bool { return r >= 'a' && r <= 'z' }):
+			name, space = "lower", 26
+		case all(token, func(r rune) bool { return r >= 'A' && r <= 'Z' }):
+			name, space = "upper", 26
+		case all(token, func(r rune) bool { return r >= '0' && r <= '9' }):
+			name, space = "digits", 10
+		}
+
+		matches = append(matches, Match{
+			Pattern:       PatternSequence,
+			I:             i,
+			J:             j,
+			Token:         string(token),
+			SequenceName:  name,
+			SequenceSpace: space,
+			Ascending:     delta > 0,
+		})
+	}
+
+	i := 0
+	lastDelta := int(password[1] - password[0])
+	for k := 2; k < len(password); k++ {
+		delta := int(password[k] - password[k-1])
+		if delta == lastDelta {
+			continue
+		}
+		add(i, k-1, lastDelta)
+		i, lastDelta = k-1, delta
+	}
+	add(i, len(password)-1, lastDelta)
+
+	return matches
+}
+
+// yearMatches returns the years from 1900 to 2099 in password.
+func yearMatches(password []rune) []Match {
+	var matches []Match
+	for i := 0; i+4 <= len(password); i++ {
+		token := password[i : i+4]
+		if !all(token, isDigit) || (string(token[:2]) != "19" && string(token[:2]) != "20") {
+			continue
+		}
+
+		year, _ := strconv.Atoi(string(token))
+		matches = append(matches, Match{
+			Pattern: PatternYear,
+			I:       i,
+			J:       i + 3,
+			Token:   string(token),
+			Year:    year,
+		})
+	}
+
+	return matches
+}
+
+const (
+	dateMinYear = 1000
+	dateMaxYear = 2050
+)
+
+// dateSplits lists, for every length of a date without separators, the
+// offsets at which it can be cut into three numbers.
+var dateSplits = map[int][][2]int{
+	4: {{1, 2}, {2, 3}},         // 1191 or 1911
+	5: {{1, 3}, {2, 3}},         // 1 11 91 or 11 1 91
+	6: {{1, 2}, {2, 4}, {4, 5}}, // 1 1 1991, 11 11 91 or 1991 1 1
+	7: {{1, 3}, {2, 3}, {4, 5}, {4, 6}},
+	8: {{2, 4}, {4, 6}}, // 11 11 1991 or 1991 11 11
+}
+
+// dateMatches returns the dates in password, with or without separators, in
+// any order of day, month and year. Dates inside longer dates are dropped.
+func dateMatches(password []rune) []Match {
+	var matches []Match
+
+	// Without separators, the cut with the most recent year wins.
+	for i := range password {
+		for j := i + 3; j <= i+7 && j < len(password); j++ {
+			token := password[i : j+1]
+			if !all(token, isDigit) {
+				continue
+			}
+
+			var best *date
+			for _, split := range dateSplits[len(token)] {
+				d, ok := dateFromInts([3]int{
+					atoi(token[:split[0]]),
+					atoi(token[split[0]:split[1]]),
+					atoi(token[split[1]:]),
+				})
+				if ok && (best == nil || abs(d.year-referenceYear) < abs(best.year-referenceYear)) {
+					best = &d
+				}
+			}
+			if best != nil {
+				matches = append(matches, dateMatch(password, i, j, "", *best))
+			}
+		}
+	}
+
+	// With separators: 1-4 digits, a separator, 1-2 digits, the same
+	// separator and 1-4 digits.
+	for i := range password {
+		for j := i + 5; j <= i+9 && j < len(password); j++ {
+			parts, sep, ok := splitDate(password[i : j+1])
+			if !ok {
+				continue
+			}
+			if d, ok := dateFromInts(parts); ok {
+				matches = append(matches, dateMatch(password, i, j, sep, d))
+			}
+		}
+	}
+
+	return slices.DeleteFunc(matches, func(m Match) bool {
+		return slices.ContainsFunc(matches, func(other Match) bool {
+			return (other.I != m.I || other.J != m.J) && other.I <= m.I && other.J >= m.J
+		})
+	})
+}
+
+type date struct {
+	year, month, day int
+}
+
+func dateMatch(password []rune, i, j int, sep string, d date) Match {
+	return Match{
+		Pattern:   PatternDate,
+		I:         i,
+		J:         j,
+		Token:     string(password[i : j+1]),
+		Separator: sep,
+		Year:      d.year,
+		Month:     d.month,
+		Day:       d.day,
+	}
+}
+
+func splitDate(token []rune) ([3]int, string, bool) {
+	var parts [3]int
+
+	first := slices.IndexFunc(token, func(r rune) bool { return !isDigit(r) })
+	if first < 1 || first > 4 || !isDateSeparator(token[first]) {
+		return parts, "", false
+	}
+	rest := token[first+1:]
+	second := slices.Index(rest, token[first])
+	if second < 1 || second > 2 {
+		return parts, "", false
+	}
+	last := rest[second+1:]
+	if len(last) < 1 || len(last) > 4 || !all(rest[:second], isDigit) || !all(last, isDigit) {
+		return parts, "", false
+	}
+
+	parts = [3]int{atoi(token[:first]), atoi(rest[:second]), atoi(last)}
+	return parts, string(token[first]), true
+}
+
+func isDateSeparator(r rune) bool {
+	return r == '/' || r == '\\' || r == '_' || r == '.' || r == '-' || unicode.IsSpace(r)
+}
+
+// dateFromInts reads three numbers as a date: a year first or last, and a day
+// and month in either order. Two digit years are read as 1951-2050.
+func dateFromInts(ints [3]int) (date, bool) {
+	if ints[1] > 31 || ints[1] <= 0 {
+		return date{}, false
+	}
+
+	over12, over31, under1 := 0, 0, 0
+	for _, n := range ints {
+		if (n > 99 && n < dateMinYear) || n > dateMaxYear {
+			return date{}, false
+		}
+		if n > 31 {
+			over31++
+		}
+		if n > 12 {
+			over12++
+		}
+		if n <= 0 {
+			under1++
+		}
+	}
+	if over31 >= 2 || over12 == 3 || under1 >= 2 {
+		return date{}, false
+	}
+
+	splits := []struct {
+		year int
+		rest [2]int
+	}{
+		{ints[2], [2]int{ints[0], ints[1]}},
+		{ints[0], [2]int{ints[1], ints[2]}},
+	}
+
+	for _, s := range splits {
+		if s.year >= dateMinYear && s.year <= dateMaxYear {
+			day, month, ok := dayMonth(s.rest)
+			return date{year: s.year, month: month, day: day}, ok
+		}
+	}
+
+	for _, s := range splits {
+		if day, month, ok := dayMonth(s.rest); ok {
+			return date{year: fourDigitYear(s.year), month: month, day: day}, true
+		}
+	}
+
+	return date{}, false
+}
+
+func dayMonth(ints [2]int) (int, int, bool) {
+	for _, dm := range [][2]int{ints, {ints[1], ints[0]}} {
+		if dm[0] >= 1 && dm[0] <= 31 && dm[1] >= 1 && dm[1] <= 12 {
+			return dm[0], dm[1], true
+		}
+	}
+
+	return 0, 0, false
+}
+
+func fourDigitYear(year int) int {
+	switch {
+	case year > 99:
+		return year
+	case year > 50:
+		return 1900 + year
+	default:
+		return 2000 + year
+	}
+}
+
+// dictionaryGuesses multiplies the rank of the word by the ways to capitalize
+// and substitute it, and doubles it when it is reversed.
+func dictionaryGuesses(m *Match) float64 {
+	guesses := float64(m.Rank) * uppercaseVariations(m.Token) * l33tVariations(m)
+	if m.Reversed {
+		guesses *= 2
+	}
+
+	return guesses
+}
+
+// uppercaseVariations returns the number of ways to capitalize a word that an
+// attacker tries before reaching token's. Capitalizing the first or last
+// letter or all of them are the obvious ones.
+func uppercaseVariations(token string) float64 {
+	runes := []rune(token)
+	upper, lower := 0, 0
+	for _, r := range runes {
+		switch {
+		case unicode.IsUpper(r):
+			upper++
+		case unicode.IsLower(r):
+			lower++
+		}
+	}
+
+	switch {
+	case upper == 0:
+		return 1
+	case lower == 0:
+		return 2
+	case upper == 1 && (unicode.IsUpper(runes[0]) || unicode.IsUpper(runes[len(runes)-1])):
+		return 2
+	}
+
+	variations := 0.0
+	for i := 1; i <= min(upper, lower); i++ {
+		variations += binomial(upper+lower, i)
+	}
+
+	return variations
+}
+
+// l33tVariations returns the number of ways to substitute the letters of a
+// word that an attacker tries before reaching m's.
+func l33tVariations(m *Match) float64 {
+	if !m.L33t {
+		return 1
+	}
+
+	lower := toLower([]rune(m.Token))
+	variations := 1.0
+	for subbed, letter := range m.Sub {
+		s, u := 0, 0
+		for _, r := range lower {
+			switch r {
+			case subbed:
+				s++
+			case letter:
+				u++
+			}
+		}
+
+		if s == 0 || u == 0 {
+			// Substituting all or none of the letters doubles the guesses.
+			variations *= 2
+			continue
+		}
+
+		possibilities := 0.0
+		for i := 1; i <= min(s, u); i++ {
+			possibilities += binomial(s+u, i)
+		}
+		variations *= possibilities
+	}
+
+	return variations
+}
+
+// spatialGuesses counts the walks of the same length with at most as many
+// turns from every key, and the ways to shift their characters.
+func spatialGuesses(m *Match) float64 {
+	g := graphByName(m.Graph)
+	length := len([]rune(m.Token))
+	starts := float64(len(g.adjacent))
+	degree := g.averageDegree
+
+	guesses := 0.0
+	for i := 2; i <= length; i++ {
+		for j := 1; j <= min(m.Turns, i-1); j++ {
+			guesses += binomial(i-1, j-1) * starts * math.Pow(degree, float64(j))
+		}
+	}
+
+	if s := m.ShiftedCount; s > 0 {
+		u := length - s
+		if u == 0 {
+			guesses *= 2
+		} else {
+			variations := 0.0
+			for i := 1; i <= min(s, u); i++ {
+				variations += binomial(s+u, i)
+			}
+			guesses *= variations
+		}
+	}
+
+	return guesses
+}
+
+func toLower(s []rune) []rune {
+	lower := make([]rune, len(s))
+	for i, r := range s {
+		lower[i] = unicode.ToLower(r)
+	}
+
+	return lower
+}
+
+func all(s []rune, f func(rune) bool) bool {
+	return !slices.ContainsFunc(s, func(r rune) bool { return !f(r) })
+}
+
+func isDigit(r rune) bool {
+	return r >= '0' && r <= '9'
+}
+
+func atoi(s []rune) int {
+	n, _ := strconv.Atoi(string(s))
+	return n
+}
@@ -0,0 +1,263 @@
+package strength
+
+import (
+	"maps"
+	"testing"
+)
+
+// find returns the matches of pattern in password.
+func find(password, pattern string, userInputs ...string) []Match {
+	dicts := append(defaultDictionaries(), newUserInputsDictionary(userInputs))
+
+	var matches []Match
+	for _, m := range omnimatch([]rune(password), dicts) {
+		if m.Pattern == pattern {
+			matches = append(matches, m)
+		}
+	}
+
+	return matches
+}
+
+// findToken returns the match of pattern whose token is token.
+func findToken(t *testing.T, password, pattern, token string, userInputs ...string) Match {
+	t.Helper()
+
+	for _, m := range find(password, pattern, userInputs...) {
+		if m.Token == token {
+			return m
+		}
+	}
+	t.Fatalf("expected a %s match %q in %q", pattern, token, password)
+
+	return Match{}
+}
+
+func TestDictionaryMatch(t *testing.T) {
+	m := findToken(t, "xxPassWordxx", PatternDictionary, "PassWord")
+	if m.I != 2 || m.J != 9 || m.MatchedWord != "password" || m.Dictionary != DictionaryPasswords || m.Rank != 2 {
+		t.Errorf("unexpected match %+v", m)
+	}
+
+	if m := findToken(t, "kcatspay", PatternDictionary, "kcats"); !m.Reversed || m.MatchedWord != "stack" {
+		t.Errorf("expected reversed %q, got %+v", "stack", m)
+	}
+
+	if m := findToken(t, "xxsmithxx", PatternDictionary, "smith"); m.Dictionary != DictionarySurnames || m.Rank != 1 {
+		t.Errorf("expected the most common surname, got %+v", m)
+	}
+
+	var inputs []Match
+	for _, m := range find("bob.smith77", PatternDictionary, "Bob", "Smith") {
+		if m.Dictionary == DictionaryUserInputs {
+			inputs = append(inputs, m)
+		}
+	}
+	if len(inputs) != 2 || inputs[1].Token != "smith" || inputs[1].Rank != 2 {
+		t.Errorf("expected both user inputs, got %+v", inputs)
+	}
+}
+
+func TestL33tMatch(t *testing.T) {
+	m := findToken(t, "p4$$w0rd", PatternDictionary, "p4$$w0rd")
+	expected := map[rune]rune{'4': 'a', '$': 's', '0': 'o'}
+	if !m.L33t || m.MatchedWord != "password" || !maps.Equal(m.Sub, expected) {
+		t.Errorf("expected %q with %v, got %+v", "password", expected, m)
+	}
+
+	// 1 stands for both i and l.
+	if m := findToken(t, "1ily", PatternDictionary, "1ily"); m.MatchedWord != "lily" || m.Sub['1'] != 'l' {
+		t.Errorf("expected %q, got %+v", "lily", m)
+	}
+}
+
+func TestSpatialMatch(t *testing.T) {
+	tests := []struct {
+		password string
+		graph    string
+		turns    int
+		shifted  int
+	}{
+		{password: "asdfgh", graph: GraphQwerty, turns: 1},
+		{password: "qazxsw", graph: GraphQwerty, turns: 3},
+		{password: "!QAZ", graph: GraphQwerty, turns: 1, shifted: 4},
+		{password: "1478963", graph: GraphKeypad, turns: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.password, func(t *testing.T) {
+			m := findToken(t, tt.password, PatternSpatial, tt.password)
+			if m.Graph != tt.graph || m.Turns != tt.turns || m.ShiftedCount != tt.shifted {
+				t.Errorf("expected %s with %d turns and %d shifted, got %s with %d and %d", tt.graph, tt.turns, tt.shifted, m.Graph, m.Turns, m.ShiftedCount)
+			}
+		})
+	}
+
+	if matches := find("qwz", PatternSpatial); len(matches) != 0 {
+		t.Errorf("expected no walk in %q, got %+v", "qwz", matches)
+	}
+}
+
+func TestRepeatMatch(t *testing.T) {
+	tests := []struct {
+		password string
+		token    string
+		base     string
+		count    int
+	}{
+		{password: "xaaay", token: "aaa", base: "a", count: 3},
+		{password: "abcabcabc", token: "abcabcabc", base: "abc", count: 3},
+		{password: "abababab", token: "abababab", base: "ab", count: 4},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.password, func(t *testing.T) {
+			m := findToken(t, tt.password, PatternRepeat, tt.token)
+			if m.BaseToken != tt.base || m.RepeatCount != tt.count {
+				t.Errorf("expected %q repeated %d times, got %q repeated %d times", tt.base, tt.count, m.BaseToken, m.RepeatCount)
+			}
+		})
+	}
+}
+
+func TestSequenceMatch(t *testing.T) {
+	tests := []struct {
+		password  string
+		token     string
+		name      string
+		ascending bool
+	}{
+		{password: "xabcdx", token: "abcd", name: "lower", ascending: true},
+		{password: "ZYXW", token: "ZYXW", name: "upper"},
+		{password: "97531", token: "97531", name: "digits"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.password, func(t *testing.T) {
+			m := findToken(t, tt.password, PatternSequence, tt.token)
+			if m.SequenceName != tt.name || m.Ascending != tt.ascending {
+				t.Errorf("expected %s ascending %v, got %s ascending %v", tt.name, tt.ascending, m.SequenceName, m.Ascending)
+			}
+		})
+	}
+
+	if matches := find("a7z", PatternSequence); len(matches) != 0 {
+		t.Errorf("expected no sequence in %q, got %+v", "a7z", matches)
+	}
+}
+
+func TestDateMatch(t *testing.T) {
+	tests := []struct {
+		password  string
+		separator string
+		year      int
+		month     int
+		day       int
+	}{
+		{password: "13/05/1994", separator: "/", year: 1994, month: 5, day: 13},
+		{password: "1994-05-13", separator: "-", year: 1994, month: 5, day: 13},
+		{password: "19940513", year: 1994, month: 5, day: 13},
+		{password: "130594", year: 1994, month: 5, day: 13},
+		{password: "1.1.99", separator: ".", year: 1999, month: 1, day: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.password, func(t *testing.T) {
+			m := findToken(t, tt.password, PatternDate, tt.password)
+			if m.Separator != tt.separator || m.Year != tt.year || m.Month != tt.month || m.Day != tt.day {
+				t.Errorf("expected %d-%02d-%02d with %q, got %d-%02d-%02d with %q", tt.year, tt.month, tt.day, tt.separator, m.Year, m.Month, m.Day, m.Separator)
+			}
+		})
+	}
+
+	// Dates inside longer dates are dropped.
+	if matches := find("13/05/1994", PatternDate); len(matches) != 1 {
+		t.Errorf("expected one date, got %+v", matches)
+	}
+	for _, password := range []string{"1.2-99", "99/99/99", "0000"} {
+		if matches := find(password, PatternDate); len(matches) != 0 {
+			t.Errorf("expected no date in %q, got %+v", password, matches)
+		}
+	}
+}
+
+func TestGuesses(t *testing.T) {
+	tests := []struct {
+		name     string
+		match    Match
+		expected float64
+	}{
+		{
+			name:     "dictionary",
+			match:    Match{Pattern: PatternDictionary, Token: "password", Rank: 2},
+			expected: 2,
+		},
+		{
+			name:     "capitalized and reversed",
+			match:    Match{Pattern: PatternDictionary, Token: "Drowssap", Rank: 2, Reversed: true},
+			expected: 8,
+		},
+		{
+			name:     "mixed case",
+			match:    Match{Pattern: PatternDictionary, Token: "PaSsword", Rank: 2},
+			expected: 2 * (8 + 28),
+		},
+		{
+			name:     "l33t with some letters substituted",
+			match:    Match{Pattern: PatternDictionary, Token: "a@@a", Rank: 1, L33t: true, Sub: map[rune]rune{'@': 'a'}},
+			expected: 4 + 6,
+		},
+		{
+			name:     "sequence from an obvious start",
+			match:    Match{Pattern: PatternSequence, Token: "abcd", Ascending: true},
+			expected: 4 * 4,
+		},
+		{
+			name:     "descending digits",
+			match:    Match{Pattern: PatternSequence, Token: "8642"},
+			expected: 20 * 4,
+		},
+		{
+			name:     "repeat",
+			match:    Match{Pattern: PatternRepeat, Token: "abcabc", BaseGuesses: 30, RepeatCount: 2},
+			expected: 60,
+		},
+		{
+			name:     "date with separator",
+			match:    Match{Pattern: PatternDate, Token: "1-1-" + "1900", Year: referenceYear - 100, Separator: "-"},
+			expected: 100 * 365 * 4,
+		},
+		{
+			name:     "recent year",
+			match:    Match{Pattern: PatternYear, Token: "2020", Year: referenceYear - 1},
+			expected: minYearSpace,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := tt.match
+			m.J = len([]rune(m.Token)) - 1
+			if got := estimateGuesses(&m, m.J+1); got != tt.expected {
+				t.Errorf("expected %v guesses, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestSpatialGuesses(t *testing.T) {
+	// A straight walk of four keys takes s * d guesses for each of its three
+	// prefixes of two or more keys.
+	g := graphByName(GraphQwerty)
+	m := Match{Pattern: PatternSpatial, Token: "asdf", J: 3, Graph: GraphQwerty, Turns: 1}
+	expected := 3 * float64(len(g.adjacent)) * g.averageDegree
+	if got := estimateGuesses(&m, 4); got != expected {
+		t.Errorf("expected %v guesses, got %v", expected, got)
+	}
+
+	// Shifting every key doubles them.
+	m = Match{Pattern: PatternSpatial, Token: "ASDF", J: 3, Graph: GraphQwerty, Turns: 1, ShiftedCount: 4}
+	if got := estimateGuesses(&m, 4); got != 2*expected {
+		t.Errorf("expected %v guesses, got %v", 2*expected, got)
+	}
+}
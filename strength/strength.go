@@ -0,0 +1,458 @@
+// Package strength estimates how hard a password is to guess, in the style of
+// zxcvbn: it finds the dictionary words, keyboard walks, repeats, sequences,
+// years and dates in the password, picks the combination of them that is
+// cheapest for an attacker who knows those patterns, and reports the number
+// of guesses it takes, a score from 0 to 4 and feedback for the user.
+//
+// The dictionaries are a list of common passwords, lists of common first
+// names and surnames, the EFF large wordlist the passgen package embeds, and
+// any user inputs the caller passes, such as the user's name or email
+// address. Everything is embedded, so the package works offline.
+//
+// The embedded lists are short: a few hundred entries each, where zxcvbn
+// ships tens of thousands. A password built from a leaked password or a word
+// missing from them, such as "Tr0ub4dor&3", is scored as brute force and its
+// score is optimistic. Treat scores as upper bounds, and pass words specific
+// to the site or the user as user inputs.
+package strength
+
+import (
+	"math"
+	"time"
+)
+
+// Pattern names reported in Match.Pattern.
+const (
+	PatternDictionary = "dictionary"
+	PatternSpatial    = "spatial"
+	PatternRepeat     = "repeat"
+	PatternSequence   = "sequence"
+	PatternYear       = "year"
+	PatternDate       = "date"
+	PatternBruteforce = "bruteforce"
+)
+
+// Dictionary names reported in Match.Dictionary.
+const (
+	DictionaryPasswords  = "passwords"
+	DictionaryNames      = "names"
+	DictionarySurnames   = "surnames"
+	DictionaryEnglish    = "english"
+	DictionaryUserInputs = "user_inputs"
+)
+
+// maxLength is the number of characters that are matched against patterns
+// at once. Longer passwords are matched in chunks of that size, which keeps
+// the estimate cheap on arbitrary input, and a repeat or sequence running
+// past the end of a chunk is followed to its end.
+const maxLength = 100
+
+const (
+	// bruteforceCardinality is the number of guesses per brute-forced
+	// character.
+	bruteforceCardinality = 10
+	// minGuessesBeforeGrowingSequence penalizes splitting a password into
+	// more matches, so a short word followed by brute force is not cheaper
+	// than brute-forcing the whole thing.
+	minGuessesBeforeGrowingSequence = 10000
+	// Matches that are only part of the password take at least this many
+	// guesses.
+	minSubmatchGuessesSingleChar = 10
+	minSubmatchGuessesMultiChar  = 50
+	// minYearSpace is the smallest number of years an attacker tries around
+	// the current one.
+	minYearSpace = 20
+)
+
+// referenceYear is the year dates and years are measured from.
+var referenceYear = time.Now().Year()
+
+type Result struct {
+	// Guesses is the estimated number of guesses needed to find the
+	// password.
+	Guesses float64
+	// GuessesLog10 is the base 10 logarithm of Guesses.
+	GuessesLog10 float64
+	// Score is 0 (too guessable) to 4 (very unguessable): fewer than 10^3,
+	// 10^6, 10^8 or 10^10 guesses give 0, 1, 2 and 3.
+	Score int
+	// Sequence is the cheapest way to cover the password with matches,
+	// left to right. Characters no pattern explains are brute force matches.
+	Sequence []Match
+	Feedback Feedback
+}
+
+type Feedback struct {
+	// Warning explains what makes the password weak, or is empty.
+	Warning     string
+	Suggestions []string
+}
+
+// Match is a part of the password explained by a pattern. Only the fields of
+// its pattern are set.
+type Match struct {
+	Pattern string
+	// I and J are the offsets in runes of the first and last character of
+	// Token.
+	I, J  int
+	Token string
+	// Guesses is the number of guesses needed to find Token on its own.
+	Guesses float64
+
+	// Dictionary is the dictionary MatchedWord comes from. Rank is the number
+	// of guesses needed to find it there: its position in the common
+	// passwords and user inputs, and the size of the unordered wordlist.
+	Dictionary  string
+	MatchedWord string
+	Rank        int
+	Reversed    bool
+	// L33t is set when Token spells MatchedWord with substitutions. Sub maps
+	// every substituted character to the letter it stands for.
+	L33t bool
+	Sub  map[rune]rune
+
+	// Graph is the keyboard of a spatial match, Turns the number of
+	// direction changes and ShiftedCount the number of characters typed with
+	// shift.
+	Graph        string
+	Turns        int
+	ShiftedCount int
+
+	// BaseToken is repeated RepeatCount times in a repeat match, and takes
+	// BaseGuesses guesses by itself.
+	BaseToken   string
+	BaseGuesses float64
+	RepeatCount int
+
+	// SequenceName is "lower", "upper", "digits" or "unicode", and
+	// SequenceSpace the number of characters in it.
+	SequenceName  string
+	SequenceSpace int
+	Ascending     bool
+
+	// Year, Month and Day are set for dates, and Year for years. Separator
+	// is the separator between the parts of a date.
+	Year      int
+	Month     int
+	Day       int
+	Separator string
+}
+
+// Estimate returns the strength of password. User inputs are matched as an
+// extra dictionary, earlier ones ranked as more likely.
+func Estimate(password string, userInputs ...string) Result {
+	runes := []rune(password)
+	dicts := append(defaultDictionaries(), newUserInputsDictionary(userInputs))
+
+	guesses, sequence := 1.0, []Match(nil)
+	for start := 0; start < len(runes); {
+		end := min(start+maxLength, len(runes))
+		chunk := runes[start:end]
+		g, seq := mostGuessableSequence(chunk, omnimatch(chunk, dicts), false)
+
+		if end < len(runes) {
+			if i, m, ok := continueLast(runes[start:], seq); ok {
+				g, seq = mostGuessableSequence(chunk[:i], omnimatch(chunk[:i], dicts), false)
+				g = capGuesses(g * estimateGuesses(&m, len(runes)-start))
+				seq = append(seq, m)
+				end = start + m.J + 1
+			}
+		}
+
+		for _, m := range seq {
+			m.I += start
+			m.J += start
+			sequence = append(sequence, m)
+		}
+		guesses = capGuesses(guesses * g)
+		start = end
+	}
+
+	result := Result{
+		Guesses:      guesses,
+		GuessesLog10: math.Log10(guesses),
+		Score:        score(guesses),
+		Sequence:     sequence,
+	}
+	result.Feedback = feedback(result.Score, sequence)
+
+	return result
+}
+
+// continueLast extends the last repeat or sequence match of seq, which covers
+// the first maxLength characters of password, if its pattern runs past them.
+// It returns the index at which the match starts, which ends the matches
+// kept before it, and the extended match.
+func continueLast(password []rune, seq []Match) (int, Match, bool) {
+	for k := len(seq) - 1; k >= 0; k-- {
+		m := seq[k]
+		if m.Pattern != PatternRepeat && m.Pattern != PatternSequence {
+			continue
+		}
+
+		// step reports whether the character at j continues the pattern.
+		var step func(j int) bool
+		if m.Pattern == PatternRepeat {
+			period := len([]rune(m.BaseToken))
+			step = func(j int) bool { return password[j] == password[j-period] }
+		} else {
+			delta := password[m.I+1] - password[m.I]
+			step = func(j int) bool { return password[j]-password[j-1] == delta }
+		}
+
+		j := m.J + 1
+		for j < len(password) && step(j) {
+			j++
+		}
+		if j <= maxLength {
+			return 0, Match{}, false
+		}
+
+		if m.Pattern == PatternRepeat {
+			period := len([]rune(m.BaseToken))
+			m.RepeatCount = (j - m.I) / period
+			j = m.I + m.RepeatCount*period
+		}
+		m.J = j - 1
+		m.Token = string(password[m.I:j])
+		m.Guesses = 0
+
+		return m.I, m, true
+	}
+
+	return 0, Match{}, false
+}
+
+// score maps guesses to 0-4. The small delta keeps matches whose guesses sit
+// right at a threshold, such as ten thousand minimum guesses, in the lower
+// score.
+func score(guesses float64) int {
+	const delta = 5
+
+	switch {
+	case guesses < 1e3+delta:
+		return 0
+	case guesses < 1e6+delta:
+		return 1
+	case guesses < 1e8+delta:
+		return 2
+	case guesses < 1e10+delta:
+		return 3
+	default:
+		return 4
+	}
+}
+
+// mostGuessableSequence finds the sequence of non-overlapping matches that
+// covers password with the fewest guesses, filling the gaps with brute force.
+//
+// A sequence of l matches takes l! * prod(guesses) guesses, since the attacker
+// does not know the order in which the patterns appear, plus
+// minGuessesBeforeGrowingSequence^(l-1) unless excludeAdditive is set. The
+// search keeps the best sequence of every length ending at every position.
+func mostGuessableSequence(password []rune, matches []Match, excludeAdditive bool) (float64, []Match) {
+	n := len(password)
+	if n == 0 {
+		return 1, nil
+	}
+
+	byEnd := make([][]Match, n)
+	for _, m := range matches {
+		estimateGuesses(&m, n)
+		byEnd[m.J] = append(byEnd[m.J], m)
+	}
+
+	// best[k][l] is the best sequence of l matches covering password[:k+1],
+	// ending with m, with product pi and overall guesses g.
+	// Lengths are visited in increasing order, so ties always resolve the
+	// same way.
+	type entry struct {
+		ok bool
+		m  Match
+		pi float64
+		g  float64
+	}
+	best := make([][]entry, n)
+	for k := range best {
+		best[k] = make([]entry, k+2)
+	}
+
+	update := func(m Match, l int) {
+		k := m.J
+		pi := estimateGuesses(&m, len(password))
+		if l > 1 {
+			pi *= best[m.I-1][l-1].pi
+		}
+		g := factorial(l) * pi
+		if !excludeAdditive {
+			g += math.Pow(minGuessesBeforeGrowingSequence, float64(l-1))
+		}
+
+		// A sequence is only worth keeping if no shorter or equally long
+		// one ending here is at least as cheap.
+		for _, e := range best[k][:l+1] {
+			if e.ok && e.g <= g {
+				return
+			}
+		}
+		best[k][l] = entry{ok: true, m: m, pi: pi, g: g}
+	}
+
+	bruteforceUpdate := func(k int) {
+		update(bruteforceMatch(password, 0, k), 1)
+		for i := 1; i <= k; i++ {
+			m := bruteforceMatch(password, i, k)
+			for l, e := range best[i-1] {
+				// Two adjacent brute force matches are never better than
+				// one covering both.
+				if !e.ok || e.m.Pattern == PatternBruteforce {
+					continue
+				}
+				update(m, l+1)
+			}
+		}
+	}
+
+	for k := range n {
+		for _, m := range byEnd[k] {
+			if m.I == 0 {
+				update(m, 1)
+				continue
+			}
+			for l, e := range best[m.I-1] {
+				if e.ok {
+					update(m, l+1)
+				}
+			}
+		}
+		bruteforceUpdate(k)
+	}
+
+	l, guesses := 0, math.Inf(1)
+	for candidate, e := range best[n-1] {
+		if e.ok && e.g < guesses {
+			l, guesses = candidate, e.g
+		}
+	}
+
+	sequence := make([]Match, l)
+	for k := n - 1; k >= 0; l-- {
+		m := best[k][l].m
+		sequence[l-1] = m
+		k = m.I - 1
+	}
+
+	return capGuesses(guesses), sequence
+}
+
+func bruteforceMatch(password []rune, i, j int) Match {
+	return Match{Pattern: PatternBruteforce, I: i, J: j, Token: string(password[i : j+1])}
+}
+
+// estimateGuesses sets and returns the guesses of m, which is part of a
+// password of the given length.
+func estimateGuesses(m *Match, length int) float64 {
+	if m.Guesses != 0 {
+		return m.Guesses
+	}
+
+	size := m.J - m.I + 1
+	minGuesses := 1.0
+	if size < length {
+		minGuesses = minSubmatchGuessesMultiChar
+		if size == 1 {
+			minGuesses = minSubmatchGuessesSingleChar
+		}
+	}
+
+	var guesses float64
+	switch m.Pattern {
+	case PatternBruteforce:
+		guesses = bruteforceGuesses(size)
+	case PatternDictionary:
+		guesses = dictionaryGuesses(m)
+	case PatternSpatial:
+		guesses = spatialGuesses(m)
+	case PatternRepeat:
+		guesses = m.BaseGuesses * float64(m.RepeatCount)
+	case PatternSequence:
+		guesses = sequenceGuesses(m)
+	case PatternYear:
+		guesses = yearSpace(m.Year)
+	case PatternDate:
+		guesses = yearSpace(m.Year) * 365
+		if m.Separator != "" {
+			guesses *= 4
+		}
+	}
+
+	m.Guesses = max(guesses, minGuesses)
+	return m.Guesses
+}
+
+// bruteforceGuesses returns the guesses for size unknown characters. Single
+// characters take more than a one-character submatch, so brute force never
+// beats a match of the same length.
+func bruteforceGuesses(size int) float64 {
+	guesses := capGuesses(math.Pow(bruteforceCardinality, float64(size)))
+	if size == 1 {
+		return max(guesses, minSubmatchGuessesSingleChar+1)
+	}
+	return max(guesses, minSubmatchGuessesMultiChar+1)
+}
+
+func sequenceGuesses(m *Match) float64 {
+	var base float64
+	switch first := []rune(m.Token)[0]; {
+	case first == 'a' || first == 'A' || first == 'z' || first == 'Z' || first == '0' || first == '1' || first == '9':
+		// Obvious starting points.
+		base = 4
+	case first >= '0' && first <= '9':
+		base = 10
+	default:
+		base = 26
+	}
+	if !m.Ascending {
+		base *= 2
+	}
+
+	return base * float64(len([]rune(m.Token)))
+}
+
+func yearSpace(year int) float64 {
+	return float64(max(abs(year-referenceYear), minYearSpace))
+}
+
+// capGuesses keeps guesses finite, so they can still be compared and
+// multiplied.
+func capGuesses(guesses float64) float64 {
+	return min(guesses, math.MaxFloat64)
+}
+
+func factorial(n int) float64 {
+	f := 1.0
+	for i := 2; i <= n; i++ {
+		f *= float64(i)
+	}
+	return f
+}
+
+// binomial returns n choose k.
+func binomial(n, k int) float64 {
+	if k < 0 || k > n {
+		return 0
+	}
+	k = min(k, n-k)
+	r := 1.0
+	for i := 1; i <= k; i++ {
+		r = r * float64(n-k+i) / float64(i)
+	}
+	return r
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
@@ -0,0 +1,261 @@
+package strength
+
+import (
+	"math"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/haadi-coder/passgen"
+)
+
+func TestEstimate(t *testing.T) {
+	tests := []struct {
+		name       string
+		password   string
+		userInputs []string
+		score      int
+		patterns   []string
+	}{
+		{name: "empty", password: "", score: 0},
+		{name: "top common password", password: "password", score: 0, patterns: []string{PatternDictionary}},
+		{name: "capitalized common password", password: "Password1", score: 0, patterns: []string{PatternDictionary}},
+		{name: "l33t common password", password: "P@ssw0rd", score: 0, patterns: []string{PatternDictionary}},
+		{name: "reversed common password", password: "drowssap", score: 0, patterns: []string{PatternDictionary}},
+		{name: "keyboard walk", password: "kjhgfdsa", score: 1, patterns: []string{PatternSpatial}},
+		{name: "keypad walk", password: "7896321", score: 1, patterns: []string{PatternSpatial}},
+		{name: "sequence", password: "abcdef", score: 0, patterns: []string{PatternSequence}},
+		{name: "repeat", password: "aaaaaaaaaaaa", score: 0, patterns: []string{PatternRepeat}},
+		{name: "year", password: "1994", score: 0, patterns: []string{PatternYear}},
+		{name: "date", password: "13/05/1994", score: 1, patterns: []string{PatternDate}},
+		{name: "user input", password: "alice1990", userInputs: []string{"Alice"}, score: 1, patterns: []string{PatternDictionary, PatternYear}},
+		{name: "word and brute force", password: "monkey!x", score: 1, patterns: []string{PatternDictionary, PatternBruteforce}},
+		{name: "name and surname", password: "johnsmith", score: 1, patterns: []string{PatternDictionary, PatternDictionary}},
+		{name: "random", password: "nK!-&p.cP9{JO!>+", score: 4, patterns: []string{PatternBruteforce}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := Estimate(tt.password, tt.userInputs...)
+			if result.Score != tt.score {
+				t.Errorf("expected score %d, got %d (%v guesses)", tt.score, result.Score, result.Guesses)
+			}
+
+			var patterns []string
+			for _, m := range result.Sequence {
+				patterns = append(patterns, m.Pattern)
+			}
+			if !slices.Equal(patterns, tt.patterns) {
+				t.Errorf("expected patterns %v, got %v", tt.patterns, patterns)
+			}
+		})
+	}
+}
+
+func TestEstimateSequence(t *testing.T) {
+	passwords := []string{"", "x", "correcthorsebatterystaple", "p4ssw0rd!2024", "Tr0ub4dour&3", "qwerty123qwerty123", strings.Repeat("ab1", 60)}
+
+	for _, password := range passwords {
+		result := Estimate(password)
+
+		// The matches cover the password without gaps or overlaps.
+		var covered strings.Builder
+		next := 0
+		for _, m := range result.Sequence {
+			if m.I != next || m.J < m.I {
+				t.Fatalf("%q: expected a match starting at %d, got %d-%d", password, next, m.I, m.J)
+			}
+			covered.WriteString(m.Token)
+			next = m.J + 1
+		}
+		if covered.String() != password {
+			t.Errorf("expected the sequence to cover %q, got %q", password, covered.String())
+		}
+
+		if math.IsInf(result.Guesses, 0) || result.Guesses < 1 {
+			t.Errorf("%q: expected finite guesses of at least 1, got %v", password, result.Guesses)
+		}
+		if got := math.Log10(result.Guesses); got != result.GuessesLog10 {
+			t.Errorf("%q: expected log10 %v, got %v", password, got, result.GuessesLog10)
+		}
+	}
+}
+
+func TestEstimateOrdering(t *testing.T) {
+	// Each password is harder to guess than the one before it.
+	passwords := []string{"password", "monkey!x", "qwerasdf", "correct-horse", "correcthorsebatterystaple"}
+
+	previous := 0.0
+	for _, password := range passwords {
+		guesses := Estimate(password).Guesses
+		if guesses <= previous {
+			t.Errorf("expected %q to take more than %v guesses, got %v", password, previous, guesses)
+		}
+		previous = guesses
+	}
+}
+
+func TestEstimateGenerated(t *testing.T) {
+	gen, err := passgen.NewGenerator(passgen.WithLength(16))
+	if err != nil {
+		t.Fatalf("failed to create generator: %v", err)
+	}
+
+	for range 50 {
+		password, err := gen.Generate()
+		if err != nil {
+			t.Fatalf("failed to generate password: %v", err)
+		}
+		if result := Estimate(password); result.Score != 4 {
+			t.Errorf("expected generated password %q to score 4, got %d", password, result.Score)
+		}
+	}
+}
+
+func TestEstimateFeedback(t *testing.T) {
+	tests := []struct {
+		password    string
+		warning     string
+		suggestions []string
+	}{
+		{
+			password:    "",
+			suggestions: []string{"Use a few words, avoid common phrases", "No need for symbols, digits, or uppercase letters"},
+		},
+		{
+			password:    "password",
+			warning:     "This is a top-10 common password",
+			suggestions: []string{"Add another word or two. Uncommon words are better."},
+		},
+		{
+			password: "P@ssw0rd",
+			warning:  "This is similar to a commonly used password",
+			suggestions: []string{
+				"Add another word or two. Uncommon words are better.",
+				"Capitalization doesn't help very much",
+				"Predictable substitutions like '@' instead of 'a' don't help very much",
+			},
+		},
+		{
+			password:    "zxcvbnm,./",
+			warning:     "Straight rows of keys are easy to guess",
+			suggestions: []string{"Add another word or two. Uncommon words are better.", "Use a longer keyboard pattern with more turns"},
+		},
+		{
+			password:    "abcabcabc",
+			warning:     `Repeats like "abcabcabc" are only slightly harder to guess than "abc"`,
+			suggestions: []string{"Add another word or two. Uncommon words are better.", "Avoid repeated words and characters"},
+		},
+		{
+			password:    "12-25-2023",
+			warning:     "Dates are often easy to guess",
+			suggestions: []string{"Add another word or two. Uncommon words are better.", "Avoid dates and years that are associated with you"},
+		},
+		{
+			password:    "jennifer",
+			warning:     "Names and surnames by themselves are easy to guess",
+			suggestions: []string{"Add another word or two. Uncommon words are better."},
+		},
+		{
+			password:    "Garcia1985",
+			warning:     "Common names and surnames are easy to guess",
+			suggestions: []string{"Add another word or two. Uncommon words are better.", "Capitalization doesn't help very much"},
+		},
+		{
+			password: "correcthorsebatterystaple",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.password, func(t *testing.T) {
+			fb := Estimate(tt.password).Feedback
+			if fb.Warning != tt.warning {
+				t.Errorf("expected warning %q, got %q", tt.warning, fb.Warning)
+			}
+			if !slices.Equal(fb.Suggestions, tt.suggestions) {
+				t.Errorf("expected suggestions %q, got %q", tt.suggestions, fb.Suggestions)
+			}
+		})
+	}
+}
+
+func TestEstimateLongPassword(t *testing.T) {
+	tests := []struct {
+		name     string
+		password string
+		score    int
+		last     string
+	}{
+		{name: "repeated character", password: strings.Repeat("a", 120), score: 1, last: PatternRepeat},
+		{name: "long sequence", password: sequence(0x4e00, 150), score: 1, last: PatternSequence},
+		{name: "repeated phrase", password: strings.Repeat("correct horse ", 100), score: 4, last: PatternRepeat},
+		{name: "repeat after random", password: "nK!-&p.cP9{JO!>+" + strings.Repeat("xy", 200), score: 4, last: PatternRepeat},
+		{name: "long random", password: strings.Repeat("nK!-&p.cP9{JO!>+", 4) + "4X^d*#tK:qZ}m&G+B2hW@ZrS;6cLv%EwR@Pz$e=9", score: 4, last: PatternBruteforce},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := Estimate(tt.password)
+			if math.IsInf(result.Guesses, 0) {
+				t.Errorf("expected finite guesses, got %v", result.Guesses)
+			}
+			if result.Score != tt.score {
+				t.Errorf("expected score %d, got %d (%v guesses)", tt.score, result.Score, result.Guesses)
+			}
+
+			last := result.Sequence[len(result.Sequence)-1]
+			if n := len([]rune(tt.password)); last.Pattern != tt.last || last.J != n-1 {
+				t.Errorf("expected a %s match ending at %d, got %s %d-%d", tt.last, n-1, last.Pattern, last.I, last.J)
+			}
+		})
+	}
+}
+
+// sequence returns n consecutive characters starting at first.
+func sequence(first rune, n int) string {
+	runes := make([]rune, n)
+	for i := range runes {
+		runes[i] = first + rune(i)
+	}
+
+	return string(runes)
+}
+
+func TestScore(t *testing.T) {
+	tests := []struct {
+		guesses float64
+		score   int
+	}{
+		{1, 0},
+		{1e3, 0},
+		{1e3 + 10, 1},
+		{1e6, 1},
+		{1e7, 2},
+		{1e9, 3},
+		{1e10 + 10, 4},
+		{math.MaxFloat64, 4},
+	}
+
+	for _, tt := range tests {
+		if got := score(tt.guesses); got != tt.score {
+			t.Errorf("expected score %d for %v guesses, got %d", tt.score, tt.guesses, got)
+		}
+	}
+}
+
+func BenchmarkEstimate(b *testing.B) {
+	passwords := map[string]string{
+		"common":   "P@ssw0rd1",
+		"random":   "nK!-&p.cP9{JO!>+",
+		"phrase":   "correct horse battery staple",
+		"max_size": strings.Repeat("ab1!", maxLength/4),
+	}
+
+	for name, password := range passwords {
+		b.Run(name, func(b *testing.B) {
+			for b.Loop() {
+				Estimate(password)
+			}
+		})
+	}
+}
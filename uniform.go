@@ -0,0 +1,200 @@
+package passgen
+
+import (
+	"io"
+	"slices"
+	"sync/atomic"
+)
+
+// maxRejections is the number of unconstrained draws a sampler tries before
+// it switches to drawing the class counts first. Configurations that get
+// there are met by well under one draw in ten, so it rarely happens
+// otherwise.
+const maxRejections = 64
+
+// sampler draws passwords of a fixed length uniformly from those that meet
+// every minimum and maximum of its classes, so each of them is equally likely
+// and the keyspace is the entropy actually delivered.
+//
+// It first draws every character from the whole pool and starts over if the
+// requirements are not met, which is uniform by construction. When that keeps
+// failing, it switches to drawing the number of characters of every
+// constrained class in proportion to the number of passwords with those
+// counts, fills them in and shuffles the result, which is uniform too.
+// Either way each password is equally likely, so the switch does not change
+// the distribution.
+type sampler struct {
+	classes  []charClass
+	pool     []rune
+	distinct bool
+	// ends[i] is the end of classes[i] in pool.
+	ends []int
+	// constrained is set when some class has a minimum or a maximum the
+	// length can exceed.
+	constrained bool
+
+	// switched is set once rejection has failed maxRejections times in a
+	// row. It is shared with the samplers derived by withClasses.
+	switched *atomic.Bool
+	// bounded holds the indices of the classes with a minimum or a maximum
+	// the length can exceed. counts draws how many characters each of them
+	// contributes, followed by the number drawn from free and freePool, the
+	// other classes.
+	bounded  []int
+	free     []charClass
+	freePool []rune
+	counts   *counts
+}
+
+func newSampler(classes []charClass, length int, distinct bool) *sampler {
+	s := &sampler{classes: classes, distinct: distinct, switched: new(atomic.Bool)}
+
+	var sizes, mins, maxs []int
+	freeSize := 0
+	for i, class := range classes {
+		s.pool = append(s.pool, class.chars...)
+		s.ends = append(s.ends, len(s.pool))
+		if class.min > 0 || class.max < length {
+			s.constrained = true
+			s.bounded = append(s.bounded, i)
+			sizes = append(sizes, len(class.chars))
+			mins = append(mins, class.min)
+			maxs = append(maxs, class.max)
+		} else {
+			freeSize += len(class.chars)
+		}
+	}
+	s.setFree()
+
+	if s.constrained {
+		if freeSize > 0 {
+			sizes = append(sizes, freeSize)
+			mins = append(mins, 0)
+			maxs = append(maxs, length)
+		}
+		s.counts = newCounts(sizes, mins, maxs, length, distinct)
+	}
+
+	return s
+}
+
+// withClasses returns a sampler for classes with the same sizes, minimums
+// and maximums as those of s but other characters.
+func (s *sampler) withClasses(classes []charClass) *sampler {
+	t := *s
+	t.classes = classes
+	t.pool = nil
+	for _, class := range classes {
+		t.pool = append(t.pool, class.chars...)
+	}
+	t.setFree()
+
+	return &t
+}
+
+// setFree collects the classes that are not bounded.
+func (s *sampler) setFree() {
+	s.free, s.freePool = nil, nil
+	for i, class := range s.classes {
+		if !slices.Contains(s.bounded, i) {
+			s.free = append(s.free, class)
+			s.freePool = append(s.freePool, class.chars...)
+		}
+	}
+}
+
+func (s *sampler) draw(r io.Reader, pass []rune) error {
+	if !s.switched.Load() {
+		for range maxRejections {
+			met, err := s.attempt(r, pass)
+			if err != nil || met {
+				return err
+			}
+		}
+		s.switched.Store(true)
+	}
+
+	if err := s.drawCounts(r, pass); err != nil {
+		return err
+	}
+
+	return shuffleRunes(r, pass)
+}
+
+// drawCounts draws the number of characters of every bounded class and of
+// the free classes, and fills pass with them in that order.
+func (s *sampler) drawCounts(r io.Reader, pass []rune) error {
+	var stack [8]int
+	k := stack[:]
+	if len(s.counts.groups) > len(stack) {
+		k = make([]int, len(s.counts.groups))
+	}
+	k = k[:len(s.counts.groups)]
+
+	if err := s.counts.draw(r, k); err != nil {
+		return err
+	}
+
+	filled := 0
+	for i, idx := range s.bounded {
+		dst := pass[filled : filled+k[i]]
+
+		var err error
+		if s.distinct {
+			err = drawDistinct(r, dst, []charClass{{chars: s.classes[idx].chars, min: k[i]}})
+		} else {
+			err = generatePassEntry(r, dst, s.classes[idx].chars)
+		}
+		if err != nil {
+			return err
+		}
+		filled += k[i]
+	}
+
+	if s.distinct {
+		return drawDistinct(r, pass[filled:], s.free)
+	}
+
+	return generatePassEntry(r, pass[filled:], s.freePool)
+}
+
+// attempt fills pass from the whole pool and reports whether the result
+// meets every minimum and maximum.
+func (s *sampler) attempt(r io.Reader, pass []rune) (bool, error) {
+	var stack [8]int
+	counts := stack[:]
+	if len(s.classes) > len(stack) {
+		counts = make([]int, len(s.classes))
+	}
+	counts = counts[:len(s.classes)]
+
+	for i := range pass {
+		idx, err := randomIndex(r, len(s.pool))
+		if err != nil {
+			return false, err
+		}
+		// Redrawing used characters picks uniformly among the unused ones.
+		for s.distinct && slices.Contains(pass[:i], s.pool[idx]) {
+			if idx, err = randomIndex(r, len(s.pool)); err != nil {
+				return false, err
+			}
+		}
+
+		pass[i] = s.pool[idx]
+		if s.constrained {
+			class := 0
+			for idx >= s.ends[class] {
+				class++
+			}
+			counts[class]++
+		}
+	}
+
+	for i, class := range s.classes {
+		if counts[i] < class.min || counts[i] > class.max {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
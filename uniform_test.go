@@ -0,0 +1,207 @@
+package passgen
+
+import (
+	"crypto/rand"
+	"math"
+	"math/big"
+	"testing"
+)
+
+func TestSamplerUniform(t *testing.T) {
+	ab := []rune("ab")
+	digits := []rune("0123")
+	symbols := []rune("!@#")
+
+	tests := []struct {
+		name     string
+		classes  []charClass
+		length   int
+		distinct bool
+	}{
+		{
+			name:    "minimums",
+			classes: []charClass{{"ab", ab, 1, noLimit}, {"digits", digits, 2, noLimit}, {"symbols", symbols, 0, noLimit}},
+			length:  3,
+		},
+		{
+			name:    "minimums and maximums",
+			classes: []charClass{{"ab", ab, 1, 2}, {"digits", digits, 0, noLimit}, {"symbols", symbols, 0, 1}},
+			length:  4,
+		},
+		{
+			name:     "distinct",
+			classes:  []charClass{{"ab", ab, 1, noLimit}, {"digits", digits, 1, noLimit}, {"symbols", symbols, 1, noLimit}},
+			length:   4,
+			distinct: true,
+		},
+	}
+
+	for _, tt := range tests {
+		for _, switched := range []bool{false, true} {
+			name := tt.name + "/rejection"
+			if switched {
+				name = tt.name + "/counts"
+			}
+
+			t.Run(name, func(t *testing.T) {
+				s := newSampler(tt.classes, tt.length, tt.distinct)
+				s.switched.Store(switched)
+
+				keyspace := countClasses(tt.classes, tt.length, tt.distinct)
+				samples := 40 * int(keyspace.Int64())
+
+				observed := make(map[string]int)
+				pass := make([]rune, tt.length)
+				for range samples {
+					if err := s.draw(rand.Reader, pass); err != nil {
+						t.Fatalf("failed to draw password: %v", err)
+					}
+					observed[string(pass)]++
+				}
+
+				if got := int64(len(observed)); got != keyspace.Int64() {
+					t.Errorf("expected %d distinct passwords, got %d", keyspace, got)
+				}
+				checkUniform(t, observed, keyspace.Int64(), samples)
+			})
+		}
+	}
+}
+
+func TestGenerateUniform(t *testing.T) {
+	small := []Option{WithoutUppercase(), WithoutLowercase(), WithoutSymbols(), WithCharset("ab", CharsetFromString("ab"), 0)}
+
+	tests := []struct {
+		name    string
+		options []Option
+	}{
+		{
+			name:    "minimums",
+			options: []Option{WithLength(3), WithMinDigits(2), WithCharset("ab", CharsetFromString("ab"), 1)},
+		},
+		{
+			name:    "first character with minimums",
+			options: []Option{WithLength(3), WithMinDigits(1), WithFirstChar("ab", ClassDigits), WithCharset("ab", CharsetFromString("ab"), 1)},
+		},
+		{
+			name:    "without repeats",
+			options: []Option{WithLength(3), WithNoRepeats(), WithMinDigits(1), WithCharset("ab", CharsetFromString("ab"), 1)},
+		},
+		{
+			name:    "last character without repeats",
+			options: []Option{WithLength(3), WithNoRepeats(), WithMinDigits(2), WithLastChar("ab")},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gen, err := NewGenerator(append(small, tt.options...)...)
+			if err != nil {
+				t.Fatalf("failed to create generator: %v", err)
+			}
+
+			keyspace := gen.Keyspace()
+			if expected := bruteForceValid(gen); keyspace.Cmp(big.NewInt(expected)) != 0 {
+				t.Fatalf("expected keyspace %d, got %v", expected, keyspace)
+			}
+
+			samples := 40 * int(keyspace.Int64())
+			observed := make(map[string]int)
+			for range samples {
+				password, err := gen.Generate()
+				if err != nil {
+					t.Fatalf("failed to generate password: %v", err)
+				}
+				observed[password]++
+			}
+
+			checkUniform(t, observed, keyspace.Int64(), samples)
+		})
+	}
+}
+
+func TestPlacementUniform(t *testing.T) {
+	gen, err := NewGenerator(WithoutUppercase(), WithoutLowercase(), WithoutSymbols(), WithCharset("ab", CharsetFromString("ab"), 1),
+		WithLength(3), WithMinDigits(1), WithFirstChar("ab", ClassDigits), WithLastChar("ab"))
+	if err != nil {
+		t.Fatalf("failed to create generator: %v", err)
+	}
+
+	keyspace := gen.Keyspace()
+	samples := 40 * int(keyspace.Int64())
+	observed := make(map[string]int)
+	pass := make([]rune, 3)
+	for range samples {
+		if err := gen.generatePlacement(rand.Reader, pass); err != nil {
+			t.Fatalf("failed to generate password: %v", err)
+		}
+		observed[string(pass)]++
+	}
+
+	checkUniform(t, observed, keyspace.Int64(), samples)
+}
+
+func TestMinimumsDistribution(t *testing.T) {
+	const samples = 20000
+
+	gen, err := NewGenerator(WithLength(4), WithoutUppercase(), WithoutLowercase(), WithMinDigits(2))
+	if err != nil {
+		t.Fatalf("failed to create generator: %v", err)
+	}
+
+	// Every password with at least two digits is equally likely, so the
+	// number of digits k follows C(4, k) * 10^k * 26^(4-k). Drawing the
+	// minimums first would favour passwords with more digits.
+	weights := make([]float64, 5)
+	total := 0.0
+	for k := 2; k <= 4; k++ {
+		weights[k] = float64(new(big.Int).Binomial(4, int64(k)).Int64()) * math.Pow(10, float64(k)) * math.Pow(26, float64(4-k))
+		total += weights[k]
+	}
+
+	observed := make([]int, 5)
+	for range samples {
+		password, err := gen.Generate()
+		if err != nil {
+			t.Fatalf("failed to generate password: %v", err)
+		}
+
+		k := 0
+		for _, r := range password {
+			if Digits.Contains(r) {
+				k++
+			}
+		}
+		observed[k]++
+	}
+
+	chi2 := 0.0
+	for k := 2; k <= 4; k++ {
+		expected := samples * weights[k] / total
+		chi2 += (float64(observed[k]) - expected) * (float64(observed[k]) - expected) / expected
+	}
+	if observed[0]+observed[1] > 0 {
+		t.Errorf("expected at least two digits in every password, got counts %v", observed)
+	}
+	if critical := chiSquareCritical(2, chiSquareZ); chi2 > critical {
+		t.Errorf("digit counts %v deviate from the expected distribution: chi2 = %.2f > %.2f", observed, chi2, critical)
+	}
+}
+
+// checkUniform runs a chi-square test of the observed counts against the
+// uniform distribution over keyspace outcomes.
+func checkUniform(t *testing.T, observed map[string]int, keyspace int64, samples int) {
+	t.Helper()
+
+	expected := float64(samples) / float64(keyspace)
+	chi2 := 0.0
+	for _, o := range observed {
+		chi2 += (float64(o) - expected) * (float64(o) - expected) / expected
+	}
+	// Outcomes never drawn contribute their full expectation.
+	chi2 += float64(keyspace-int64(len(observed))) * expected
+
+	if critical := chiSquareCritical(int(keyspace)-1, chiSquareZ); chi2 > critical {
+		t.Errorf("passwords deviate from uniform: chi2 = %.2f > %.2f", chi2, critical)
+	}
+}
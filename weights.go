@@ -0,0 +1,174 @@
+package passgen
+
+import (
+	"fmt"
+	"io"
+	"math"
+)
+
+// weighting draws fill characters with per-class weights: a character of
+// class i is weights[i] times as likely as one with weight 1, so the class
+// itself is drawn with probability proportional to weights[i] * |class i|.
+type weighting struct {
+	classes []charClass
+	// cumulative[i] is the probability of drawing from classes[:i+1].
+	cumulative []float64
+}
+
+func newWeighting(classes []charClass, weights []float64) *weighting {
+	probs := classProbabilities(classes, weights)
+
+	cumulative := make([]float64, len(probs))
+	sum := 0.0
+	for i, p := range probs {
+		sum += p
+		cumulative[i] = sum
+	}
+
+	return &weighting{classes: classes, cumulative: cumulative}
+}
+
+// classWeights returns the weight of every class: the configured weight of
+// the built-in classes and 1 for custom charsets.
+func (c *config) classWeights(classes []charClass) []float64 {
+	byName := map[string]float64{
+		ClassUppercase: c.weights[0],
+		ClassLowercase: c.weights[1],
+		ClassDigits:    c.weights[2],
+		ClassSymbols:   c.weights[3],
+	}
+
+	weights := make([]float64, len(classes))
+	for i, class := range classes {
+		weights[i] = 1
+		if w, ok := byName[class.name]; ok {
+			weights[i] = w
+		}
+	}
+
+	return weights
+}
+
+// classProbabilities returns the probability of drawing a fill character
+// from each class.
+func classProbabilities(classes []charClass, weights []float64) []float64 {
+	probs := make([]float64, len(classes))
+	total := 0.0
+	for i, class := range classes {
+		probs[i] = weights[i] * float64(len(class.chars))
+		total += probs[i]
+	}
+	for i := range probs {
+		probs[i] /= total
+	}
+
+	return probs
+}
+
+func (w *weighting) draw(r io.Reader, dst []rune) error {
+	for i := range dst {
+		v, err := randomUint64(r)
+		if err != nil {
+			return err
+		}
+
+		// The top 53 bits give a uniform float64 in [0, 1).
+		u := float64(v>>11) / (1 << 53)
+		class := len(w.cumulative) - 1
+		for j, c := range w.cumulative {
+			if u < c && len(w.classes[j].chars) > 0 {
+				class = j
+				break
+			}
+		}
+
+		if err := generatePassEntry(r, dst[i:i+1], w.classes[class].chars); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// weightedEntropy returns the Shannon entropy in bits of a weighted password
+// of the given length.
+//
+// The minimums are drawn first, the f_i fill characters of each class after
+// them, and the result is shuffled. A password with k_i = m_i + f_i
+// characters from class i, where m_i is its minimum, then has probability
+//
+//	prod_i [k_i!/f_i! * n_i^-k_i * q_i^f_i] * (L-M)!/L!
+//
+// where n_i is the size of class i, q_i the probability of drawing a fill
+// character from it, L the length and M the sum of the minimums. The entropy
+// is the expectation of its negative logarithm. The fill counts are
+// multinomial, so every term only needs the binomial marginal of f_i.
+func weightedEntropy(classes []charClass, weights []float64, length int) float64 {
+	totalMin := 0
+	for _, class := range classes {
+		totalMin += class.min
+	}
+	fill := length - totalMin
+	if fill < 0 {
+		return math.Inf(-1)
+	}
+
+	bits := (lgamma(length+1) - lgamma(fill+1)) / math.Ln2
+	for i, q := range classProbabilities(classes, weights) {
+		class := classes[i]
+		if len(class.chars) == 0 {
+			continue
+		}
+
+		n := math.Log2(float64(len(class.chars)))
+		bits += float64(class.min)*n + float64(fill)*q*(n-math.Log2(q))
+
+		if class.min == 0 {
+			continue
+		}
+		// E[log2(k_i!/f_i!)] over f_i ~ Binomial(fill, q).
+		for f := 0; f <= fill; f++ {
+			p := binomialPMF(fill, f, q)
+			if p == 0 {
+				continue
+			}
+			bits -= p * (lgamma(class.min+f+1) - lgamma(f+1)) / math.Ln2
+		}
+	}
+
+	return bits
+}
+
+func binomialPMF(n, k int, p float64) float64 {
+	switch {
+	case p == 1:
+		if k == n {
+			return 1
+		}
+		return 0
+	case p == 0:
+		if k == 0 {
+			return 1
+		}
+		return 0
+	}
+
+	logP := lgamma(n+1) - lgamma(k+1) - lgamma(n-k+1) + float64(k)*math.Log(p) + float64(n-k)*math.Log1p(-p)
+	return math.Exp(logP)
+}
+
+func lgamma(n int) float64 {
+	v, _ := math.Lgamma(float64(n))
+	return v
+}
+
+func validateWeights(weights []float64) error {
+	for i, w := range weights {
+		if math.IsNaN(w) || math.IsInf(w, 0) || w <= 0 {
+			name := []string{ClassUppercase, ClassLowercase, ClassDigits, ClassSymbols}[i]
+			return fmt.Errorf("weight for %s must be a positive number, got %v", name, w)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,265 @@
+package passgen
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestWithClassWeights(t *testing.T) {
+	gen, err := NewGenerator(WithLength(20), WithMinRequirements(1, 1, 1, 1), WithClassWeights(1, 8, 1, 0.5))
+	if err != nil {
+		t.Fatalf("failed to create generator: %v", err)
+	}
+
+	for range 200 {
+		password, err := gen.Generate()
+		if err != nil {
+			t.Fatalf("failed to generate password: %v", err)
+		}
+		if n := len([]rune(password)); n != 20 {
+			t.Errorf("expected length 20, got %d: %q", n, password)
+		}
+		if err := gen.Validate(password); err != nil {
+			t.Errorf("password %q does not satisfy the policy: %v", password, err)
+		}
+	}
+}
+
+func TestClassWeightsDistribution(t *testing.T) {
+	const samples = 20000
+
+	gen, err := NewGenerator(WithLength(1), WithClassWeights(1, 8, 1, 0.5))
+	if err != nil {
+		t.Fatalf("failed to create generator: %v", err)
+	}
+
+	sets := []Charset{Uppercase, Lowercase, Digits, Symbols}
+	weights := []float64{1, 8, 1, 0.5}
+
+	total := 0.0
+	for i, cs := range sets {
+		total += weights[i] * float64(cs.Len())
+	}
+
+	observed := make([]int, len(sets))
+	for range samples {
+		password, err := gen.Generate()
+		if err != nil {
+			t.Fatalf("failed to generate password: %v", err)
+		}
+		for i, cs := range sets {
+			if cs.Contains([]rune(password)[0]) {
+				observed[i]++
+			}
+		}
+	}
+
+	chi2 := 0.0
+	for i, cs := range sets {
+		expected := samples * weights[i] * float64(cs.Len()) / total
+		chi2 += (float64(observed[i]) - expected) * (float64(observed[i]) - expected) / expected
+	}
+	if critical := chiSquareCritical(len(sets)-1, chiSquareZ); chi2 > critical {
+		t.Errorf("class counts %v deviate from the weights: chi2 = %.2f > %.2f", observed, chi2, critical)
+	}
+}
+
+func TestClassWeightsEntropy(t *testing.T) {
+	small := []Option{WithoutUppercase(), WithoutLowercase(), WithoutSymbols()}
+
+	tests := []struct {
+		name    string
+		options []Option
+	}{
+		{
+			name:    "no minimums",
+			options: []Option{WithLength(3), WithCharset("ab", CharsetFromString("ab"), 0), WithClassWeights(1, 1, 3, 1)},
+		},
+		{
+			name:    "one minimum",
+			options: []Option{WithLength(3), WithCharset("ab", CharsetFromString("ab"), 1), WithClassWeights(1, 1, 3, 1)},
+		},
+		{
+			name:    "several minimums",
+			options: []Option{WithLength(4), WithMinDigits(1), WithCharset("ab", CharsetFromString("ab"), 2), WithClassWeights(1, 1, 0.25, 1)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gen, err := NewGenerator(append(small, tt.options...)...)
+			if err != nil {
+				t.Fatalf("failed to create generator: %v", err)
+			}
+
+			expected := bruteForceWeightedEntropy(gen)
+			if got := gen.Entropy(); math.Abs(got-expected) > 1e-9 {
+				t.Errorf("expected entropy %v, got %v", expected, got)
+			}
+		})
+	}
+}
+
+func TestClassWeightsEntropyBounds(t *testing.T) {
+	uniform, err := NewGenerator(WithLength(16))
+	if err != nil {
+		t.Fatalf("failed to create generator: %v", err)
+	}
+	equal, err := NewGenerator(WithLength(16), WithClassWeights(2, 2, 2, 2))
+	if err != nil {
+		t.Fatalf("failed to create generator: %v", err)
+	}
+	skewed, err := NewGenerator(WithLength(16), WithClassWeights(1, 20, 1, 0.1))
+	if err != nil {
+		t.Fatalf("failed to create generator: %v", err)
+	}
+
+	if math.Abs(equal.Entropy()-uniform.Entropy()) > 1e-9 {
+		t.Errorf("expected equal weights to keep the entropy at %v, got %v", uniform.Entropy(), equal.Entropy())
+	}
+	if skewed.Entropy() >= uniform.Entropy() {
+		t.Errorf("expected skewed weights to lower the entropy below %v, got %v", uniform.Entropy(), skewed.Entropy())
+	}
+	if skewed.Keyspace().Cmp(uniform.Keyspace()) != 0 {
+		t.Errorf("expected weights to keep the keyspace at %v, got %v", uniform.Keyspace(), skewed.Keyspace())
+	}
+
+	derived, err := NewGenerator(WithMinEntropy(80), WithClassWeights(1, 20, 1, 0.1))
+	if err != nil {
+		t.Fatalf("failed to create generator: %v", err)
+	}
+	if derived.Entropy() < 80 {
+		t.Errorf("expected at least 80 bits, got %v", derived.Entropy())
+	}
+	if plain, _ := NewGenerator(WithMinEntropy(80)); derived.cfg.length <= plain.cfg.length {
+		t.Errorf("expected weights to require more than %d characters, got %d", plain.cfg.length, derived.cfg.length)
+	}
+}
+
+func TestNewGeneratorClassWeights(t *testing.T) {
+	tests := []struct {
+		name     string
+		options  []Option
+		errorMsg string
+	}{
+		{
+			name:     "zero weight",
+			options:  []Option{WithClassWeights(1, 0, 1, 1)},
+			errorMsg: "weight for lowercase must be a positive number, got 0",
+		},
+		{
+			name:     "NaN weight",
+			options:  []Option{WithClassWeights(1, 1, 1, math.NaN())},
+			errorMsg: "weight for symbols must be a positive number, got NaN",
+		},
+		{
+			name:     "combined with maximum requirements",
+			options:  []Option{WithClassWeights(1, 2, 1, 1), WithMaxSymbols(2)},
+			errorMsg: "class weights cannot be combined with maximum requirements",
+		},
+		{
+			name:     "combined with first character",
+			options:  []Option{WithClassWeights(1, 2, 1, 1), WithFirstChar(ClassLowercase)},
+			errorMsg: "class weights cannot be combined with first and last character constraints",
+		},
+		{
+			name:     "combined with a mask",
+			options:  []Option{WithClassWeights(1, 2, 1, 1), WithMask("?a?a")},
+			errorMsg: "class weights cannot be combined with a mask",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewGenerator(tt.options...)
+			if err == nil {
+				t.Fatalf("expected error but got none")
+			}
+			if !strings.Contains(err.Error(), tt.errorMsg) {
+				t.Errorf("expected error message to contain %q, got %q", tt.errorMsg, err.Error())
+			}
+		})
+	}
+}
+
+// bruteForceWeightedEntropy follows every way the generator can draw the
+// minimums and weighted fill characters and shuffle them, and returns the
+// Shannon entropy of the resulting passwords.
+func bruteForceWeightedEntropy(g *Generator) float64 {
+	length := g.cfg.length
+	weights := g.cfg.classWeights(g.classes)
+	probs := classProbabilities(g.classes, weights)
+
+	// Every position lists the characters it can be drawn from and their
+	// probabilities.
+	type choice struct {
+		r rune
+		p float64
+	}
+	var positions [][]choice
+	for _, class := range g.classes {
+		for range class.min {
+			var choices []choice
+			for _, r := range class.chars {
+				choices = append(choices, choice{r, 1 / float64(len(class.chars))})
+			}
+			positions = append(positions, choices)
+		}
+	}
+	var fill []choice
+	for i, class := range g.classes {
+		for _, r := range class.chars {
+			fill = append(fill, choice{r, probs[i] / float64(len(class.chars))})
+		}
+	}
+	for len(positions) < length {
+		positions = append(positions, fill)
+	}
+
+	var perms [][]int
+	var permute func(prefix []int, rest []int)
+	permute = func(prefix []int, rest []int) {
+		if len(rest) == 0 {
+			perms = append(perms, prefix)
+			return
+		}
+		for i := range rest {
+			next := append(append([]int{}, rest[:i]...), rest[i+1:]...)
+			permute(append(append([]int{}, prefix...), rest[i]), next)
+		}
+	}
+	identity := make([]int, length)
+	for i := range identity {
+		identity[i] = i
+	}
+	permute(nil, identity)
+
+	dist := make(map[string]float64)
+	seq := make([]rune, length)
+	var walk func(pos int, p float64)
+	walk = func(pos int, p float64) {
+		if pos == length {
+			out := make([]rune, length)
+			for _, perm := range perms {
+				for i, j := range perm {
+					out[i] = seq[j]
+				}
+				dist[string(out)] += p / float64(len(perms))
+			}
+			return
+		}
+		for _, c := range positions[pos] {
+			seq[pos] = c.r
+			walk(pos+1, p*c.p)
+		}
+	}
+	walk(0, 1)
+
+	entropy := 0.0
+	for _, p := range dist {
+		entropy -= p * math.Log2(p)
+	}
+
+	return entropy
+}
@@ -0,0 +1,49 @@
+package passgen
+
+import (
+	"bufio"
+	_ "embed"
+	"slices"
+	"strings"
+	"sync"
+)
+
+var (
+	//go:embed wordlists/eff_large_wordlist.txt
+	effLargeWordlist string
+
+	//go:embed wordlists/eff_short_wordlist_2_0.txt
+	effShortWordlist string
+
+	effLargeWords = sync.OnceValue(func() []string { return parseWordlist(effLargeWordlist) })
+	effShortWords = sync.OnceValue(func() []string { return parseWordlist(effShortWordlist) })
+)
+
+// EFFLargeWordlist returns the 7776 words of the EFF large wordlist
+// (12.9 bits per word).
+func EFFLargeWordlist() []string {
+	return slices.Clone(effLargeWords())
+}
+
+// EFFShortWordlist returns the 1296 words of the EFF short wordlist 2.0
+// (10.3 bits per word).
+func EFFShortWordlist() []string {
+	return slices.Clone(effShortWords())
+}
+
+// parseWordlist reads a diceware list with one "<dice rolls>\t<word>" entry per line.
+func parseWordlist(data string) []string {
+	var words []string
+
+	scanner := bufio.NewScanner(strings.NewReader(data))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+
+		words = append(words, fields[len(fields)-1])
+	}
+
+	return words
+}